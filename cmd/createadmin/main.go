@@ -0,0 +1,85 @@
+// Command createadmin creates a super_admin account, for the first login on a
+// new installation. Other users are then created through POST
+// /api/auth/register, which needs user:write.
+//
+//	ADMIN_PASSWORD=... go run ./cmd/createadmin -username admin -email admin@example.com
+//
+// The password is read from ADMIN_PASSWORD so it does not end up in the shell
+// history. Run cmd/migrate first.
+package main
+
+import (
+	"flag"
+	"os"
+	"sim-clinic-api/internal/config"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/repository"
+	"sim-clinic-api/internal/utils"
+	"sim-clinic-api/pkg/database"
+	logger "sim-clinic-api/pkg/log"
+
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	username := flag.String("username", "", "username of the new account")
+	email := flag.String("email", "", "email of the new account")
+	fullname := flag.String("fullname", "", "full name of the new account")
+	flag.Parse()
+
+	logger.Init()
+
+	request := model.RegisterRequest{
+		Username: *username,
+		Email:    *email,
+		Password: os.Getenv("ADMIN_PASSWORD"),
+		Fullname: *fullname,
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		logrus.Fatal("Error loading config:", err)
+	}
+
+	db, err := database.NewPostgresConnection(cfg)
+	if err != nil {
+		logrus.Fatal("Error connecting to database:", err)
+	}
+	if err := database.CheckMigrated(db); err != nil {
+		logrus.Fatal(err)
+	}
+
+	role, err := repository.NewRoleRepository(db).FindByName("super_admin")
+	if err != nil {
+		logrus.Fatal("Error loading super_admin role:", err)
+	}
+	request.RoleID = role.ID
+	if err := request.Validate(); err != nil {
+		logrus.Fatal("Invalid account, set -username, -email and ADMIN_PASSWORD: ", err)
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	if existing, _ := userRepo.FindByUsername(request.Username); existing != nil {
+		logrus.Fatalf("Username %s already exists", request.Username)
+	}
+	if existing, _ := userRepo.FindByEmail(request.Email); existing != nil {
+		logrus.Fatalf("Email %s already exists", request.Email)
+	}
+
+	hashedPassword, err := utils.HashPassword(request.Password)
+	if err != nil {
+		logrus.Fatal("Error hashing password:", err)
+	}
+
+	user := &model.User{
+		Username: request.Username,
+		Email:    request.Email,
+		Password: hashedPassword,
+		RoleID:   role.ID,
+		Fullname: request.Fullname,
+	}
+	if err := userRepo.Create(user); err != nil {
+		logrus.Fatal("Error creating account:", err)
+	}
+	logrus.Infof("Created super_admin %s", user.Username)
+}
@@ -0,0 +1,66 @@
+// Command jwtkeygen writes a new JWT signing key to <dir>/<kid>.pem.
+//
+//	go run ./cmd/jwtkeygen -dir ./keys -kid 2026-10 -alg EdDSA
+//
+// See utils.KeySet for the rotation procedure.
+package main
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	dir := flag.String("dir", "keys", "directory holding the signing keys")
+	kid := flag.String("kid", time.Now().Format("2006-01-02"), "key id, also used as file name")
+	alg := flag.String("alg", "EdDSA", "key algorithm: EdDSA or RS256")
+	flag.Parse()
+
+	var (
+		key crypto.PrivateKey
+		err error
+	)
+	switch *alg {
+	case "EdDSA":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	case "RS256":
+		key, err = rsa.GenerateKey(rand.Reader, 3072)
+	default:
+		logrus.Fatalf("Unsupported algorithm: %s", *alg)
+	}
+	if err != nil {
+		logrus.Fatal("Error generating key:", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		logrus.Fatal("Error encoding key:", err)
+	}
+
+	if err := os.MkdirAll(*dir, 0o700); err != nil {
+		logrus.Fatal("Error creating key directory:", err)
+	}
+
+	path := filepath.Join(*dir, *kid+".pem")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		logrus.Fatal("Error creating key file:", err)
+	}
+	defer f.Close()
+
+	if err := pem.Encode(f, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		logrus.Fatal("Error writing key file:", err)
+	}
+
+	logrus.Infof("Key written to %s, set JWT_ACTIVE_KEY_ID=%s to sign with it", path, *kid)
+}
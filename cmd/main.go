@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
 	"sim-clinic-api/internal/config"
 	"sim-clinic-api/internal/handler"
+	customMiddleware "sim-clinic-api/internal/middleware"
+	"sim-clinic-api/internal/notification"
 	"sim-clinic-api/internal/repository"
 	"sim-clinic-api/internal/service"
+	"sim-clinic-api/internal/utils"
 	"sim-clinic-api/pkg/database"
 	logger "sim-clinic-api/pkg/log"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
@@ -24,6 +30,11 @@ func main() {
 
 	// Initialize Echo
 	e := echo.New()
+	// IP klien dipakai untuk allowlist API key dan throttle login
+	e.IPExtractor, err = customMiddleware.NewIPExtractor(cfg.TrustedProxies)
+	if err != nil {
+		logrus.Fatal("Error loading trusted proxies:", err)
+	}
 
 	// Initialize database
 	db, err := database.NewPostgresConnection(cfg)
@@ -31,23 +42,158 @@ func main() {
 		logrus.Fatal("Error connecting to database:", err)
 	}
 
-	// Auto migrate
-	// if err := database.AutoMigrate(db); err != nil {
-	// 	logrus.Fatal("Error migrating database:", err)
-	// }
+	// Migrasi dijalankan terpisah lewat go run ./cmd/migrate sebelum deploy
+	if err := database.CheckMigrated(db); err != nil {
+		logrus.Fatal("Error checking database schema:", err)
+	}
+
+	// Initialize notifier; channels without their own adapter use NOTIFIER_DRIVER
+	fallbackNotifier, err := notification.NewNotifier(cfg.NotifierDriver, cfg.NotifierFilePath)
+	if err != nil {
+		logrus.Fatal("Error initializing notifier:", err)
+	}
+	channelNotifiers := make(map[string]notification.Notifier)
+	if cfg.SMTPAddr != "" {
+		channelNotifiers[notification.ChannelEmail] = notification.NewSMTPNotifier(cfg.SMTPAddr, cfg.SMTPFrom, cfg.SMTPUsername, cfg.SMTPPassword)
+	}
+	if cfg.WhatsAppGatewayURL != "" {
+		channelNotifiers[notification.ChannelWhatsApp] = notification.NewGatewayNotifier(cfg.WhatsAppGatewayURL, cfg.WhatsAppGatewayToken)
+	}
+	if cfg.SMSGatewayURL != "" {
+		channelNotifiers[notification.ChannelSMS] = notification.NewGatewayNotifier(cfg.SMSGatewayURL, cfg.SMSGatewayToken)
+	}
+	notifier := notification.NewRouter(fallbackNotifier, channelNotifiers)
+
+	// Load JWT signing keys
+	jwtKeys := utils.NewHMACKeySet(cfg.JWTSecret)
+	if cfg.JWTKeysDir != "" {
+		jwtKeys, err = utils.LoadKeySet(cfg.JWTKeysDir, cfg.JWTActiveKeyID)
+		if err != nil {
+			logrus.Fatal("Error loading JWT keys:", err)
+		}
+	} else {
+		logrus.Warn("JWT_KEYS_DIR not set, signing tokens with shared HS256 secret")
+	}
+
+	// Challenge token MFA ditandatangani terpisah dari access token
+	mfaSecret := cfg.MFAChallengeSecret
+	if mfaSecret == "" {
+		mfaSecret, err = utils.GenerateOpaqueToken(32)
+		if err != nil {
+			logrus.Fatal("Error generating MFA challenge secret:", err)
+		}
+		logrus.Warn("MFA_CHALLENGE_SECRET not set, MFA challenges only verify on this instance")
+	}
+	mfaKeys := utils.NewHMACKeySet(mfaSecret)
+
+	// Jam kerja terapis dibaca dalam zona waktu klinik
+	clinicLocation, err := time.LoadLocation(cfg.ClinicTimezone)
+	if err != nil {
+		logrus.Fatal("Error loading clinic timezone:", err)
+	}
 
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	roleRepo := repository.NewRoleRepository(db)
 	tokenRepo := repository.NewTokenRepository(db)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(db)
+	mfaRepo := repository.NewMFARepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
 	masterDataRepo := repository.NewMasterDataRepository(db)
 	customerRepo := repository.NewCustomerRepository(db)
+	counterRepo := repository.NewCounterRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	medicalHistoryRepo := repository.NewMedicalHistoryRepository(db)
+	treatmentSessionRepo := repository.NewTreatmentSessionRepository(db)
+	clinicalNoteRepo := repository.NewClinicalNoteRepository(db)
+	scheduleRepo := repository.NewScheduleRepository(db)
+	appointmentRepo := repository.NewAppointmentRepository(db)
+	bookingPolicyRepo := repository.NewBookingPolicyRepository(db)
+	calendarTokenRepo := repository.NewCalendarTokenRepository(db)
+	resourceRepo := repository.NewResourceRepository(db)
+	queueRepo := repository.NewQueueRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+
+	// Cache auth dibagi antar replika lewat LISTEN/NOTIFY
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	authCache := service.NewAuthCache(cfg.AuthCacheSize, cfg.AuthUserCacheTTL, func(payload string) error {
+		return database.Notify(db, service.AuthInvalidationChannel, payload)
+	})
+	go database.Listen(ctx, cfg, service.AuthInvalidationChannel, authCache.HandleInvalidation, authCache.Reset)
+	// Perubahan antrian diteruskan ke layar yang tersambung di replika lain
+	queueHub := service.NewQueueHub(func(payload string) error {
+		return database.Notify(db, service.QueueEventsChannel, payload)
+	})
+	go database.Listen(ctx, cfg, service.QueueEventsChannel, queueHub.HandleNotification, nil)
+	go service.RunTokenJanitor(ctx, tokenRepo, sessionRepo, cfg.TokenJanitorInterval)
+
+	notificationPolicy := service.NotificationPolicy{
+		Channel:         cfg.NotificationChannel,
+		DefaultLanguage: cfg.NotificationLanguage,
+		ClinicName:      cfg.ClinicName,
+		Location:        clinicLocation,
+		MaxAttempts:     cfg.NotificationMaxAttempts,
+		RetryBase:       cfg.NotificationRetryBase,
+		PollInterval:    cfg.NotificationPollInterval,
+		ReminderLead:    cfg.NotificationReminderLead,
+	}
+	notificationWorker := service.NewNotificationWorker(outboxRepo, appointmentRepo, customerRepo, notifier, notificationPolicy)
+	go notificationWorker.Run(ctx)
 
 	// Initialize services
-	authService := service.NewAuthService(userRepo, roleRepo, tokenRepo, cfg.JWTSecret, cfg.JWTExpire)
-	userService := service.NewUserService(userRepo)
+	authorizer := service.NewAuthorizer(roleRepo, authCache)
+	authService := service.NewAuthService(
+		userRepo,
+		roleRepo,
+		tokenRepo,
+		loginAttemptRepo,
+		jwtKeys,
+		cfg.JWTExpire,
+		cfg.JWTRefreshExpire,
+		service.LoginPolicy{
+			MaxAttempts:     cfg.LoginMaxAttempts,
+			IPMaxAttempts:   cfg.LoginIPMaxAttempts,
+			AttemptWindow:   cfg.LoginAttemptWindow,
+			LockoutDuration: cfg.LoginLockoutDuration,
+			DelayBase:       cfg.LoginDelayBase,
+			DelayMax:        cfg.LoginDelayMax,
+		},
+		mfaRepo,
+		service.MFAPolicy{
+			Issuer:            cfg.MFAIssuer,
+			RequiredRoles:     cfg.MFARequiredRoles,
+			ChallengeExpire:   cfg.MFAChallengeExpire,
+			RecoveryCodeCount: cfg.MFARecoveryCodeCount,
+			ChallengeKeys:     mfaKeys,
+		},
+		sessionRepo,
+		authCache,
+		authorizer,
+	)
+	userService := service.NewUserService(userRepo, roleRepo, tokenRepo, sessionRepo, notifier, cfg.PasswordResetExpire, authCache, authorizer)
 	masterDataService := service.NewMasterDataService(masterDataRepo)
-	customerService := service.NewCustomerService(customerRepo)
+	customerService := service.NewCustomerService(customerRepo, counterRepo, service.CodeRegisterFormat{
+		Prefix: cfg.CodeRegisterPrefix,
+		Reset:  cfg.CodeRegisterReset,
+		Digits: cfg.CodeRegisterDigits,
+	}, clinicLocation)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, authCache, authorizer)
+	roleService := service.NewRoleService(roleRepo, authCache, authorizer)
+	medicalHistoryService := service.NewMedicalHistoryService(medicalHistoryRepo, customerRepo, masterDataRepo)
+	treatmentSessionService := service.NewTreatmentSessionService(treatmentSessionRepo, medicalHistoryRepo, customerRepo, masterDataRepo, userRepo, clinicalNoteRepo, authorizer)
+	clinicalNoteService := service.NewClinicalNoteService(clinicalNoteRepo, treatmentSessionRepo, customerRepo, authorizer)
+	scheduleService := service.NewScheduleService(scheduleRepo, userRepo, authorizer, clinicLocation)
+	attendanceService := service.NewAttendanceService(appointmentRepo, bookingPolicyRepo, customerRepo, cfg.AppointmentLateCancelWindow)
+	appointmentService := service.NewAppointmentService(appointmentRepo, scheduleRepo, customerRepo, masterDataRepo, userRepo, resourceRepo, authorizer, service.AppointmentPolicy{
+		Location: clinicLocation,
+		SlotStep: cfg.AppointmentSlotStep,
+	}, notificationPolicy, attendanceService)
+	queueService := service.NewQueueService(queueRepo, customerRepo, masterDataRepo, counterRepo, queueHub, clinicLocation)
+	notificationService := service.NewNotificationService(outboxRepo)
+	calendarService := service.NewCalendarService(calendarTokenRepo, appointmentRepo, userRepo, masterDataRepo, resourceRepo, authorizer, cfg.ClinicName)
+	resourceService := service.NewResourceService(resourceRepo, masterDataRepo, clinicLocation)
 
 	// Setup routes
 	handler.SetupRoutes(
@@ -56,6 +202,19 @@ func main() {
 		userService,
 		masterDataService,
 		customerService,
+		apiKeyService,
+		roleService,
+		medicalHistoryService,
+		treatmentSessionService,
+		clinicalNoteService,
+		scheduleService,
+		appointmentService,
+		queueService,
+		notificationService,
+		attendanceService,
+		calendarService,
+		resourceService,
+		authorizer,
 	)
 
 	// Start server
@@ -0,0 +1,61 @@
+// Command migrate brings the database schema up to date and seeds the
+// permissions and default roles. Run it once per deploy, before starting the
+// new version of the API:
+//
+//	go run ./cmd/migrate
+//
+// It creates the tables, the pg_trgm and btree_gist extensions, the
+// appointment overlap constraint and the indexes the API relies on, then gives
+// a registration code to customers created before codes were generated. The
+// API refuses to start against a database that has not been migrated.
+package main
+
+import (
+	"sim-clinic-api/internal/config"
+	"sim-clinic-api/internal/repository"
+	"sim-clinic-api/internal/service"
+	"sim-clinic-api/pkg/database"
+	logger "sim-clinic-api/pkg/log"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	logger.Init()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		logrus.Fatal("Error loading config:", err)
+	}
+
+	db, err := database.NewPostgresConnection(cfg)
+	if err != nil {
+		logrus.Fatal("Error connecting to database:", err)
+	}
+
+	if err := database.AutoMigrate(db); err != nil {
+		logrus.Fatal("Error migrating database:", err)
+	}
+
+	clinicLocation, err := time.LoadLocation(cfg.ClinicTimezone)
+	if err != nil {
+		logrus.Fatal("Error loading clinic timezone:", err)
+	}
+
+	customerService := service.NewCustomerService(
+		repository.NewCustomerRepository(db),
+		repository.NewCounterRepository(db),
+		service.CodeRegisterFormat{
+			Prefix: cfg.CodeRegisterPrefix,
+			Reset:  cfg.CodeRegisterReset,
+			Digits: cfg.CodeRegisterDigits,
+		},
+		clinicLocation,
+	)
+	if err := customerService.BackfillCodeRegisters(); err != nil {
+		logrus.Fatal("Error assigning customer registration codes:", err)
+	}
+	logrus.Info("Database migrated successfully")
+}
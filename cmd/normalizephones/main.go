@@ -0,0 +1,72 @@
+// Command normalizephones rewrites stored customer phone numbers to E.164.
+//
+//	go run ./cmd/normalizephones -dry-run
+//
+// Numbers that cannot be parsed are reported and left as they are. Numbers
+// shared by several customers after normalization are listed so they can be
+// reviewed through GET /api/customer/duplicates.
+package main
+
+import (
+	"flag"
+	"sim-clinic-api/internal/config"
+	"sim-clinic-api/internal/repository"
+	"sim-clinic-api/internal/service"
+	"sim-clinic-api/pkg/database"
+	logger "sim-clinic-api/pkg/log"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report changes without writing them")
+	flag.Parse()
+
+	logger.Init()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		logrus.Fatal("Error loading config:", err)
+	}
+
+	db, err := database.NewPostgresConnection(cfg)
+	if err != nil {
+		logrus.Fatal("Error connecting to database:", err)
+	}
+
+	clinicLocation, err := time.LoadLocation(cfg.ClinicTimezone)
+	if err != nil {
+		logrus.Fatal("Error loading clinic timezone:", err)
+	}
+
+	customerService := service.NewCustomerService(
+		repository.NewCustomerRepository(db),
+		repository.NewCounterRepository(db),
+		service.CodeRegisterFormat{
+			Prefix: cfg.CodeRegisterPrefix,
+			Reset:  cfg.CodeRegisterReset,
+			Digits: cfg.CodeRegisterDigits,
+		},
+		clinicLocation,
+	)
+
+	result, err := customerService.NormalizePhoneNumbers(*dryRun)
+	if err != nil {
+		logrus.Fatal("Error normalizing phone numbers:", err)
+	}
+
+	for id, phoneNumber := range result.Invalid {
+		logrus.Warnf("Customer %s has an invalid phone number: %q", id, phoneNumber)
+	}
+	for phoneNumber, count := range result.Duplicate {
+		logrus.Warnf("Phone number %s is shared by %d customers", phoneNumber, count)
+	}
+	logrus.WithFields(logrus.Fields{
+		"checked": result.Checked,
+		"updated": result.Updated,
+		"invalid": len(result.Invalid),
+		"dry_run": *dryRun,
+	}).Info("Phone number normalization finished")
+}
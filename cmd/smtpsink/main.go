@@ -0,0 +1,161 @@
+// Command smtpsink is a local SMTP stand-in for development. It accepts every
+// message and writes it to the log, or appends it to a file with -out.
+//
+//	go run ./cmd/smtpsink -addr localhost:1025
+//
+// Point the API at it with SMTP_ADDR=localhost:1025. It never delivers mail
+// and accepts any credentials, so it must not be exposed beyond localhost.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"net"
+	"net/textproto"
+	"os"
+	logger "sim-clinic-api/pkg/log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const idleTimeout = 5 * time.Minute
+
+type sink struct {
+	out string
+	mu  sync.Mutex
+}
+
+func main() {
+	addr := flag.String("addr", "localhost:1025", "address to listen on")
+	out := flag.String("out", "", "append received messages to this file instead of logging them")
+	flag.Parse()
+
+	logger.Init()
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		logrus.Fatal("Error listening:", err)
+	}
+	logrus.Infof("SMTP sink listening on %s", listener.Addr())
+
+	s := &sink{out: *out}
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			logrus.Errorf("Accept failed: %v", err)
+			continue
+		}
+		go s.serve(conn)
+	}
+}
+
+// serve speaks just enough SMTP for net/smtp and common mail libraries.
+func (s *sink) serve(conn net.Conn) {
+	defer conn.Close()
+
+	text := textproto.NewConn(conn)
+	reply := func(format string, args ...interface{}) bool {
+		conn.SetDeadline(time.Now().Add(idleTimeout))
+		return text.PrintfLine(format, args...) == nil
+	}
+
+	var from string
+	var to []string
+	if !reply("220 smtpsink ready") {
+		return
+	}
+	for {
+		conn.SetDeadline(time.Now().Add(idleTimeout))
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		var ok bool
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			ok = reply("250-smtpsink\r\n250-8BITMIME\r\n250 AUTH PLAIN LOGIN")
+		case "HELO":
+			ok = reply("250 smtpsink")
+		case "AUTH":
+			// Kredensial apa pun diterima
+			if strings.HasPrefix(strings.ToUpper(arg), "LOGIN") {
+				if !reply("334 VXNlcm5hbWU6") {
+					return
+				}
+				if _, err := text.ReadLine(); err != nil {
+					return
+				}
+				if !reply("334 UGFzc3dvcmQ6") {
+					return
+				}
+				if _, err := text.ReadLine(); err != nil {
+					return
+				}
+			}
+			ok = reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			from = strings.TrimPrefix(arg, "FROM:")
+			to = nil
+			ok = reply("250 OK")
+		case "RCPT":
+			to = append(to, strings.TrimPrefix(arg, "TO:"))
+			ok = reply("250 OK")
+		case "DATA":
+			if !reply("354 End data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			if err := s.record(from, to, data); err != nil {
+				logrus.Errorf("Failed to record message: %v", err)
+				ok = reply("451 could not store message")
+				break
+			}
+			ok = reply("250 OK queued")
+		case "RSET":
+			from, to = "", nil
+			ok = reply("250 OK")
+		case "NOOP":
+			ok = reply("250 OK")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			ok = reply("502 command not implemented")
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+func (s *sink) record(from string, to []string, data []byte) error {
+	if s.out == "" {
+		logrus.WithFields(logrus.Fields{
+			"from": from,
+			"to":   strings.Join(to, ", "),
+		}).Info("\n" + string(data))
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.out, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "=== %s\nFrom: %s\nTo: %s\n\n%s\n", time.Now().Format(time.RFC3339), from, strings.Join(to, ", "), data)
+	return w.Flush()
+}
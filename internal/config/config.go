@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"sim-clinic-api/internal/utils"
 	"time"
 
@@ -10,12 +11,65 @@ import (
 
 var getEnv = utils.GetEnv
 var parseDuration = utils.ParseDuration
+var parseInt = utils.ParseInt
+var parseList = utils.ParseList
 
 type Config struct {
-	AppPort   string
-	AppEnv    string
-	JWTSecret string
-	JWTExpire time.Duration
+	AppPort        string
+	AppEnv         string
+	TrustedProxies []string
+	JWTSecret      string
+	JWTExpire      time.Duration
+
+	JWTRefreshExpire time.Duration
+	JWTKeysDir       string
+	JWTActiveKeyID   string
+
+	PasswordResetExpire time.Duration
+	NotifierDriver      string
+	NotifierFilePath    string
+
+	SMTPAddr             string
+	SMTPFrom             string
+	SMTPUsername         string
+	SMTPPassword         string
+	WhatsAppGatewayURL   string
+	WhatsAppGatewayToken string
+	SMSGatewayURL        string
+	SMSGatewayToken      string
+
+	ClinicName               string
+	NotificationChannel      string
+	NotificationLanguage     string
+	NotificationMaxAttempts  int
+	NotificationRetryBase    time.Duration
+	NotificationPollInterval time.Duration
+	NotificationReminderLead time.Duration
+
+	LoginMaxAttempts     int
+	LoginIPMaxAttempts   int
+	LoginAttemptWindow   time.Duration
+	LoginLockoutDuration time.Duration
+	LoginDelayBase       time.Duration
+	LoginDelayMax        time.Duration
+
+	MFAIssuer            string
+	MFARequiredRoles     []string
+	MFAChallengeExpire   time.Duration
+	MFARecoveryCodeCount int
+	MFAChallengeSecret   string
+
+	AuthCacheSize        int
+	AuthUserCacheTTL     time.Duration
+	TokenJanitorInterval time.Duration
+
+	CodeRegisterPrefix string
+	CodeRegisterReset  string
+	CodeRegisterDigits int
+
+	ClinicTimezone              string
+	AppointmentSlotStep         time.Duration
+	AppointmentLateCancelWindow time.Duration
 
 	DBHost     string
 	DBPort     string
@@ -31,11 +85,65 @@ func LoadConfig() (*Config, error) {
 		logrus.Warn("No .env file found, using environment variables")
 	}
 
-	return &Config{
-		AppPort:   getEnv("APP_PORT", "8080"),
-		AppEnv:    getEnv("APP_ENV", "development"),
-		JWTSecret: getEnv("JWT_SECRET", "secret"),
-		JWTExpire: parseDuration(getEnv("JWT_EXPIRE", "24h")),
+	cfg := &Config{
+		AppPort: getEnv("APP_PORT", "8080"),
+		AppEnv:  getEnv("APP_ENV", "development"),
+		// CIDR proxy yang boleh mengisi X-Forwarded-For; kosong berarti IP koneksi langsung
+		TrustedProxies: parseList(getEnv("TRUSTED_PROXIES", "")),
+		JWTSecret:      getEnv("JWT_SECRET", "secret"),
+		JWTExpire:      parseDuration(getEnv("JWT_EXPIRE", "15m")),
+
+		JWTRefreshExpire: parseDuration(getEnv("JWT_REFRESH_EXPIRE", "168h")),
+		JWTKeysDir:       getEnv("JWT_KEYS_DIR", ""),
+		JWTActiveKeyID:   getEnv("JWT_ACTIVE_KEY_ID", ""),
+
+		PasswordResetExpire: parseDuration(getEnv("PASSWORD_RESET_EXPIRE", "1h")),
+		NotifierDriver:      getEnv("NOTIFIER_DRIVER", "log"),
+		NotifierFilePath:    getEnv("NOTIFIER_FILE_PATH", "notifications.log"),
+
+		// Kosong berarti channel tersebut memakai NOTIFIER_DRIVER
+		SMTPAddr:             getEnv("SMTP_ADDR", ""),
+		SMTPFrom:             getEnv("SMTP_FROM", "no-reply@sim-clinic.local"),
+		SMTPUsername:         getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:         getEnv("SMTP_PASSWORD", ""),
+		WhatsAppGatewayURL:   getEnv("WHATSAPP_GATEWAY_URL", ""),
+		WhatsAppGatewayToken: getEnv("WHATSAPP_GATEWAY_TOKEN", ""),
+		SMSGatewayURL:        getEnv("SMS_GATEWAY_URL", ""),
+		SMSGatewayToken:      getEnv("SMS_GATEWAY_TOKEN", ""),
+
+		ClinicName:               getEnv("CLINIC_NAME", "SIM Clinic"),
+		NotificationChannel:      getEnv("NOTIFICATION_CHANNEL", "whatsapp"),
+		NotificationLanguage:     getEnv("NOTIFICATION_LANGUAGE", "id"),
+		NotificationMaxAttempts:  parseInt(getEnv("NOTIFICATION_MAX_ATTEMPTS", "5"), 5),
+		NotificationRetryBase:    parseDuration(getEnv("NOTIFICATION_RETRY_BASE", "1m")),
+		NotificationPollInterval: parseDuration(getEnv("NOTIFICATION_POLL_INTERVAL", "15s")),
+		NotificationReminderLead: parseDuration(getEnv("NOTIFICATION_REMINDER_LEAD", "24h")),
+
+		LoginMaxAttempts:     parseInt(getEnv("LOGIN_MAX_ATTEMPTS", "5"), 5),
+		LoginIPMaxAttempts:   parseInt(getEnv("LOGIN_IP_MAX_ATTEMPTS", "20"), 20),
+		LoginAttemptWindow:   parseDuration(getEnv("LOGIN_ATTEMPT_WINDOW", "15m")),
+		LoginLockoutDuration: parseDuration(getEnv("LOGIN_LOCKOUT_DURATION", "15m")),
+		LoginDelayBase:       parseDuration(getEnv("LOGIN_DELAY_BASE", "1s")),
+		LoginDelayMax:        parseDuration(getEnv("LOGIN_DELAY_MAX", "30s")),
+
+		MFAIssuer:            getEnv("MFA_ISSUER", "SIM Clinic"),
+		MFARequiredRoles:     parseList(getEnv("MFA_REQUIRED_ROLES", "")),
+		MFAChallengeExpire:   parseDuration(getEnv("MFA_CHALLENGE_EXPIRE", "5m")),
+		MFARecoveryCodeCount: parseInt(getEnv("MFA_RECOVERY_CODE_COUNT", "10"), 10),
+		// Kosong berarti secret acak per proses; set yang sama di semua replika
+		MFAChallengeSecret: getEnv("MFA_CHALLENGE_SECRET", ""),
+
+		AuthCacheSize:        parseInt(getEnv("AUTH_CACHE_SIZE", "10000"), 10000),
+		AuthUserCacheTTL:     parseDuration(getEnv("AUTH_USER_CACHE_TTL", "30s")),
+		TokenJanitorInterval: parseDuration(getEnv("TOKEN_JANITOR_INTERVAL", "1h")),
+
+		CodeRegisterPrefix: getEnv("CODE_REGISTER_PREFIX", "RM"),
+		CodeRegisterReset:  getEnv("CODE_REGISTER_RESET", "month"),
+		CodeRegisterDigits: parseInt(getEnv("CODE_REGISTER_DIGITS", "6"), 6),
+
+		ClinicTimezone:              getEnv("CLINIC_TIMEZONE", "Asia/Jakarta"),
+		AppointmentSlotStep:         parseDuration(getEnv("APPOINTMENT_SLOT_STEP", "15m")),
+		AppointmentLateCancelWindow: parseDuration(getEnv("APPOINTMENT_LATE_CANCEL_WINDOW", "24h")),
 
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "5432"),
@@ -44,5 +152,10 @@ func LoadConfig() (*Config, error) {
 		DBName:     getEnv("DB_NAME", "sim-clinic"),
 		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
 		DBLogLevel: getEnv("DB_LOG_LEVEL", "info"),
-	}, nil
+	}
+
+	if cfg.CodeRegisterReset != "month" && cfg.CodeRegisterReset != "year" {
+		return nil, fmt.Errorf("CODE_REGISTER_RESET must be month or year, got %q", cfg.CodeRegisterReset)
+	}
+	return cfg, nil
 }
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/service"
+	"strconv"
+)
+
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+// CreateAPIKey godoc
+// @Summary Create API key
+// @Description Create a scoped API key for an integration. The key is only returned once.
+// @Tags api-keys
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param request body model.CreateAPIKeyRequest true "Create API Key Request"
+// @Success 201 {object} map[string]interface{} "API key created successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 403 {object} map[string]interface{} "Access denied"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c echo.Context) error {
+	userRole, ok := c.Get("userRole").(string)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	var request model.CreateAPIKeyRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	key, err := h.apiKeyService.CreateAPIKey(request, userRole, userID)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, successResponse(key))
+}
+
+// GetAPIKeys godoc
+// @Summary List API keys
+// @Description List all API keys without their secret
+// @Tags api-keys
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "API keys retrieved successfully"
+// @Failure 403 {object} map[string]interface{} "Access denied"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/api-keys [get]
+func (h *APIKeyHandler) GetAPIKeys(c echo.Context) error {
+	userRole, ok := c.Get("userRole").(string)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	keys, err := h.apiKeyService.GetAPIKeys(userRole)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(keys))
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke API key
+// @Description Revoke an API key immediately
+// @Tags api-keys
+// @Produce  json
+// @Security BearerAuth
+// @Param id path int true "API Key ID"
+// @Success 200 {object} map[string]interface{} "API key revoked successfully"
+// @Failure 403 {object} map[string]interface{} "Access denied"
+// @Failure 404 {object} map[string]interface{} "API key not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c echo.Context) error {
+	userRole, ok := c.Get("userRole").(string)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid API key ID"))
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(uint(id), userRole, userID); err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(map[string]string{
+		"message": "API key revoked successfully",
+	}))
+}
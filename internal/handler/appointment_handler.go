@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"net/http"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/service"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AppointmentHandler struct {
+	appointmentService service.AppointmentService
+}
+
+func NewAppointmentHandler(appointmentService service.AppointmentService) *AppointmentHandler {
+	return &AppointmentHandler{appointmentService: appointmentService}
+}
+
+func (h *AppointmentHandler) GetSlots(c echo.Context) error {
+	var request model.AppointmentSlotRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid query parameters"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	slots, err := h.appointmentService.GetSlots(request)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(slots))
+}
+
+func (h *AppointmentHandler) GetAppointments(c echo.Context) error {
+	var request model.AppointmentListRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid query parameters"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	appointments, err := h.appointmentService.GetAppointments(request)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(appointments))
+}
+
+func (h *AppointmentHandler) GetAppointment(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	appointment, err := h.appointmentService.GetAppointment(uint(id))
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(appointment))
+}
+
+func (h *AppointmentHandler) CreateAppointment(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+	userRole, ok := c.Get("userRole").(string)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	var request model.CreateAppointmentRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	appointment, err := h.appointmentService.CreateAppointment(request, userID, userRole)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, successResponse(appointment))
+}
+
+func (h *AppointmentHandler) RescheduleAppointment(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	var request model.RescheduleAppointmentRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	appointment, err := h.appointmentService.RescheduleAppointment(uint(id), request, userID)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(appointment))
+}
+
+func (h *AppointmentHandler) CancelAppointment(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	var request model.CancelAppointmentRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	appointment, err := h.appointmentService.CancelAppointment(uint(id), request, userID)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(appointment))
+}
+
+func (h *AppointmentHandler) UpdateAppointmentStatus(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	var request model.AppointmentStatusRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	appointment, err := h.appointmentService.UpdateAppointmentStatus(uint(id), request, userID)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(appointment))
+}
+
+func (h *AppointmentHandler) PreviewSeries(c echo.Context) error {
+	var request model.CreateAppointmentSeriesRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	preview, err := h.appointmentService.PreviewSeries(request)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(preview))
+}
+
+func (h *AppointmentHandler) CreateSeries(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+	userRole, ok := c.Get("userRole").(string)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	var request model.CreateAppointmentSeriesRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	series, err := h.appointmentService.CreateSeries(request, userID, userRole)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, successResponse(series))
+}
+
+func (h *AppointmentHandler) GetSeries(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	series, err := h.appointmentService.GetSeries(uint(id))
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(series))
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"net/http"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/service"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type AttendanceHandler struct {
+	attendanceService service.AttendanceService
+}
+
+func NewAttendanceHandler(attendanceService service.AttendanceService) *AttendanceHandler {
+	return &AttendanceHandler{attendanceService: attendanceService}
+}
+
+// ============ BOOKING POLICY HANDLERS ============
+func (h *AttendanceHandler) GetPolicies(c echo.Context) error {
+	policies, err := h.attendanceService.GetPolicies()
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(policies))
+}
+
+func (h *AttendanceHandler) CreatePolicy(c echo.Context) error {
+	var request model.BookingPolicyRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	policy, err := h.attendanceService.CreatePolicy(request)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, successResponse(policy))
+}
+
+func (h *AttendanceHandler) UpdatePolicy(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	var request model.BookingPolicyRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	policy, err := h.attendanceService.UpdatePolicy(uint(id), request)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(policy))
+}
+
+func (h *AttendanceHandler) DeletePolicy(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	if err := h.attendanceService.DeletePolicy(uint(id)); err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(map[string]string{
+		"message": "Booking policy deleted successfully",
+	}))
+}
+
+// ============ ATTENDANCE HANDLERS ============
+func (h *AttendanceHandler) GetAttendance(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	record, err := h.attendanceService.GetAttendance(id)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(record))
+}
+
+func (h *AttendanceHandler) GetFlaggedCustomers(c echo.Context) error {
+	flagged, err := h.attendanceService.GetFlaggedCustomers()
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(flagged))
+}
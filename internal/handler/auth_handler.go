@@ -20,17 +20,24 @@ func NewAuthHandler(authService service.AuthService) *AuthHandler {
 
 // Register godoc
 // @Summary Register a new user
-// @Description Register a new user with username, email, password and role
+// @Description Register a new user with username, email, password and role. The role must be one the caller may assign
 // @Tags auth
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param request body model.RegisterRequest true "Register Request"
 // @Success 201 {object} map[string]interface{} "User created successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 403 {object} map[string]interface{} "Access denied"
 // @Failure 409 {object} map[string]interface{} "User already exists"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /auth/register [post]
 func (h *AuthHandler) Register(c echo.Context) error {
+	userRole, ok := c.Get("userRole").(string)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
 	var request model.RegisterRequest
 
 	if err := c.Bind(&request); err != nil {
@@ -43,7 +50,7 @@ func (h *AuthHandler) Register(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
 	}
 
-	user, err := h.authService.Register(request)
+	user, err := h.authService.Register(request, userRole)
 	if err != nil {
 		return handleServiceError(c, err)
 	}
@@ -71,6 +78,8 @@ func (h *AuthHandler) Register(c echo.Context) error {
 // @Success 200 {object} map[string]interface{} "Login successful"
 // @Failure 400 {object} map[string]interface{} "Invalid input"
 // @Failure 401 {object} map[string]interface{} "Invalid credentials"
+// @Failure 423 {object} map[string]interface{} "Account locked"
+// @Failure 429 {object} map[string]interface{} "Too many failed attempts"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c echo.Context) error {
@@ -86,6 +95,9 @@ func (h *AuthHandler) Login(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
 	}
 
+	request.IPAddress = c.RealIP()
+	request.UserAgent = c.Request().UserAgent()
+
 	response, err := h.authService.Login(request)
 	if err != nil {
 		return handleServiceError(c, err)
@@ -95,6 +107,41 @@ func (h *AuthHandler) Login(c echo.Context) error {
 	return c.JSON(http.StatusOK, successResponse(response))
 }
 
+// RefreshToken godoc
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token and a rotated refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.RefreshTokenRequest true "Refresh Token Request"
+// @Success 200 {object} map[string]interface{} "Token refreshed"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Invalid, expired or reused refresh token"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /auth/refresh [post]
+func (h *AuthHandler) RefreshToken(c echo.Context) error {
+	var request model.RefreshTokenRequest
+
+	if err := c.Bind(&request); err != nil {
+		logrus.Warnf("Invalid refresh request: %v", err)
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		logrus.Warnf("Validation failed: %v", err)
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	request.IPAddress = c.RealIP()
+
+	response, err := h.authService.RefreshToken(request)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(response))
+}
+
 // Logout godoc
 // @Summary Logout user
 // @Description Logout user and invalidate JWT token
@@ -102,6 +149,7 @@ func (h *AuthHandler) Login(c echo.Context) error {
 // @Accept  json
 // @Produce  json
 // @Security BearerAuth
+// @Param request body model.LogoutRequest false "Logout Request"
 // @Success 200 {object} map[string]interface{} "Logout successful"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
@@ -127,8 +175,14 @@ func (h *AuthHandler) Logout(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
 	}
 
+	// Refresh token bersifat opsional
+	var request model.LogoutRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
 	// Call logout service
-	err := h.authService.Logout(tokenString, userID)
+	err := h.authService.Logout(tokenString, request.RefreshToken, userID)
 	if err != nil {
 		return handleServiceError(c, err)
 	}
@@ -139,6 +193,217 @@ func (h *AuthHandler) Logout(c echo.Context) error {
 	}))
 }
 
+// VerifyMFA godoc
+// @Summary Verify second factor
+// @Description Exchange an MFA challenge token and a TOTP or recovery code for an access token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.MFAVerifyRequest true "MFA Verify Request"
+// @Success 200 {object} map[string]interface{} "Login successful"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Invalid code or challenge token"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /auth/mfa/verify [post]
+func (h *AuthHandler) VerifyMFA(c echo.Context) error {
+	var request model.MFAVerifyRequest
+
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	request.IPAddress = c.RealIP()
+	request.UserAgent = c.Request().UserAgent()
+
+	response, err := h.authService.VerifyMFA(request)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(response))
+}
+
+// BeginMFAEnrollmentWithChallenge godoc
+// @Summary Start MFA enrollment during login
+// @Description Generate a TOTP secret for an account whose role requires MFA but has not enrolled yet
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.MFAChallengeRequest true "MFA Challenge Request"
+// @Success 200 {object} map[string]interface{} "Secret and provisioning URI"
+// @Failure 400 {object} map[string]interface{} "MFA already enabled"
+// @Failure 401 {object} map[string]interface{} "Invalid challenge token"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /auth/mfa/enroll [post]
+func (h *AuthHandler) BeginMFAEnrollmentWithChallenge(c echo.Context) error {
+	var request model.MFAChallengeRequest
+
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	response, err := h.authService.BeginMFAEnrollmentWithChallenge(request.MFAToken)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(response))
+}
+
+// BeginMFAEnrollment godoc
+// @Summary Start MFA enrollment
+// @Description Generate a TOTP secret and provisioning URI for the current user
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Secret and provisioning URI"
+// @Failure 400 {object} map[string]interface{} "MFA already enabled"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/users/me/mfa/enroll [post]
+func (h *AuthHandler) BeginMFAEnrollment(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	response, err := h.authService.BeginMFAEnrollment(userID)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(response))
+}
+
+// ConfirmMFAEnrollment godoc
+// @Summary Confirm MFA enrollment
+// @Description Enable MFA with a code from the authenticator app and receive recovery codes
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.MFACodeRequest true "MFA Code Request"
+// @Success 200 {object} map[string]interface{} "Recovery codes"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Invalid code"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/users/me/mfa/confirm [post]
+func (h *AuthHandler) ConfirmMFAEnrollment(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	var request model.MFACodeRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	response, err := h.authService.ConfirmMFAEnrollment(userID, request.Code)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(response))
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate MFA recovery codes
+// @Description Replace all recovery codes of the current user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.MFACodeRequest true "MFA Code Request"
+// @Success 200 {object} map[string]interface{} "Recovery codes"
+// @Failure 400 {object} map[string]interface{} "MFA not enabled"
+// @Failure 401 {object} map[string]interface{} "Invalid code"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/users/me/mfa/recovery-codes [post]
+func (h *AuthHandler) RegenerateRecoveryCodes(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	var request model.MFACodeRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	response, err := h.authService.RegenerateRecoveryCodes(userID, request.Code)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(response))
+}
+
+// DisableMFA godoc
+// @Summary Disable MFA
+// @Description Disable MFA for the current user unless their role requires it
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.MFACodeRequest true "MFA Code Request"
+// @Success 200 {object} map[string]interface{} "MFA disabled"
+// @Failure 401 {object} map[string]interface{} "Invalid code"
+// @Failure 403 {object} map[string]interface{} "MFA required for role"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/users/me/mfa [delete]
+func (h *AuthHandler) DisableMFA(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	var request model.MFACodeRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	if err := h.authService.DisableMFA(userID, request.Code); err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(map[string]string{
+		"message": "MFA disabled successfully",
+	}))
+}
+
+// JWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys for verifying access tokens issued by this service
+// @Tags auth
+// @Produce json
+// @Success 200 {object} utils.JWKS "Key set"
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(c echo.Context) error {
+	// Format standar JWKS, tanpa pembungkus successResponse
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, h.authService.JWKS())
+}
+
 func successResponse(data interface{}) map[string]interface{} {
 	return map[string]interface{}{
 		"success": true,
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"net/http"
+	"sim-clinic-api/internal/service"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+type CalendarHandler struct {
+	calendarService service.CalendarService
+}
+
+func NewCalendarHandler(calendarService service.CalendarService) *CalendarHandler {
+	return &CalendarHandler{calendarService: calendarService}
+}
+
+// ============ CALENDAR TOKEN HANDLERS ============
+func (h *CalendarHandler) GetToken(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	token, err := h.calendarService.GetToken(userID)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(token))
+}
+
+func (h *CalendarHandler) RegenerateToken(c echo.Context) error {
+	userRole, ok := c.Get("userRole").(string)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	baseURL := c.Scheme() + "://" + c.Request().Host
+	token, err := h.calendarService.RegenerateToken(userID, userRole, baseURL)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, successResponse(token))
+}
+
+func (h *CalendarHandler) RevokeToken(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	if err := h.calendarService.RevokeToken(userID); err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(map[string]string{
+		"message": "Calendar token revoked successfully",
+	}))
+}
+
+// ============ CALENDAR FEED HANDLERS ============
+func (h *CalendarHandler) TherapistFeed(c echo.Context) error {
+	feed, err := h.calendarService.TherapistFeed(c.Param("token"))
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return writeCalendar(c, feed)
+}
+
+func (h *CalendarHandler) LayananTerapiFeed(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	feed, err := h.calendarService.LayananTerapiFeed(c.Param("token"), uint(id))
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return writeCalendar(c, feed)
+}
+
+func (h *CalendarHandler) ResourceFeed(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	feed, err := h.calendarService.ResourceFeed(c.Param("token"), uint(id))
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return writeCalendar(c, feed)
+}
+
+func writeCalendar(c echo.Context, feed []byte) error {
+	c.Response().Header().Set(echo.HeaderContentDisposition, `inline; filename="appointments.ics"`)
+	c.Response().Header().Set("Cache-Control", "private, max-age=300")
+	return c.Blob(http.StatusOK, calendarContentType, feed)
+}
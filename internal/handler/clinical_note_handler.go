@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"net/http"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ClinicalNoteHandler struct {
+	clinicalNoteService service.ClinicalNoteService
+}
+
+func NewClinicalNoteHandler(clinicalNoteService service.ClinicalNoteService) *ClinicalNoteHandler {
+	return &ClinicalNoteHandler{clinicalNoteService: clinicalNoteService}
+}
+
+func (h *ClinicalNoteHandler) GetNote(c echo.Context) error {
+	customerID, sessionID, ok := treatmentSessionParams(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	note, err := h.clinicalNoteService.GetNote(customerID, sessionID)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(note))
+}
+
+func (h *ClinicalNoteHandler) CreateNote(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	customerID, sessionID, ok := treatmentSessionParams(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	var request model.ClinicalNoteRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	note, err := h.clinicalNoteService.CreateNote(customerID, sessionID, request, userID)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, successResponse(note))
+}
+
+func (h *ClinicalNoteHandler) UpdateNote(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	customerID, sessionID, ok := treatmentSessionParams(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	var request model.ClinicalNoteRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	note, err := h.clinicalNoteService.UpdateNote(customerID, sessionID, request, userID)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(note))
+}
+
+func (h *ClinicalNoteHandler) SignNote(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	customerID, sessionID, ok := treatmentSessionParams(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	note, err := h.clinicalNoteService.SignNote(customerID, sessionID, userID)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(note))
+}
+
+func (h *ClinicalNoteHandler) DeleteNote(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	customerID, sessionID, ok := treatmentSessionParams(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	if err := h.clinicalNoteService.DeleteNote(customerID, sessionID, userID); err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(map[string]string{
+		"message": "Clinical note deleted successfully",
+	}))
+}
+
+func (h *ClinicalNoteHandler) AddAddendum(c echo.Context) error {
+	userRole, ok := c.Get("userRole").(string)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	customerID, sessionID, ok := treatmentSessionParams(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	var request model.ClinicalNoteAddendumRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	note, err := h.clinicalNoteService.AddAddendum(customerID, sessionID, request, userID, userRole)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, successResponse(note))
+}
@@ -37,7 +37,7 @@ func (h *CustomerHandler) CheckExistCustomer(ctx echo.Context) error {
 
 	custm, err := h.customerService.CheckCustomer(request.PhoneNumber)
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+		return handleServiceError(ctx, err)
 	}
 
 	return ctx.JSON(http.StatusOK, successResponse(custm))
@@ -55,14 +55,12 @@ func (h *CustomerHandler) CreateCustomer(c echo.Context) error {
 	}
 
 	payloadCustomer := model.Customer{
-		Id:                 uuid.New().String(),
 		CustomerName:       request.CustomerName,
 		CustomerAddress:    request.CustomerAddress,
 		City:               request.City,
 		Gender:             request.Gender,
 		PhoneNumber:        request.PhoneNumber,
 		InformedConsent:    request.InformedConsent,
-		CodeRegister:       "Code",
 		SourceTerapistInfo: request.SourceTerapistInfo,
 	}
 	customer, err := h.customerService.CreateCustomer(payloadCustomer)
@@ -75,7 +73,7 @@ func (h *CustomerHandler) CreateCustomer(c echo.Context) error {
 func (h *CustomerHandler) GetCustomers(ctx echo.Context) error {
 	var (
 		tag     = tagCustomerHandler + "GetCustomers."
-		request model.RequestPagination
+		request model.CustomerListRequest
 	)
 
 	if err := ctx.Bind(&request); err != nil {
@@ -88,12 +86,163 @@ func (h *CustomerHandler) GetCustomers(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, errorResponse(err.Error()))
 	}
 
-	cust, err := h.customerService.GetCustomer(request)
+	if err := request.Validate(); err != nil {
+		return ctx.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
 
+	cust, err := h.customerService.GetCustomer(request)
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+		return handleServiceError(ctx, err)
 	}
 
 	return ctx.JSON(http.StatusOK, successResponse(cust))
 
 }
+
+func (h *CustomerHandler) GetCustomerByID(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	customer, err := h.customerService.GetCustomerByID(id)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(customer))
+}
+
+func (h *CustomerHandler) UpdateCustomer(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	var request model.UpdateCustomerRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	customer, err := h.customerService.UpdateCustomer(id, request)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(customer))
+}
+
+func (h *CustomerHandler) DeleteCustomer(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	if err := h.customerService.DeleteCustomer(id); err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(map[string]string{
+		"message": "Customer deleted successfully",
+	}))
+}
+
+func (h *CustomerHandler) RestoreCustomer(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	customer, err := h.customerService.RestoreCustomer(id)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(customer))
+}
+
+func (h *CustomerHandler) UpdateNotificationPreferences(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	var request model.NotificationPreferencesRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	customer, err := h.customerService.UpdateNotificationPreferences(id, request)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(customer))
+}
+
+func (h *CustomerHandler) FindDuplicates(ctx echo.Context) error {
+	var (
+		tag     = tagCustomerHandler + "FindDuplicates."
+		request model.CustomerDuplicatesRequest
+	)
+
+	if err := ctx.Bind(&request); err != nil {
+		logrus.Error(map[string]interface{}{
+			"tag":     tag + "01",
+			"payload": request,
+			"error":   err,
+		})
+		return ctx.JSON(http.StatusBadRequest, errorResponse("Invalid query parameters"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return ctx.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	groups, err := h.customerService.FindDuplicates(request)
+	if err != nil {
+		return handleServiceError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, successResponse(groups))
+}
+
+func (h *CustomerHandler) MergeCustomers(ctx echo.Context) error {
+	var (
+		tag     = tagCustomerHandler + "MergeCustomers."
+		request model.MergeCustomerRequest
+	)
+
+	userID, ok := ctx.Get("userID").(uint)
+	if !ok {
+		return ctx.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	if err := ctx.Bind(&request); err != nil {
+		logrus.Error(map[string]interface{}{
+			"tag":     tag + "01",
+			"payload": request,
+			"error":   err,
+		})
+		return ctx.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return ctx.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	result, err := h.customerService.MergeCustomers(request, userID)
+	if err != nil {
+		return handleServiceError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, successResponse(result))
+}
@@ -167,7 +167,16 @@ func (h *MasterDataHandler) DeleteRiwayatPenyakit(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
 	}
 
-	if err := h.masterDataService.DeleteRiwayatPenyakit(uint(id)); err != nil {
+	// Riwayat yang masih dipakai pasien hanya bisa dihapus dengan pengganti
+	var replacementID uint64
+	if replacement := c.QueryParam("replacement_id"); replacement != "" {
+		replacementID, err = strconv.ParseUint(replacement, 10, 32)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, errorResponse("Invalid replacement_id"))
+		}
+	}
+
+	if err := h.masterDataService.DeleteRiwayatPenyakit(uint(id), uint(replacementID)); err != nil {
 		return handleServiceError(c, err)
 	}
 
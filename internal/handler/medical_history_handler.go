@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"net/http"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/service"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type MedicalHistoryHandler struct {
+	medicalHistoryService service.MedicalHistoryService
+}
+
+func NewMedicalHistoryHandler(medicalHistoryService service.MedicalHistoryService) *MedicalHistoryHandler {
+	return &MedicalHistoryHandler{medicalHistoryService: medicalHistoryService}
+}
+
+func (h *MedicalHistoryHandler) GetMedicalHistory(c echo.Context) error {
+	customerID := c.Param("id")
+	if _, err := uuid.Parse(customerID); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	histories, err := h.medicalHistoryService.GetMedicalHistory(customerID)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(histories))
+}
+
+func (h *MedicalHistoryHandler) CreateMedicalHistory(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	customerID := c.Param("id")
+	if _, err := uuid.Parse(customerID); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	var request model.MedicalHistoryRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	history, err := h.medicalHistoryService.CreateMedicalHistory(customerID, request, userID)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, successResponse(history))
+}
+
+func (h *MedicalHistoryHandler) UpdateMedicalHistory(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	customerID := c.Param("id")
+	if _, err := uuid.Parse(customerID); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	id, err := strconv.ParseUint(c.Param("historyId"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid history ID"))
+	}
+
+	var request model.MedicalHistoryRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	history, err := h.medicalHistoryService.UpdateMedicalHistory(customerID, uint(id), request, userID)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(history))
+}
+
+func (h *MedicalHistoryHandler) DeleteMedicalHistory(c echo.Context) error {
+	customerID := c.Param("id")
+	if _, err := uuid.Parse(customerID); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	id, err := strconv.ParseUint(c.Param("historyId"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid history ID"))
+	}
+
+	if err := h.medicalHistoryService.DeleteMedicalHistory(customerID, uint(id)); err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(map[string]string{
+		"message": "Medical history deleted successfully",
+	}))
+}
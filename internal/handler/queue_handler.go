@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	customMiddleware "sim-clinic-api/internal/middleware"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/service"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// queueHeartbeatInterval keeps idle display connections open through proxies.
+	queueHeartbeatInterval = 20 * time.Second
+	// queueRetryMillis tells EventSource how long to wait before reconnecting.
+	queueRetryMillis = 3000
+)
+
+type QueueHandler struct {
+	queueService service.QueueService
+}
+
+func NewQueueHandler(queueService service.QueueService) *QueueHandler {
+	return &QueueHandler{queueService: queueService}
+}
+
+func (h *QueueHandler) GetQueue(c echo.Context) error {
+	var request model.QueueListRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid query parameters"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	entries, err := h.queueService.GetQueue(request)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(entries))
+}
+
+func (h *QueueHandler) CheckIn(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	var request model.QueueCheckInRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	entry, err := h.queueService.CheckIn(request, userID)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, successResponse(entry))
+}
+
+func (h *QueueHandler) CallNext(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	var request model.QueueCallNextRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	entry, err := h.queueService.CallNext(request, userID)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(entry))
+}
+
+func (h *QueueHandler) Call(c echo.Context) error {
+	return h.performAction(c, model.QueueActionCall)
+}
+
+func (h *QueueHandler) Recall(c echo.Context) error {
+	return h.performAction(c, model.QueueActionRecall)
+}
+
+func (h *QueueHandler) Skip(c echo.Context) error {
+	return h.performAction(c, model.QueueActionSkip)
+}
+
+func (h *QueueHandler) Finish(c echo.Context) error {
+	return h.performAction(c, model.QueueActionFinish)
+}
+
+func (h *QueueHandler) performAction(c echo.Context, action string) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	// Body hanya berisi ruangan dan boleh kosong
+	var request model.QueueCallRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	entry, err := h.queueService.PerformAction(uint(id), action, request, userID)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(entry))
+}
+
+// Stream pushes queue changes to waiting-room displays as Server-Sent Events.
+// A display connecting fresh first receives a "snapshot" event with today's
+// tickets; one reconnecting with Last-Event-ID (or ?last_event_id=) instead
+// receives the "queue" events it missed, or a new snapshot if it missed too
+// many to replay. Passing layanan_terapi_id limits the
+// feed to one layanan terapi. Browser displays, which cannot send headers,
+// authenticate with an API key holding the queue:read scope in ?api_key=.
+// The stream ends when the token or key expires or is revoked.
+func (h *QueueHandler) Stream(c echo.Context) error {
+	var layananTerapiID uint64
+	if value := c.QueryParam("layanan_terapi_id"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, errorResponse("Invalid layanan_terapi_id"))
+		}
+		layananTerapiID = parsed
+	}
+
+	lastEventID := c.Request().Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.QueryParam("last_event_id")
+	}
+	var resumeFrom uint64
+	if lastEventID != "" {
+		parsed, err := strconv.ParseUint(lastEventID, 10, 32)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, errorResponse("Invalid Last-Event-ID"))
+		}
+		resumeFrom = parsed
+	}
+
+	// Berlangganan sebelum membaca database agar tidak ada kejadian yang terlewat
+	events, unsubscribe := h.queueService.Subscribe()
+	defer unsubscribe()
+
+	var snapshot *model.QueueSnapshot
+	var missed []model.QueueEvent
+	complete := false
+	if resumeFrom != 0 {
+		var err error
+		missed, complete, err = h.queueService.EventsAfter(uint(resumeFrom))
+		if err != nil {
+			return handleServiceError(c, err)
+		}
+	}
+	// Layar baru atau yang tertinggal terlalu jauh mulai lagi dari snapshot
+	if !complete {
+		var err error
+		snapshot, err = h.queueService.Snapshot()
+		if err != nil {
+			return handleServiceError(c, err)
+		}
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(res, "retry: %d\n\n", queueRetryMillis); err != nil {
+		return nil
+	}
+
+	// Kejadian live dengan id sampai sini sudah terkirim lewat snapshot atau replay
+	sentUpTo := uint(resumeFrom)
+	if snapshot != nil {
+		if layananTerapiID != 0 {
+			tickets := make([]model.QueueTicket, 0, len(snapshot.Tickets))
+			for _, ticket := range snapshot.Tickets {
+				if uint64(ticket.LayananTerapiID) == layananTerapiID {
+					tickets = append(tickets, ticket)
+				}
+			}
+			snapshot.Tickets = tickets
+		}
+		sentUpTo = snapshot.LastEventID
+		if err := writeServerSentEvent(res, snapshot.LastEventID, "snapshot", snapshot); err != nil {
+			return nil
+		}
+	}
+	for _, event := range missed {
+		if event.ID > sentUpTo {
+			sentUpTo = event.ID
+		}
+		if layananTerapiID != 0 && uint64(event.LayananTerapiID) != layananTerapiID {
+			continue
+		}
+		if err := writeServerSentEvent(res, event.ID, "queue", event); err != nil {
+			return nil
+		}
+	}
+	res.Flush()
+
+	heartbeat := time.NewTicker(queueHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	// Tanpa masa berlaku, kanal nil tidak pernah terpicu
+	var expired <-chan time.Time
+	if expiresAt := customMiddleware.CredentialExpiresAt(c); !expiresAt.IsZero() {
+		expiry := time.NewTimer(time.Until(expiresAt))
+		defer expiry.Stop()
+		expired = expiry.C
+	}
+
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-expired:
+			return nil
+		case <-heartbeat.C:
+			// Sesi yang di-logout atau key yang dicabut memutus layar
+			if !customMiddleware.CredentialValid(c) {
+				return nil
+			}
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case event, ok := <-events:
+			// Tertinggal terlalu jauh; layar akan tersambung ulang dengan Last-Event-ID
+			if !ok {
+				return nil
+			}
+			if event.ID <= sentUpTo {
+				continue
+			}
+			if layananTerapiID != 0 && uint64(event.LayananTerapiID) != layananTerapiID {
+				continue
+			}
+			if err := writeServerSentEvent(res, event.ID, "queue", event); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
+
+func writeServerSentEvent(res *echo.Response, id uint, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(res, "id: %d\nevent: %s\ndata: %s\n\n", id, event, payload)
+	return err
+}
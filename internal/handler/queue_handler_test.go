@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+// stubQueueService replays missed events, or reports that there were too many
+// when complete is false.
+type stubQueueService struct {
+	service.QueueService
+	missed   []model.QueueEvent
+	complete bool
+}
+
+func (s stubQueueService) Subscribe() (<-chan model.QueueEvent, func()) {
+	return make(chan model.QueueEvent), func() {}
+}
+
+func (s stubQueueService) Snapshot() (*model.QueueSnapshot, error) {
+	return &model.QueueSnapshot{QueueDate: "2026-10-18", LastEventID: 900}, nil
+}
+
+func (s stubQueueService) EventsAfter(uint) ([]model.QueueEvent, bool, error) {
+	if !s.complete {
+		return nil, false, nil
+	}
+	return s.missed, true, nil
+}
+
+// streamQueue runs Stream until it has written the catch-up events and
+// returns the response body.
+func streamQueue(t *testing.T, queueService service.QueueService, lastEventID string) string {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/api/queue/stream", nil).WithContext(ctx)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	rec := httptest.NewRecorder()
+
+	if err := NewQueueHandler(queueService).Stream(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Body.String()
+}
+
+func TestQueueStreamResume(t *testing.T) {
+	tests := []struct {
+		name         string
+		lastEventID  string
+		queue        stubQueueService
+		wantSnapshot bool
+		wantEvents   []string
+	}{
+		{
+			name:         "new display",
+			wantSnapshot: true,
+		},
+		{
+			name:        "replay",
+			lastEventID: "5",
+			queue: stubQueueService{
+				complete: true,
+				missed:   []model.QueueEvent{{ID: 6}, {ID: 7}},
+			},
+			wantEvents: []string{"id: 6\n", "id: 7\n"},
+		},
+		{
+			name:         "missed too many",
+			lastEventID:  "5",
+			queue:        stubQueueService{complete: false},
+			wantSnapshot: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := streamQueue(t, tt.queue, tt.lastEventID)
+			if got := strings.Contains(body, "event: snapshot\n"); got != tt.wantSnapshot {
+				t.Errorf("snapshot sent = %v, want %v\n%s", got, tt.wantSnapshot, body)
+			}
+			for _, want := range tt.wantEvents {
+				if !strings.Contains(body, want) {
+					t.Errorf("body misses %q\n%s", want, body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"net/http"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/service"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ResourceHandler struct {
+	resourceService service.ResourceService
+}
+
+func NewResourceHandler(resourceService service.ResourceService) *ResourceHandler {
+	return &ResourceHandler{resourceService: resourceService}
+}
+
+// ============ RESOURCE TYPE HANDLERS ============
+func (h *ResourceHandler) GetTypes(c echo.Context) error {
+	types, err := h.resourceService.GetTypes()
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(types))
+}
+
+func (h *ResourceHandler) CreateType(c echo.Context) error {
+	var request model.ResourceTypeRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	resourceType, err := h.resourceService.CreateType(request)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, successResponse(resourceType))
+}
+
+func (h *ResourceHandler) UpdateType(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	var request model.ResourceTypeRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	resourceType, err := h.resourceService.UpdateType(uint(id), request)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(resourceType))
+}
+
+func (h *ResourceHandler) DeleteType(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	if err := h.resourceService.DeleteType(uint(id)); err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(map[string]string{
+		"message": "Resource type deleted successfully",
+	}))
+}
+
+// ============ RESOURCE HANDLERS ============
+func (h *ResourceHandler) GetResources(c echo.Context) error {
+	var request model.ResourceListRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid query parameters"))
+	}
+
+	resources, err := h.resourceService.GetResources(request)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(resources))
+}
+
+func (h *ResourceHandler) CreateResource(c echo.Context) error {
+	var request model.ResourceRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	resource, err := h.resourceService.CreateResource(request)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, successResponse(resource))
+}
+
+func (h *ResourceHandler) UpdateResource(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	var request model.ResourceRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	resource, err := h.resourceService.UpdateResource(uint(id), request)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(resource))
+}
+
+func (h *ResourceHandler) DeleteResource(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	if err := h.resourceService.DeleteResource(uint(id)); err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(map[string]string{
+		"message": "Resource deleted successfully",
+	}))
+}
+
+// ============ RESOURCE REQUIREMENT HANDLERS ============
+func (h *ResourceHandler) GetRequirements(c echo.Context) error {
+	var request model.ResourceRequirementListRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid query parameters"))
+	}
+
+	requirements, err := h.resourceService.GetRequirements(request)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(requirements))
+}
+
+func (h *ResourceHandler) CreateRequirement(c echo.Context) error {
+	var request model.ResourceRequirementRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	requirement, err := h.resourceService.CreateRequirement(request)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, successResponse(requirement))
+}
+
+func (h *ResourceHandler) DeleteRequirement(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	if err := h.resourceService.DeleteRequirement(uint(id)); err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(map[string]string{
+		"message": "Resource requirement deleted successfully",
+	}))
+}
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/service"
+	"strconv"
+)
+
+type RoleHandler struct {
+	roleService service.RoleService
+}
+
+func NewRoleHandler(roleService service.RoleService) *RoleHandler {
+	return &RoleHandler{roleService: roleService}
+}
+
+// CreateRole godoc
+// @Summary Create role
+// @Description Create a role with a level and a set of permissions
+// @Tags roles
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param request body model.RoleRequest true "Role Request"
+// @Success 201 {object} map[string]interface{} "Role created successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 403 {object} map[string]interface{} "Access denied"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/roles [post]
+func (h *RoleHandler) CreateRole(c echo.Context) error {
+	userRole, ok := c.Get("userRole").(string)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	var request model.RoleRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	role, err := h.roleService.CreateRole(request, userRole)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, successResponse(role))
+}
+
+// GetRoles godoc
+// @Summary List roles
+// @Description List all roles with their permissions
+// @Tags roles
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Roles retrieved successfully"
+// @Failure 403 {object} map[string]interface{} "Access denied"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/roles [get]
+func (h *RoleHandler) GetRoles(c echo.Context) error {
+	userRole, ok := c.Get("userRole").(string)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	roles, err := h.roleService.GetRoles(userRole)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(roles))
+}
+
+// GetRoleByID godoc
+// @Summary Get role by ID
+// @Description Get a role with its permissions
+// @Tags roles
+// @Produce  json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Success 200 {object} map[string]interface{} "Role retrieved successfully"
+// @Failure 403 {object} map[string]interface{} "Access denied"
+// @Failure 404 {object} map[string]interface{} "Role not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/roles/{id} [get]
+func (h *RoleHandler) GetRoleByID(c echo.Context) error {
+	userRole, ok := c.Get("userRole").(string)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid role ID"))
+	}
+
+	role, err := h.roleService.GetRoleByID(uint(id), userRole)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(role))
+}
+
+// UpdateRole godoc
+// @Summary Update role
+// @Description Update a role and replace its permissions. Permissions the caller does not hold stay as they are
+// @Tags roles
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Param request body model.RoleRequest true "Role Request"
+// @Success 200 {object} map[string]interface{} "Role updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 403 {object} map[string]interface{} "Access denied"
+// @Failure 404 {object} map[string]interface{} "Role not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/roles/{id} [put]
+func (h *RoleHandler) UpdateRole(c echo.Context) error {
+	userRole, ok := c.Get("userRole").(string)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid role ID"))
+	}
+
+	var request model.RoleRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	role, err := h.roleService.UpdateRole(uint(id), request, userRole)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(role))
+}
+
+// DeleteRole godoc
+// @Summary Delete role
+// @Description Delete a role that is not a system role and has no users
+// @Tags roles
+// @Produce  json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Success 200 {object} map[string]interface{} "Role deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Role still in use"
+// @Failure 403 {object} map[string]interface{} "Access denied"
+// @Failure 404 {object} map[string]interface{} "Role not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/roles/{id} [delete]
+func (h *RoleHandler) DeleteRole(c echo.Context) error {
+	userRole, ok := c.Get("userRole").(string)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid role ID"))
+	}
+
+	if err := h.roleService.DeleteRole(uint(id), userRole); err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(map[string]string{
+		"message": "Role deleted successfully",
+	}))
+}
+
+// GetPermissions godoc
+// @Summary List permissions
+// @Description List every permission that can be granted to a role
+// @Tags roles
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Permissions retrieved successfully"
+// @Failure 403 {object} map[string]interface{} "Access denied"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/roles/permissions [get]
+func (h *RoleHandler) GetPermissions(c echo.Context) error {
+	userRole, ok := c.Get("userRole").(string)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	permissions, err := h.roleService.GetPermissions(userRole)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(permissions))
+}
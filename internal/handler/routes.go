@@ -2,6 +2,7 @@ package handler
 
 import (
 	customMiddleware "sim-clinic-api/internal/middleware"
+	"sim-clinic-api/internal/model"
 	"sim-clinic-api/internal/service"
 
 	"github.com/labstack/echo/v4"
@@ -16,28 +17,72 @@ func SetupRoutes(
 	userService service.UserService,
 	masterService service.MasterDataService,
 	customerService service.CustomerService,
+	apiKeyService service.APIKeyService,
+	roleService service.RoleService,
+	medicalHistoryService service.MedicalHistoryService,
+	treatmentSessionService service.TreatmentSessionService,
+	clinicalNoteService service.ClinicalNoteService,
+	scheduleService service.ScheduleService,
+	appointmentService service.AppointmentService,
+	queueService service.QueueService,
+	notificationService service.NotificationService,
+	attendanceService service.AttendanceService,
+	calendarService service.CalendarService,
+	resourceService service.ResourceService,
+	authorizer *service.Authorizer,
 ) {
+	// Setiap route wajib punya permission atau ditandai public
+	policies := customMiddleware.RecordRoutePolicies(e)
+	can := func(permission string) echo.MiddlewareFunc {
+		return customMiddleware.RequirePermission(authorizer, permission)
+	}
+	public := customMiddleware.Public()
+	authenticated := customMiddleware.Authenticated()
+
 	// Middleware
-	e.Use(middleware.Logger())
+	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{Skipper: hasSecretPath}))
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 	e.Use(middleware.RequestID())
 	e.Use(LoggingMiddleware())
-	e.Use(customMiddleware.AuthMiddleware(authService))
+	e.Use(customMiddleware.AuthMiddleware(authService, apiKeyService))
 
 	// Swagger
-	e.GET("/swagger/*", echoSwagger.WrapHandler)
+	e.GET("/swagger/*", echoSwagger.WrapHandler, public)
 
 	// Health check
 	e.GET("/health", func(c echo.Context) error {
 		return c.JSON(200, map[string]string{"status": "OK"})
-	})
+	}, public)
 
 	// Initialize handlers
 	authHandler := NewAuthHandler(authService)
 	userHandler := NewUserHandler(userService)
 	masterHandler := NewMasterDataHandler(masterService)
 	customerHandler := NewCustomerHandler(customerService)
+	apiKeyHandler := NewAPIKeyHandler(apiKeyService)
+	roleHandler := NewRoleHandler(roleService)
+	medicalHistoryHandler := NewMedicalHistoryHandler(medicalHistoryService)
+	treatmentSessionHandler := NewTreatmentSessionHandler(treatmentSessionService)
+	clinicalNoteHandler := NewClinicalNoteHandler(clinicalNoteService)
+	scheduleHandler := NewScheduleHandler(scheduleService)
+	appointmentHandler := NewAppointmentHandler(appointmentService)
+	queueHandler := NewQueueHandler(queueService)
+	notificationHandler := NewNotificationHandler(notificationService)
+	attendanceHandler := NewAttendanceHandler(attendanceService)
+	calendarHandler := NewCalendarHandler(calendarService)
+	resourceHandler := NewResourceHandler(resourceService)
+
+	// Public keys untuk verifikasi token oleh service lain
+	e.GET("/.well-known/jwks.json", authHandler.JWKS, public)
+
+	// Feed iCalendar untuk aplikasi kalender; token rahasia di URL menggantikan login
+	calendar := e.Group("/calendar")
+	{
+		calendar.GET("/:token/appointments.ics", calendarHandler.TherapistFeed, public)
+		calendar.GET("/:token/layanan-terapi/:id/appointments.ics", calendarHandler.LayananTerapiFeed, public)
+		calendar.GET("/:token/resources/:id/appointments.ics", calendarHandler.ResourceFeed, public)
+	}
 
 	// API Group dengan prefix api
 	api := e.Group("/api")
@@ -45,73 +90,229 @@ func SetupRoutes(
 		// Auth routes
 		auth := api.Group("/auth")
 		{
-			auth.POST("/register", authHandler.Register)
-			auth.POST("/login", authHandler.Login)
-			auth.POST("/logout", authHandler.Logout)
+			auth.POST("/register", authHandler.Register, can(model.PermUserWrite))
+			auth.POST("/login", authHandler.Login, public)
+			auth.POST("/refresh", authHandler.RefreshToken, public)
+			auth.POST("/password-reset", userHandler.ResetPassword, public)
+			auth.POST("/mfa/verify", authHandler.VerifyMFA, public)
+			auth.POST("/mfa/enroll", authHandler.BeginMFAEnrollmentWithChallenge, public)
+			auth.POST("/logout", authHandler.Logout, authenticated)
 		}
 
 		// Users Routes (protected)
 		users := api.Group("/users")
-		users.Use(customMiddleware.AuthMiddleware(authService))
+		users.Use(customMiddleware.AuthMiddleware(authService, apiKeyService))
 		{
-			users.GET("", userHandler.GetAllUsers)
-			users.GET("/:id", userHandler.GetUserByID)
-			users.PUT("/:id", userHandler.UpdateUser) // Tambahkan ini
-			users.DELETE("/:id", userHandler.DeleteUser)
+			users.GET("", userHandler.GetAllUsers, can(model.PermUserRead))
+			users.PUT("/me/password", userHandler.ChangePassword, authenticated)
+			users.GET("/me/sessions", userHandler.GetMySessions, authenticated)
+			users.DELETE("/me/sessions", userHandler.RevokeAllMySessions, authenticated)
+			users.DELETE("/me/sessions/:id", userHandler.RevokeMySession, authenticated)
+			users.POST("/me/mfa/enroll", authHandler.BeginMFAEnrollment, authenticated)
+			users.POST("/me/mfa/confirm", authHandler.ConfirmMFAEnrollment, authenticated)
+			users.POST("/me/mfa/recovery-codes", authHandler.RegenerateRecoveryCodes, authenticated)
+			users.DELETE("/me/mfa", authHandler.DisableMFA, authenticated)
+			users.GET("/me/calendar-token", calendarHandler.GetToken, can(model.PermAppointmentRead))
+			users.POST("/me/calendar-token", calendarHandler.RegenerateToken, can(model.PermAppointmentRead))
+			users.DELETE("/me/calendar-token", calendarHandler.RevokeToken, can(model.PermAppointmentRead))
+			// Profil sendiri selalu boleh, sisanya dicek di service
+			users.GET("/:id", userHandler.GetUserByID, authenticated)
+			users.PUT("/:id", userHandler.UpdateUser, authenticated)
+			users.DELETE("/:id", userHandler.DeleteUser, can(model.PermUserDelete))
+			users.POST("/:id/password-reset", userHandler.RequestPasswordReset, can(model.PermUserWrite))
+			users.POST("/:id/unlock", userHandler.UnlockUser, can(model.PermUserWrite))
+			users.DELETE("/:id/sessions", userHandler.RevokeUserSessions, can(model.PermUserWrite))
+		}
+
+		// Roles & permissions
+		roles := api.Group("/roles")
+		roles.Use(customMiddleware.AuthMiddleware(authService, apiKeyService))
+		{
+			roles.GET("", roleHandler.GetRoles, can(model.PermRoleRead))
+			roles.GET("/permissions", roleHandler.GetPermissions, can(model.PermRoleRead))
+			roles.POST("", roleHandler.CreateRole, can(model.PermRoleWrite))
+			roles.GET("/:id", roleHandler.GetRoleByID, can(model.PermRoleRead))
+			roles.PUT("/:id", roleHandler.UpdateRole, can(model.PermRoleWrite))
+			roles.DELETE("/:id", roleHandler.DeleteRole, can(model.PermRoleWrite))
 		}
 
 		master := api.Group("/master")
-		master.Use(customMiddleware.AuthMiddleware(authService))
+		master.Use(customMiddleware.AuthMiddleware(authService, apiKeyService))
 		{
 			// Layanan Terapi
 			layanan := master.Group("/layanan-terapi")
 			{
-				layanan.POST("", masterHandler.CreateLayananTerapi)
-				layanan.GET("", masterHandler.GetAllLayananTerapi)
-				layanan.GET("/:id", masterHandler.GetLayananTerapiByID)
-				layanan.PUT("/:id", masterHandler.UpdateLayananTerapi)
-				layanan.DELETE("/:id", masterHandler.DeleteLayananTerapi)
+				layanan.POST("", masterHandler.CreateLayananTerapi, can(model.PermMasterWrite))
+				layanan.GET("", masterHandler.GetAllLayananTerapi, can(model.PermMasterRead))
+				layanan.GET("/:id", masterHandler.GetLayananTerapiByID, can(model.PermMasterRead))
+				layanan.PUT("/:id", masterHandler.UpdateLayananTerapi, can(model.PermMasterWrite))
+				layanan.DELETE("/:id", masterHandler.DeleteLayananTerapi, can(model.PermMasterWrite))
 			}
 
 			// Riwayat Penyakit
 			riwayat := master.Group("/riwayat-penyakit")
 			{
-				riwayat.POST("", masterHandler.CreateRiwayatPenyakit)
-				riwayat.GET("", masterHandler.GetAllRiwayatPenyakit)
-				riwayat.GET("/:id", masterHandler.GetRiwayatPenyakitByID)
-				riwayat.PUT("/:id", masterHandler.UpdateRiwayatPenyakit)
-				riwayat.DELETE("/:id", masterHandler.DeleteRiwayatPenyakit)
+				riwayat.POST("", masterHandler.CreateRiwayatPenyakit, can(model.PermMasterWrite))
+				riwayat.GET("", masterHandler.GetAllRiwayatPenyakit, can(model.PermMasterRead))
+				riwayat.GET("/:id", masterHandler.GetRiwayatPenyakitByID, can(model.PermMasterRead))
+				riwayat.PUT("/:id", masterHandler.UpdateRiwayatPenyakit, can(model.PermMasterWrite))
+				riwayat.DELETE("/:id", masterHandler.DeleteRiwayatPenyakit, can(model.PermMasterWrite))
 			}
 
 			// Teknik Terapi
 			teknik := master.Group("/teknik-terapi")
 			{
-				teknik.POST("", masterHandler.CreateTeknikTerapi)
-				teknik.GET("", masterHandler.GetAllTeknikTerapi)
-				teknik.GET("/:id", masterHandler.GetTeknikTerapiByID)
-				teknik.PUT("/:id", masterHandler.UpdateTeknikTerapi)
-				teknik.DELETE("/:id", masterHandler.DeleteTeknikTerapi)
+				teknik.POST("", masterHandler.CreateTeknikTerapi, can(model.PermMasterWrite))
+				teknik.GET("", masterHandler.GetAllTeknikTerapi, can(model.PermMasterRead))
+				teknik.GET("/:id", masterHandler.GetTeknikTerapiByID, can(model.PermMasterRead))
+				teknik.PUT("/:id", masterHandler.UpdateTeknikTerapi, can(model.PermMasterWrite))
+				teknik.DELETE("/:id", masterHandler.DeleteTeknikTerapi, can(model.PermMasterWrite))
 			}
 		}
 
 		customer := api.Group("/customer")
-		customer.Use(customMiddleware.AuthMiddleware(authService))
+		customer.Use(customMiddleware.AuthMiddleware(authService, apiKeyService))
+		{
+			customer.GET("", customerHandler.GetCustomers, can(model.PermCustomerRead))
+			customer.GET("/check/:phoneNumber", customerHandler.CheckExistCustomer, can(model.PermCustomerRead))
+			customer.GET("/duplicates", customerHandler.FindDuplicates, can(model.PermCustomerRead))
+			customer.GET("/flagged", attendanceHandler.GetFlaggedCustomers, can(model.PermAppointmentRead))
+			customer.POST("", customerHandler.CreateCustomer, can(model.PermCustomerWrite))
+			customer.POST("/merge", customerHandler.MergeCustomers, can(model.PermCustomerMerge))
+			customer.GET("/:id", customerHandler.GetCustomerByID, can(model.PermCustomerRead))
+			customer.PUT("/:id", customerHandler.UpdateCustomer, can(model.PermCustomerWrite))
+			customer.DELETE("/:id", customerHandler.DeleteCustomer, can(model.PermCustomerDelete))
+			customer.POST("/:id/restore", customerHandler.RestoreCustomer, can(model.PermCustomerDelete))
+			customer.PUT("/:id/notification-preferences", customerHandler.UpdateNotificationPreferences, can(model.PermCustomerWrite))
+			customer.GET("/:id/attendance", attendanceHandler.GetAttendance, can(model.PermAppointmentRead))
+
+			// Riwayat penyakit pasien
+			customer.GET("/:id/medical-history", medicalHistoryHandler.GetMedicalHistory, can(model.PermMedicalRead))
+			customer.POST("/:id/medical-history", medicalHistoryHandler.CreateMedicalHistory, can(model.PermMedicalWrite))
+			customer.PUT("/:id/medical-history/:historyId", medicalHistoryHandler.UpdateMedicalHistory, can(model.PermMedicalWrite))
+			customer.DELETE("/:id/medical-history/:historyId", medicalHistoryHandler.DeleteMedicalHistory, can(model.PermMedicalWrite))
+
+			// Sesi terapi dan timeline pasien
+			customer.GET("/:id/sessions", treatmentSessionHandler.GetTreatmentSessions, can(model.PermMedicalRead))
+			customer.POST("/:id/sessions", treatmentSessionHandler.CreateTreatmentSession, can(model.PermMedicalWrite))
+			customer.GET("/:id/sessions/:sessionId", treatmentSessionHandler.GetTreatmentSession, can(model.PermMedicalRead))
+			customer.PUT("/:id/sessions/:sessionId", treatmentSessionHandler.UpdateTreatmentSession, can(model.PermMedicalWrite))
+			customer.DELETE("/:id/sessions/:sessionId", treatmentSessionHandler.DeleteTreatmentSession, can(model.PermMedicalWrite))
+			customer.GET("/:id/timeline", treatmentSessionHandler.GetTimeline, can(model.PermMedicalRead))
+
+			// Catatan SOAP per sesi, hanya penulis yang boleh mengubah sebelum ditandatangani
+			customer.GET("/:id/sessions/:sessionId/note", clinicalNoteHandler.GetNote, can(model.PermMedicalRead))
+			customer.POST("/:id/sessions/:sessionId/note", clinicalNoteHandler.CreateNote, can(model.PermMedicalWrite))
+			customer.PUT("/:id/sessions/:sessionId/note", clinicalNoteHandler.UpdateNote, can(model.PermMedicalWrite))
+			customer.DELETE("/:id/sessions/:sessionId/note", clinicalNoteHandler.DeleteNote, can(model.PermMedicalWrite))
+			customer.POST("/:id/sessions/:sessionId/note/sign", clinicalNoteHandler.SignNote, can(model.PermMedicalWrite))
+			customer.POST("/:id/sessions/:sessionId/note/addenda", clinicalNoteHandler.AddAddendum, can(model.PermMedicalWrite))
+		}
+
+		// Jadwal kerja terapis, cuti dan hari libur
+		schedules := api.Group("/schedules")
+		schedules.Use(customMiddleware.AuthMiddleware(authService, apiKeyService))
+		{
+			schedules.GET("/working-hours", scheduleHandler.GetWorkingHours, can(model.PermAppointmentRead))
+			schedules.POST("/working-hours", scheduleHandler.CreateWorkingHour, can(model.PermScheduleManage))
+			schedules.PUT("/working-hours/:id", scheduleHandler.UpdateWorkingHour, can(model.PermScheduleManage))
+			schedules.DELETE("/working-hours/:id", scheduleHandler.DeleteWorkingHour, can(model.PermScheduleManage))
+			schedules.GET("/exceptions", scheduleHandler.GetExceptions, can(model.PermAppointmentRead))
+			schedules.POST("/exceptions", scheduleHandler.CreateException, can(model.PermScheduleManage))
+			schedules.DELETE("/exceptions/:id", scheduleHandler.DeleteException, can(model.PermScheduleManage))
+		}
+
+		appointments := api.Group("/appointments")
+		appointments.Use(customMiddleware.AuthMiddleware(authService, apiKeyService))
+		{
+			appointments.GET("", appointmentHandler.GetAppointments, can(model.PermAppointmentRead))
+			appointments.POST("", appointmentHandler.CreateAppointment, can(model.PermAppointmentWrite))
+			appointments.GET("/slots", appointmentHandler.GetSlots, can(model.PermAppointmentRead))
+			appointments.POST("/series", appointmentHandler.CreateSeries, can(model.PermAppointmentWrite))
+			appointments.POST("/series/preview", appointmentHandler.PreviewSeries, can(model.PermAppointmentRead))
+			appointments.GET("/series/:id", appointmentHandler.GetSeries, can(model.PermAppointmentRead))
+			appointments.GET("/:id", appointmentHandler.GetAppointment, can(model.PermAppointmentRead))
+			appointments.PUT("/:id/reschedule", appointmentHandler.RescheduleAppointment, can(model.PermAppointmentWrite))
+			appointments.POST("/:id/cancel", appointmentHandler.CancelAppointment, can(model.PermAppointmentWrite))
+			appointments.POST("/:id/status", appointmentHandler.UpdateAppointmentStatus, can(model.PermAppointmentWrite))
+		}
+
+		// Kebijakan booking untuk pasien yang sering tidak datang atau batal mendadak
+		bookingPolicies := api.Group("/booking-policies")
+		bookingPolicies.Use(customMiddleware.AuthMiddleware(authService, apiKeyService))
+		{
+			bookingPolicies.GET("", attendanceHandler.GetPolicies, can(model.PermAppointmentRead))
+			bookingPolicies.POST("", attendanceHandler.CreatePolicy, can(model.PermBookingPolicyManage))
+			bookingPolicies.PUT("/:id", attendanceHandler.UpdatePolicy, can(model.PermBookingPolicyManage))
+			bookingPolicies.DELETE("/:id", attendanceHandler.DeletePolicy, can(model.PermBookingPolicyManage))
+		}
+
+		// Ruangan dan alat terapi yang dipesan bersama janji
+		resources := api.Group("/resources")
+		resources.Use(customMiddleware.AuthMiddleware(authService, apiKeyService))
 		{
-			customer.GET("", customerHandler.GetCustomers)
-			customer.GET("/check/:phoneNumber", customerHandler.CheckExistCustomer)
-			customer.POST("", customerHandler.CreateCustomer)
+			resources.GET("/types", resourceHandler.GetTypes, can(model.PermAppointmentRead))
+			resources.POST("/types", resourceHandler.CreateType, can(model.PermResourceManage))
+			resources.PUT("/types/:id", resourceHandler.UpdateType, can(model.PermResourceManage))
+			resources.DELETE("/types/:id", resourceHandler.DeleteType, can(model.PermResourceManage))
+			resources.GET("/requirements", resourceHandler.GetRequirements, can(model.PermAppointmentRead))
+			resources.POST("/requirements", resourceHandler.CreateRequirement, can(model.PermResourceManage))
+			resources.DELETE("/requirements/:id", resourceHandler.DeleteRequirement, can(model.PermResourceManage))
+			resources.GET("", resourceHandler.GetResources, can(model.PermAppointmentRead))
+			resources.POST("", resourceHandler.CreateResource, can(model.PermResourceManage))
+			resources.PUT("/:id", resourceHandler.UpdateResource, can(model.PermResourceManage))
+			resources.DELETE("/:id", resourceHandler.DeleteResource, can(model.PermResourceManage))
+		}
+
+		// Antrian walk-in harian dan feed layar ruang tunggu
+		queue := api.Group("/queue")
+		queue.Use(customMiddleware.AuthMiddleware(authService, apiKeyService))
+		{
+			queue.GET("", queueHandler.GetQueue, can(model.PermQueueRead))
+			queue.POST("", queueHandler.CheckIn, can(model.PermQueueWrite))
+			queue.GET("/stream", queueHandler.Stream, can(model.PermQueueRead))
+			queue.POST("/next", queueHandler.CallNext, can(model.PermQueueWrite))
+			queue.POST("/:id/call", queueHandler.Call, can(model.PermQueueWrite))
+			queue.POST("/:id/recall", queueHandler.Recall, can(model.PermQueueWrite))
+			queue.POST("/:id/skip", queueHandler.Skip, can(model.PermQueueWrite))
+			queue.POST("/:id/finish", queueHandler.Finish, can(model.PermQueueWrite))
+		}
+
+		// Outbox pesan untuk pasien
+		notifications := api.Group("/notifications")
+		notifications.Use(customMiddleware.AuthMiddleware(authService, apiKeyService))
+		{
+			notifications.GET("", notificationHandler.GetMessages, can(model.PermNotificationManage))
+			notifications.POST("/:id/retry", notificationHandler.RetryMessage, can(model.PermNotificationManage))
+		}
+
+		// API key untuk integrasi antar sistem
+		apiKeys := api.Group("/api-keys")
+		apiKeys.Use(customMiddleware.AuthMiddleware(authService, apiKeyService))
+		{
+			apiKeys.POST("", apiKeyHandler.CreateAPIKey, can(model.PermAPIKeyManage))
+			apiKeys.GET("", apiKeyHandler.GetAPIKeys, can(model.PermAPIKeyManage))
+			apiKeys.DELETE("/:id", apiKeyHandler.RevokeAPIKey, can(model.PermAPIKeyManage))
 		}
 	}
 
+	if err := policies.Verify(); err != nil {
+		logrus.Fatal(err)
+	}
 }
 
 func LoggingMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			uri := c.Request().URL.Path
+			if hasSecretPath(c) {
+				uri = c.Path()
+			}
+
 			// Before request
 			logrus.WithFields(logrus.Fields{
 				"method": c.Request().Method,
-				"uri":    c.Request().URL.Path,
+				"uri":    uri,
 				"ip":     c.RealIP(),
 			}).Info("Incoming request")
 
@@ -121,14 +322,14 @@ func LoggingMiddleware() echo.MiddlewareFunc {
 			if err != nil {
 				logrus.WithFields(logrus.Fields{
 					"method": c.Request().Method,
-					"uri":    c.Request().URL.Path,
+					"uri":    uri,
 					"status": c.Response().Status,
 					"error":  err.Error(),
 				}).Error("Request failed")
 			} else {
 				logrus.WithFields(logrus.Fields{
 					"method": c.Request().Method,
-					"uri":    c.Request().URL.Path,
+					"uri":    uri,
 					"status": c.Response().Status,
 				}).Info("Request completed")
 			}
@@ -137,3 +338,9 @@ func LoggingMiddleware() echo.MiddlewareFunc {
 		}
 	}
 }
+
+// hasSecretPath reports whether the request URL carries a secret, such as a
+// calendar feed token, that must not end up in the logs.
+func hasSecretPath(c echo.Context) bool {
+	return c.Param("token") != "" || c.QueryParam(customMiddleware.APIKeyQueryParam) != ""
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sim-clinic-api/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// stubCalendarService answers the feed requests; the other methods are not
+// reached by these tests.
+type stubCalendarService struct {
+	service.CalendarService
+}
+
+func (stubCalendarService) TherapistFeed(string) ([]byte, error) {
+	return []byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"), nil
+}
+
+func (stubCalendarService) LayananTerapiFeed(string, uint) ([]byte, error) {
+	return []byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"), nil
+}
+
+func (stubCalendarService) ResourceFeed(string, uint) ([]byte, error) {
+	return []byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"), nil
+}
+
+// setupTestRoutes registers every route with stub services. SetupRoutes
+// exits when the route policy check fails, so the exit is turned into a
+// test failure.
+func setupTestRoutes(t *testing.T) *echo.Echo {
+	t.Helper()
+
+	logger := logrus.StandardLogger()
+	exit := logger.ExitFunc
+	failed := false
+	logger.ExitFunc = func(int) { failed = true }
+	t.Cleanup(func() { logger.ExitFunc = exit })
+
+	e := echo.New()
+	SetupRoutes(e, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		stubCalendarService{}, nil, nil)
+	if failed {
+		t.Fatal("SetupRoutes failed the route policy check")
+	}
+	return e
+}
+
+func TestSetupRoutesPassesPolicyCheck(t *testing.T) {
+	setupTestRoutes(t)
+}
+
+func TestCalendarFeedsNeedNoLogin(t *testing.T) {
+	e := setupTestRoutes(t)
+
+	paths := []string{
+		"/calendar/secret/appointments.ics",
+		"/calendar/secret/layanan-terapi/1/appointments.ics",
+		"/calendar/secret/resources/1/appointments.ics",
+	}
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET %s = %d, want %d", path, rec.Code, http.StatusOK)
+			}
+			if got := rec.Header().Get(echo.HeaderContentType); got != calendarContentType {
+				t.Errorf("Content-Type = %q, want %q", got, calendarContentType)
+			}
+		})
+	}
+}
+
+func TestRegisterNeedsLogin(t *testing.T) {
+	e := setupTestRoutes(t)
+
+	body := `{"username":"intruder","email":"intruder@example.com","password":"secret123","role_id":1}`
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/register", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("POST /api/auth/register = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"net/http"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/service"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type TreatmentSessionHandler struct {
+	treatmentSessionService service.TreatmentSessionService
+}
+
+func NewTreatmentSessionHandler(treatmentSessionService service.TreatmentSessionService) *TreatmentSessionHandler {
+	return &TreatmentSessionHandler{treatmentSessionService: treatmentSessionService}
+}
+
+func (h *TreatmentSessionHandler) GetTreatmentSessions(c echo.Context) error {
+	customerID := c.Param("id")
+	if _, err := uuid.Parse(customerID); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	var request model.TimelineRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid query parameters"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	sessions, err := h.treatmentSessionService.GetTreatmentSessions(customerID, request)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(sessions))
+}
+
+func (h *TreatmentSessionHandler) GetTreatmentSession(c echo.Context) error {
+	customerID, id, ok := treatmentSessionParams(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	session, err := h.treatmentSessionService.GetTreatmentSession(customerID, id)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(session))
+}
+
+func (h *TreatmentSessionHandler) CreateTreatmentSession(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	customerID := c.Param("id")
+	if _, err := uuid.Parse(customerID); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	var request model.TreatmentSessionRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	session, err := h.treatmentSessionService.CreateTreatmentSession(customerID, request, userID)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, successResponse(session))
+}
+
+func (h *TreatmentSessionHandler) UpdateTreatmentSession(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	customerID, id, ok := treatmentSessionParams(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	var request model.TreatmentSessionRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	session, err := h.treatmentSessionService.UpdateTreatmentSession(customerID, id, request, userID)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(session))
+}
+
+func (h *TreatmentSessionHandler) DeleteTreatmentSession(c echo.Context) error {
+	customerID, id, ok := treatmentSessionParams(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	if err := h.treatmentSessionService.DeleteTreatmentSession(customerID, id); err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(map[string]string{
+		"message": "Treatment session deleted successfully",
+	}))
+}
+
+func (h *TreatmentSessionHandler) GetTimeline(c echo.Context) error {
+	customerID := c.Param("id")
+	if _, err := uuid.Parse(customerID); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid ID"))
+	}
+
+	var request model.TimelineRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid query parameters"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	entries, err := h.treatmentSessionService.GetTimeline(customerID, request)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(entries))
+}
+
+// treatmentSessionParams reads the customer and session ids from the path.
+func treatmentSessionParams(c echo.Context) (string, uint, bool) {
+	customerID := c.Param("id")
+	if _, err := uuid.Parse(customerID); err != nil {
+		return "", 0, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
+	if err != nil {
+		return "", 0, false
+	}
+	return customerID, uint(id), true
+}
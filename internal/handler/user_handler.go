@@ -96,13 +96,18 @@ func (h *UserHandler) GetUserByID(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
 	}
 
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
 	// Get user ID from path parameter
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, errorResponse("Invalid user ID"))
 	}
 
-	user, err := h.userService.GetUserByID(uint(id), userRole)
+	user, err := h.userService.GetUserByID(uint(id), userRole, userID)
 	if err != nil {
 		return handleServiceError(c, err)
 	}
@@ -223,3 +228,260 @@ func (h *UserHandler) DeleteUser(c echo.Context) error {
 		"message": "User deleted successfully",
 	}))
 }
+
+// ChangePassword godoc
+// @Summary Change own password
+// @Description Change the password of the current user. All existing tokens are revoked.
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param request body model.ChangePasswordRequest true "Change Password Request"
+// @Success 200 {object} map[string]interface{} "Password changed successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid input or wrong current password"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/users/me/password [put]
+func (h *UserHandler) ChangePassword(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	var request model.ChangePasswordRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	if err := h.userService.ChangePassword(userID, request); err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(map[string]string{
+		"message": "Password changed successfully",
+	}))
+}
+
+// RequestPasswordReset godoc
+// @Summary Reset user password
+// @Description Issue a single-use password reset token and deliver it to the user
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{} "Password reset token sent"
+// @Failure 403 {object} map[string]interface{} "Access denied"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/users/{id}/password-reset [post]
+func (h *UserHandler) RequestPasswordReset(c echo.Context) error {
+	userRole, ok := c.Get("userRole").(string)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid user ID"))
+	}
+
+	if err := h.userService.RequestPasswordReset(uint(id), userRole, userID); err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(map[string]string{
+		"message": "Password reset token sent",
+	}))
+}
+
+// ResetPassword godoc
+// @Summary Complete password reset
+// @Description Set a new password using a password reset token
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param request body model.ResetPasswordRequest true "Reset Password Request"
+// @Success 200 {object} map[string]interface{} "Password reset successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid input or invalid token"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /auth/password-reset [post]
+func (h *UserHandler) ResetPassword(c echo.Context) error {
+	var request model.ResetPasswordRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid request body"))
+	}
+
+	if err := request.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	if err := h.userService.ResetPassword(request); err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(map[string]string{
+		"message": "Password reset successfully",
+	}))
+}
+
+// UnlockUser godoc
+// @Summary Unlock user
+// @Description Clear failed login attempts and lift an account lockout
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{} "User unlocked successfully"
+// @Failure 403 {object} map[string]interface{} "Access denied"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/users/{id}/unlock [post]
+func (h *UserHandler) UnlockUser(c echo.Context) error {
+	userRole, ok := c.Get("userRole").(string)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid user ID"))
+	}
+
+	if err := h.userService.UnlockUser(uint(id), userRole, userID); err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(map[string]string{
+		"message": "User unlocked successfully",
+	}))
+}
+
+// GetMySessions godoc
+// @Summary List own sessions
+// @Description List active sessions of the current user
+// @Tags users
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Sessions retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/users/me/sessions [get]
+func (h *UserHandler) GetMySessions(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	sessionID, _ := c.Get("sessionID").(string)
+
+	sessions, err := h.userService.GetMySessions(userID, sessionID)
+	if err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(sessions))
+}
+
+// RevokeMySession godoc
+// @Summary Revoke own session
+// @Description Log out one session of the current user
+// @Tags users
+// @Produce  json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} map[string]interface{} "Session revoked successfully"
+// @Failure 404 {object} map[string]interface{} "Session not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/users/me/sessions/{id} [delete]
+func (h *UserHandler) RevokeMySession(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	if err := h.userService.RevokeMySession(userID, c.Param("id")); err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(map[string]string{
+		"message": "Session revoked successfully",
+	}))
+}
+
+// RevokeAllMySessions godoc
+// @Summary Log out everywhere
+// @Description Revoke every session of the current user, including this one
+// @Tags users
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Sessions revoked successfully"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/users/me/sessions [delete]
+func (h *UserHandler) RevokeAllMySessions(c echo.Context) error {
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	if err := h.userService.RevokeAllMySessions(userID); err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(map[string]string{
+		"message": "Logged out from all sessions",
+	}))
+}
+
+// RevokeUserSessions godoc
+// @Summary Revoke user sessions
+// @Description Revoke every session of a user
+// @Tags users
+// @Produce  json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{} "Sessions revoked successfully"
+// @Failure 403 {object} map[string]interface{} "Access denied"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/users/{id}/sessions [delete]
+func (h *UserHandler) RevokeUserSessions(c echo.Context) error {
+	userRole, ok := c.Get("userRole").(string)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	userID, ok := c.Get("userID").(uint)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorResponse("Invalid user context"))
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("Invalid user ID"))
+	}
+
+	if err := h.userService.RevokeUserSessions(uint(id), userRole, userID); err != nil {
+		return handleServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, successResponse(map[string]string{
+		"message": "Sessions revoked successfully",
+	}))
+}
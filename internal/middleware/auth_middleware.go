@@ -3,11 +3,26 @@ package middleware
 import (
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
+	"net/http"
 	"sim-clinic-api/internal/service"
 	"strings"
+	"time"
 )
 
-func AuthMiddleware(authService service.AuthService) echo.MiddlewareFunc {
+// APIKeyHeader carries an API key as an alternative to a Bearer token.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyQueryParam carries an API key on event streams, because a browser
+// EventSource cannot send headers. It is ignored on every other route.
+const APIKeyQueryParam = "api_key"
+
+// Kunci context untuk memeriksa ulang kredensial pada koneksi yang lama terbuka
+const (
+	credentialExpiresAtKey = "credentialExpiresAt"
+	credentialCheckKey     = "credentialCheck"
+)
+
+func AuthMiddleware(authService service.AuthService, apiKeyService service.APIKeyService) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			// Skip auth untuk public routes
@@ -15,6 +30,35 @@ func AuthMiddleware(authService service.AuthService) echo.MiddlewareFunc {
 				return next(c)
 			}
 
+			// Integrasi antar sistem memakai API key, bukan akun user
+			apiKey := c.Request().Header.Get(APIKeyHeader)
+			if apiKey == "" && isEventStreamRoute(c.Path()) {
+				apiKey = c.QueryParam(APIKeyQueryParam)
+			}
+			if apiKey != "" {
+				key, err := apiKeyService.ValidateAPIKey(apiKey, c.RealIP())
+				if err != nil {
+					logrus.Warnf("Invalid API key: %v", err)
+					if serviceErr, ok := err.(*service.ServiceError); ok && serviceErr.Code == http.StatusForbidden {
+						return echo.ErrForbidden
+					}
+					return echo.ErrUnauthorized
+				}
+
+				c.Set("apiKeyID", key.ID)
+				c.Set("apiKeyScopes", key.Scopes)
+				if key.ExpiresAt != nil {
+					c.Set(credentialExpiresAtKey, *key.ExpiresAt)
+				}
+				ipAddress := c.RealIP()
+				c.Set(credentialCheckKey, func() error {
+					_, err := apiKeyService.ValidateAPIKey(apiKey, ipAddress)
+					return err
+				})
+
+				return next(c)
+			}
+
 			authHeader := c.Request().Header.Get("Authorization")
 			if authHeader == "" {
 				return echo.ErrUnauthorized
@@ -28,7 +72,7 @@ func AuthMiddleware(authService service.AuthService) echo.MiddlewareFunc {
 			tokenString := parts[1]
 
 			// Validate token
-			user, err := authService.ValidateToken(tokenString)
+			user, claims, err := authService.ValidateToken(tokenString)
 			if err != nil {
 				logrus.Warnf("Invalid token: %v", err)
 				return echo.ErrUnauthorized
@@ -38,17 +82,58 @@ func AuthMiddleware(authService service.AuthService) echo.MiddlewareFunc {
 			c.Set("userID", user.ID)
 			c.Set("username", user.Username)
 			c.Set("userRole", user.Role.Name)
+			c.Set("sessionID", claims.SessionID)
+			if claims.ExpiresAt != nil {
+				c.Set(credentialExpiresAtKey, claims.ExpiresAt.Time)
+			}
+			c.Set(credentialCheckKey, func() error {
+				_, _, err := authService.ValidateToken(tokenString)
+				return err
+			})
 
 			return next(c)
 		}
 	}
 }
 
+// CredentialExpiresAt returns when the token or API key of the request
+// expires, or the zero time if it does not.
+func CredentialExpiresAt(c echo.Context) time.Time {
+	expiresAt, _ := c.Get(credentialExpiresAtKey).(time.Time)
+	return expiresAt
+}
+
+// CredentialValid validates the token or API key of the request again. Long
+// running requests such as event streams call it to notice a logout, a
+// revoked session or a revoked key.
+func CredentialValid(c echo.Context) bool {
+	check, ok := c.Get(credentialCheckKey).(func() error)
+	if !ok {
+		return true
+	}
+	if err := check(); err != nil {
+		logrus.Infof("Closing %s: %v", c.Path(), err)
+		return false
+	}
+	return true
+}
+
+// isEventStreamRoute reports whether path is a Server-Sent Events stream that
+// accepts its API key in the query string.
+func isEventStreamRoute(path string) bool {
+	return path == "/api/queue/stream"
+}
+
 func isPublicRoute(path string) bool {
 	publicRoutes := []string{
 		"/api/auth/login",
-		"/api/auth/register",
+		"/api/auth/refresh",
+		"/api/auth/password-reset",
+		"/api/auth/mfa/",
 		"/swagger/",
+		"/.well-known/",
+		"/calendar/",
+		"/health",
 	}
 
 	for _, route := range publicRoutes {
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"sim-clinic-api/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequirePermission allows the request only if the caller holds permission:
+// users through their role, API keys through their scopes.
+func RequirePermission(authorizer *service.Authorizer, permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if isPolicyProbe(c) {
+				c.Set(routePolicyKey, permission)
+				return nil
+			}
+
+			if scopes, ok := c.Get("apiKeyScopes").([]string); ok {
+				for _, scope := range scopes {
+					if scope == permission {
+						return next(c)
+					}
+				}
+				return forbidden(c, "access denied: missing permission "+permission)
+			}
+
+			userRole, _ := c.Get("userRole").(string)
+			if !authorizer.Can(userRole, permission) {
+				return forbidden(c, "access denied: missing permission "+permission)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// Authenticated marks a route open to every logged-in user, such as the
+// self-service /users/me routes. The service decides what the user may see.
+// API keys are refused because there is no user behind them.
+func Authenticated() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if isPolicyProbe(c) {
+				c.Set(routePolicyKey, policyAuthenticated)
+				return nil
+			}
+
+			if _, ok := c.Get("apiKeyID").(uint); ok {
+				return forbidden(c, "access denied: api keys cannot access this resource")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// Public marks a route that needs no authentication. The path must also be
+// listed in isPublicRoute, which VerifyRoutePolicies checks.
+func Public() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if isPolicyProbe(c) {
+				c.Set(routePolicyKey, policyPublic)
+				return nil
+			}
+			return next(c)
+		}
+	}
+}
+
+// forbidden writes the 403 body shared by every permission failure.
+func forbidden(c echo.Context, message string) error {
+	return c.JSON(http.StatusForbidden, map[string]interface{}{
+		"success": false,
+		"error":   message,
+	})
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	routePolicyProbe = "routePolicyProbe"
+	routePolicyKey   = "routePolicy"
+
+	policyPublic        = "public"
+	policyAuthenticated = "authenticated"
+)
+
+// RoutePolicies records which policy (a permission, Authenticated or Public)
+// is attached to every route, so the server can refuse to start when a route
+// was registered without one.
+type RoutePolicies struct {
+	routes map[string]string
+}
+
+// RecordRoutePolicies hooks into route registration on e. It must be called
+// before any route is added.
+func RecordRoutePolicies(e *echo.Echo) *RoutePolicies {
+	p := &RoutePolicies{routes: make(map[string]string)}
+	e.OnAddRouteHandler = func(host string, route echo.Route, handler echo.HandlerFunc, middleware []echo.MiddlewareFunc) {
+		// Route 404 milik group bukan endpoint
+		if route.Method == echo.RouteNotFound {
+			return
+		}
+		p.routes[route.Method+" "+route.Path] = probeRoutePolicy(middleware)
+	}
+	return p
+}
+
+// Verify returns an error listing every route without a policy, and every
+// route whose Public marker disagrees with isPublicRoute.
+func (p *RoutePolicies) Verify() error {
+	var problems []string
+	for route, policy := range p.routes {
+		path := route[strings.Index(route, " ")+1:]
+		switch {
+		case policy == "":
+			problems = append(problems, route+": no permission and not marked public")
+		case policy == policyPublic && !isPublicRoute(path):
+			problems = append(problems, route+": marked public but not listed in isPublicRoute")
+		case policy != policyPublic && isPublicRoute(path):
+			problems = append(problems, route+": listed in isPublicRoute but not marked public")
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("route policy check failed:\n  %s", strings.Join(problems, "\n  "))
+}
+
+// probeRoutePolicy runs each middleware of a route against a probe context.
+// The policy middlewares answer a probe by recording themselves instead of
+// handling the request, and the handler itself is never called.
+func probeRoutePolicy(middleware []echo.MiddlewareFunc) string {
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	c.Set(routePolicyProbe, true)
+
+	noop := func(echo.Context) error { return nil }
+	for _, m := range middleware {
+		_ = m(noop)(c)
+		if policy, ok := c.Get(routePolicyKey).(string); ok {
+			return policy
+		}
+	}
+	return ""
+}
+
+func isPolicyProbe(c echo.Context) bool {
+	probe, _ := c.Get(routePolicyProbe).(bool)
+	return probe
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// APIKeyScopes lists the scopes an API key may be granted. A scope is
+// "<resource>:read" or "<resource>:write".
+var APIKeyScopes = []string{
+	PermCustomerRead,
+	PermCustomerWrite,
+	PermMasterRead,
+	PermMasterWrite,
+	// Layar ruang tunggu tersambung ke /api/queue/stream dengan key ini.
+	// queue:write sengaja tidak ada: setiap panggilan dan check-in dicatat atas nama user
+	PermQueueRead,
+}
+
+// APIKey authenticates an integration instead of a person. Only the hash of
+// the key is stored; the plaintext is shown once when the key is created.
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Name       string     `json:"name" gorm:"not null"`
+	Prefix     string     `json:"prefix" gorm:"not null"`
+	KeyHash    string     `json:"-" gorm:"uniqueIndex;not null"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json;not null"`
+	AllowedIPs []string   `json:"allowed_ips" gorm:"serializer:json"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	CreatedBy  uint       `json:"created_by" gorm:"not null"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// IsActive reports whether the key can still be used at t.
+func (k *APIKey) IsActive(t time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || t.Before(*k.ExpiresAt))
+}
+
+// HasScope reports whether the key was granted scope.
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsIP reports whether ip matches the allowlist. An empty allowlist allows every address.
+func (k *APIKey) AllowsIP(ip string) bool {
+	if len(k.AllowedIPs) == 0 {
+		return true
+	}
+
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+
+	for _, allowed := range k.AllowedIPs {
+		if _, network, err := net.ParseCIDR(allowed); err == nil {
+			if network.Contains(addr) {
+				return true
+			}
+		} else if other := net.ParseIP(allowed); other != nil && other.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+type CreateAPIKeyRequest struct {
+	Name       string     `json:"name" valid:"required,length(3|100)"`
+	Scopes     []string   `json:"scopes"`
+	AllowedIPs []string   `json:"allowed_ips"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+func (r *CreateAPIKeyRequest) Validate() error {
+	if _, err := govalidator.ValidateStruct(r); err != nil {
+		return err
+	}
+
+	if len(r.Scopes) == 0 {
+		return errors.New("scopes: at least one scope is required")
+	}
+	for _, scope := range r.Scopes {
+		if !govalidator.IsIn(scope, APIKeyScopes...) {
+			return fmt.Errorf("scopes: unknown scope %q, allowed: %s", scope, strings.Join(APIKeyScopes, ", "))
+		}
+	}
+
+	for _, ip := range r.AllowedIPs {
+		if net.ParseIP(ip) == nil {
+			if _, _, err := net.ParseCIDR(ip); err != nil {
+				return fmt.Errorf("allowed_ips: %q is not an IP address or CIDR range", ip)
+			}
+		}
+	}
+
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(time.Now()) {
+		return errors.New("expires_at: must be in the future")
+	}
+	return nil
+}
+
+// APIKeyCreatedResponse carries the plaintext key, which cannot be retrieved again.
+type APIKeyCreatedResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
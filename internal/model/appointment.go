@@ -0,0 +1,203 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// Status janji temu
+const (
+	AppointmentBooked    = "booked"
+	AppointmentCheckedIn = "checked_in"
+	AppointmentInSession = "in_session"
+	AppointmentCompleted = "completed"
+	AppointmentNoShow    = "no_show"
+	AppointmentCancelled = "cancelled"
+)
+
+// appointmentTransitions lists the statuses an appointment may move to.
+// Completed, no-show and cancelled appointments are final.
+var appointmentTransitions = map[string][]string{
+	AppointmentBooked:    {AppointmentCheckedIn, AppointmentNoShow, AppointmentCancelled},
+	AppointmentCheckedIn: {AppointmentInSession, AppointmentCancelled},
+	AppointmentInSession: {AppointmentCompleted},
+}
+
+// CanTransitionAppointment reports whether an appointment may move from one status to another.
+func CanTransitionAppointment(from, to string) bool {
+	for _, next := range appointmentTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// AppointmentOccupiesSlot reports whether an appointment in this status keeps
+// its therapist busy. It must match the WHERE clause of the exclusion
+// constraint created in database.AutoMigrate.
+func AppointmentOccupiesSlot(status string) bool {
+	return status != AppointmentCancelled && status != AppointmentNoShow
+}
+
+// Appointment is a booking of a customer with a therapist for a layanan terapi.
+// Overlapping appointments of one therapist are rejected by the database.
+// DepositReference records the deposit a booking policy asked for. Resources
+// are the rooms and equipment reserved for it.
+type Appointment struct {
+	ID               uint                  `json:"id" gorm:"primaryKey"`
+	SeriesID         *uint                 `json:"seriesId,omitempty" gorm:"index"`
+	CustomerID       string                `json:"customerId" gorm:"index;not null"`
+	Customer         *Customer             `json:"customer,omitempty"`
+	TherapistID      uint                  `json:"therapistId" gorm:"index;not null"`
+	Therapist        *UserSummary          `json:"therapist,omitempty" gorm:"foreignKey:TherapistID"`
+	LayananTerapiID  uint                  `json:"layananTerapiId" gorm:"index;not null"`
+	LayananTerapi    *LayananTerapi        `json:"layananTerapi,omitempty"`
+	TeknikTerapiID   *uint                 `json:"teknikTerapiId,omitempty" gorm:"index"`
+	TeknikTerapi     *TeknikTerapi         `json:"teknikTerapi,omitempty"`
+	StartAt          time.Time             `json:"startAt" gorm:"index;not null"`
+	EndAt            time.Time             `json:"endAt" gorm:"not null"`
+	Status           string                `json:"status" gorm:"index;not null;default:booked"`
+	Notes            string                `json:"notes" gorm:"type:text"`
+	Source           string                `json:"source" gorm:"not null;default:front_desk"`
+	DepositReference string                `json:"depositReference,omitempty"`
+	CancelReason     string                `json:"cancelReason,omitempty"`
+	CancelledBy      string                `json:"cancelledBy,omitempty"`
+	CancelledAt      *time.Time            `json:"cancelledAt,omitempty"`
+	CreatedBy        uint                  `json:"createdBy" gorm:"not null"`
+	CreatedAt        time.Time             `json:"createdAt"`
+	UpdatedAt        time.Time             `json:"updatedAt"`
+	Resources        []AppointmentResource `json:"resources,omitempty" gorm:"foreignKey:AppointmentID"`
+}
+
+// Jenis kejadian pada riwayat janji temu
+const (
+	AppointmentEventBooked      = "booked"
+	AppointmentEventRescheduled = "rescheduled"
+	AppointmentEventStatus      = "status_changed"
+)
+
+// AppointmentEvent records every change to an appointment and who made it.
+type AppointmentEvent struct {
+	ID                  uint       `json:"id" gorm:"primaryKey"`
+	AppointmentID       uint       `json:"appointmentId" gorm:"index;not null"`
+	Type                string     `json:"type" gorm:"not null"`
+	FromStatus          string     `json:"fromStatus,omitempty"`
+	ToStatus            string     `json:"toStatus,omitempty"`
+	PreviousStartAt     *time.Time `json:"previousStartAt,omitempty"`
+	PreviousTherapistID *uint      `json:"previousTherapistId,omitempty"`
+	Reason              string     `json:"reason,omitempty" gorm:"type:text"`
+	UserID              uint       `json:"userId" gorm:"not null"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	// Pesan untuk pasien, disimpan bersama kejadian ini dalam satu transaksi
+	Notifications []OutboxMessage `json:"-" gorm:"foreignKey:AppointmentEventID"`
+}
+
+type CreateAppointmentRequest struct {
+	CustomerID      string    `json:"customerId" valid:"required,uuid"`
+	TherapistID     uint      `json:"therapistId" valid:"required"`
+	LayananTerapiID uint      `json:"layananTerapiId" valid:"required"`
+	TeknikTerapiID  *uint     `json:"teknikTerapiId"`
+	StartAt         time.Time `json:"startAt" valid:"required"`
+	Notes           string    `json:"notes" valid:"optional,length(0|1000)"`
+	// Hanya petugas front desk yang boleh mencatat deposit
+	DepositReference string `json:"depositReference" valid:"optional,length(1|100)"`
+}
+
+func (r *CreateAppointmentRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
+
+type RescheduleAppointmentRequest struct {
+	StartAt time.Time `json:"startAt" valid:"required"`
+	// Kosong berarti tetap dengan terapis yang sama
+	TherapistID uint   `json:"therapistId"`
+	Reason      string `json:"reason" valid:"required,length(3|500)"`
+	// Untuk janji dalam seri: this, following atau all. Kosong sama dengan this
+	Scope string `json:"scope" valid:"optional,in(this|following|all)"`
+}
+
+func (r *RescheduleAppointmentRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
+
+type CancelAppointmentRequest struct {
+	Reason string `json:"reason" valid:"required,length(3|500)"`
+	Scope  string `json:"scope" valid:"optional,in(this|following|all)"`
+	// Pembatalan oleh klinik tidak dihitung di catatan kehadiran pasien. Kosong berarti customer
+	CancelledBy string `json:"cancelledBy" valid:"optional,in(customer|clinic)"`
+}
+
+func (r *CancelAppointmentRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
+
+type AppointmentStatusRequest struct {
+	Status string `json:"status" valid:"required,in(checked_in|in_session|completed|no_show)"`
+	Reason string `json:"reason" valid:"optional,length(0|500)"`
+}
+
+func (r *AppointmentStatusRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
+
+type AppointmentListRequest struct {
+	From        string `query:"from"`
+	To          string `query:"to"`
+	TherapistID uint   `query:"therapist_id"`
+	CustomerID  string `query:"customer_id" valid:"optional,uuid"`
+	Status      string `query:"status" valid:"optional,in(booked|checked_in|in_session|completed|no_show|cancelled)"`
+}
+
+func (r AppointmentListRequest) Validate() error {
+	if _, err := govalidator.ValidateStruct(r); err != nil {
+		return err
+	}
+
+	for name, value := range map[string]string{"from": r.From, "to": r.To} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse(DateLayout, value); err != nil {
+			return fmt.Errorf("%s: must be a date formatted as YYYY-MM-DD", name)
+		}
+	}
+	return nil
+}
+
+type AppointmentSlotRequest struct {
+	TherapistID     uint   `query:"therapist_id" valid:"required"`
+	LayananTerapiID uint   `query:"layanan_terapi_id" valid:"required"`
+	TeknikTerapiID  uint   `query:"teknik_terapi_id"`
+	Date            string `query:"date" valid:"required"`
+}
+
+func (r AppointmentSlotRequest) Validate() error {
+	if _, err := govalidator.ValidateStruct(r); err != nil {
+		return err
+	}
+
+	if _, err := time.Parse(DateLayout, r.Date); err != nil {
+		return errors.New("date: must be a date formatted as YYYY-MM-DD")
+	}
+	return nil
+}
+
+// AppointmentSlot is a free period in which an appointment can be booked.
+type AppointmentSlot struct {
+	StartAt time.Time `json:"startAt"`
+	EndAt   time.Time `json:"endAt"`
+}
+
+// AppointmentDetail is an appointment together with its change history.
+type AppointmentDetail struct {
+	Appointment
+	Events []AppointmentEvent `json:"events"`
+}
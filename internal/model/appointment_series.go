@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// Cakupan perubahan pada janji yang termasuk seri
+const (
+	SeriesScopeThis      = "this"
+	SeriesScopeFollowing = "following"
+	SeriesScopeAll       = "all"
+)
+
+// AppointmentSeries groups the appointments of a therapy program booked from
+// one recurrence rule. Each occurrence is a normal Appointment with SeriesID set.
+type AppointmentSeries struct {
+	ID              uint          `json:"id" gorm:"primaryKey"`
+	CustomerID      string        `json:"customerId" gorm:"index;not null"`
+	TherapistID     uint          `json:"therapistId" gorm:"not null"`
+	LayananTerapiID uint          `json:"layananTerapiId" gorm:"not null"`
+	TeknikTerapiID  *uint         `json:"teknikTerapiId,omitempty"`
+	RRule           string        `json:"rrule" gorm:"not null"`
+	StartAt         time.Time     `json:"startAt" gorm:"not null"`
+	Notes           string        `json:"notes" gorm:"type:text"`
+	CreatedBy       uint          `json:"createdBy" gorm:"not null"`
+	Appointments    []Appointment `json:"appointments,omitempty" gorm:"foreignKey:SeriesID"`
+	CreatedAt       time.Time     `json:"createdAt"`
+	UpdatedAt       time.Time     `json:"updatedAt"`
+}
+
+type CreateAppointmentSeriesRequest struct {
+	CustomerID      string    `json:"customerId" valid:"required,uuid"`
+	TherapistID     uint      `json:"therapistId" valid:"required"`
+	LayananTerapiID uint      `json:"layananTerapiId" valid:"required"`
+	TeknikTerapiID  *uint     `json:"teknikTerapiId"`
+	StartAt         time.Time `json:"startAt" valid:"required"`
+	RRule           string    `json:"rrule" valid:"required,length(1|200)"`
+	Notes           string    `json:"notes" valid:"optional,length(0|1000)"`
+	// Jika true, tanggal yang bentrok dilewati dan sisanya tetap dibuat
+	SkipConflicts    bool   `json:"skipConflicts"`
+	DepositReference string `json:"depositReference" valid:"optional,length(1|100)"`
+}
+
+func (r *CreateAppointmentSeriesRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
+
+// SeriesOccurrence is one planned occurrence of a series. Conflict explains
+// why it cannot be booked and is empty when it can; Resources are the rooms
+// and equipment it would get.
+type SeriesOccurrence struct {
+	StartAt   time.Time             `json:"startAt"`
+	EndAt     time.Time             `json:"endAt"`
+	Conflict  string                `json:"conflict,omitempty"`
+	Resources []AppointmentResource `json:"resources,omitempty"`
+}
+
+// AppointmentSeriesPreview lists the occurrences a series would book.
+type AppointmentSeriesPreview struct {
+	Occurrences []SeriesOccurrence `json:"occurrences"`
+	Conflicts   int                `json:"conflicts"`
+}
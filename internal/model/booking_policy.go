@@ -0,0 +1,108 @@
+package model
+
+import (
+	"time"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// Cara janji temu dibuat
+const (
+	BookingSourceFrontDesk = "front_desk"
+	BookingSourceOnline    = "online"
+)
+
+// Pihak yang membatalkan janji temu
+const (
+	CancelledByCustomer = "customer"
+	CancelledByClinic   = "clinic"
+)
+
+// Catatan kehadiran yang dihitung oleh kebijakan booking
+const (
+	PolicyMetricNoShow           = "no_show"
+	PolicyMetricLateCancellation = "late_cancellation"
+)
+
+// Tindakan kebijakan booking saat ambang batas tercapai
+const (
+	PolicyActionRequireDeposit = "require_deposit"
+	PolicyActionBlockOnline    = "block_online_booking"
+	PolicyActionBlockBooking   = "block_booking"
+)
+
+// BookingPolicy restricts new bookings of customers who reached Threshold
+// no-shows or late cancellations within the last WindowDays days.
+type BookingPolicy struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Name       string    `json:"name" gorm:"not null"`
+	Metric     string    `json:"metric" gorm:"not null"`
+	Threshold  int       `json:"threshold" gorm:"not null"`
+	WindowDays int       `json:"windowDays" gorm:"not null"`
+	Action     string    `json:"action" gorm:"not null"`
+	Active     bool      `json:"active" gorm:"not null;default:true"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+type BookingPolicyRequest struct {
+	Name       string `json:"name" valid:"required,length(3|100)"`
+	Metric     string `json:"metric" valid:"required,in(no_show|late_cancellation)"`
+	Threshold  int    `json:"threshold" valid:"required,range(1|100)"`
+	WindowDays int    `json:"windowDays" valid:"required,range(1|730)"`
+	Action     string `json:"action" valid:"required,in(require_deposit|block_online_booking|block_booking)"`
+	// Kosong berarti aktif
+	Active *bool `json:"active"`
+}
+
+func (r *BookingPolicyRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
+
+// AttendanceCounts summarises how a customer dealt with their appointments.
+// A cancellation is late when the customer cancelled within the clinic's
+// late-cancellation window before the start.
+type AttendanceCounts struct {
+	Completed     int64 `json:"completed"`
+	LateCancelled int64 `json:"lateCancelled"`
+	NoShow        int64 `json:"noShow"`
+}
+
+// CustomerAttendance is AttendanceCounts for one customer.
+type CustomerAttendance struct {
+	CustomerID string `json:"customerId"`
+	AttendanceCounts
+}
+
+// BookingRestriction is a booking policy a customer currently falls under.
+type BookingRestriction struct {
+	PolicyID   uint   `json:"policyId"`
+	PolicyName string `json:"policyName"`
+	Metric     string `json:"metric"`
+	Action     string `json:"action"`
+	Count      int64  `json:"count"`
+	Threshold  int    `json:"threshold"`
+	WindowDays int    `json:"windowDays"`
+}
+
+// AttendanceRecord is the attendance of a customer over all their
+// appointments together with the restrictions it currently causes.
+type AttendanceRecord struct {
+	CustomerID   string               `json:"customerId"`
+	AllTime      AttendanceCounts     `json:"allTime"`
+	Restrictions []BookingRestriction `json:"restrictions"`
+}
+
+// FlaggedCustomer is a customer under at least one booking restriction.
+type FlaggedCustomer struct {
+	Customer     Customer             `json:"customer"`
+	Restrictions []BookingRestriction `json:"restrictions"`
+}
+
+// BookingContext describes how a booking is made, for the booking policies.
+// It follows from who books, never from the request body.
+type BookingContext struct {
+	Source           string
+	DepositReference string
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// CalendarToken is the secret in a user's calendar feed URLs, so calendar
+// apps can subscribe without logging in. Only its hash is stored, and every
+// user has at most one: regenerating it stops all URLs handed out before.
+type CalendarToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"userId" gorm:"uniqueIndex;not null"`
+	Prefix     string     `json:"prefix" gorm:"not null"`
+	TokenHash  string     `json:"-" gorm:"uniqueIndex;not null"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// CalendarTokenCreatedResponse is returned once when a token is generated.
+// LayananTerapiFeedURL and ResourceFeedURL are only set for users who may
+// subscribe to every layanan terapi and room; replace {layananTerapiId} or
+// {resourceId} with the id to follow.
+type CalendarTokenCreatedResponse struct {
+	CalendarToken
+	Token                string `json:"token"`
+	FeedURL              string `json:"feedUrl"`
+	LayananTerapiFeedURL string `json:"layananTerapiFeedUrl,omitempty"`
+	ResourceFeedURL      string `json:"resourceFeedUrl,omitempty"`
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// ClinicalNote is the SOAP note of a treatment session. The author may edit it
+// until it is signed; after that it is immutable and corrections are added as
+// addenda.
+type ClinicalNote struct {
+	ID                 uint                   `json:"id" gorm:"primaryKey"`
+	TreatmentSessionID uint                   `json:"treatmentSessionId" gorm:"uniqueIndex;not null"`
+	AuthorID           uint                   `json:"authorId" gorm:"index;not null"`
+	Author             *UserSummary           `json:"author,omitempty" gorm:"foreignKey:AuthorID"`
+	Subjective         string                 `json:"subjective" gorm:"type:text"`
+	Objective          string                 `json:"objective" gorm:"type:text"`
+	Assessment         string                 `json:"assessment" gorm:"type:text"`
+	Plan               string                 `json:"plan" gorm:"type:text"`
+	SignedAt           *time.Time             `json:"signedAt"`
+	Addenda            []ClinicalNoteAddendum `json:"addenda"`
+	CreatedAt          time.Time              `json:"createdAt"`
+	UpdatedAt          time.Time              `json:"updatedAt"`
+}
+
+// IsSigned reports whether the note has been signed and can no longer be edited.
+func (n *ClinicalNote) IsSigned() bool {
+	return n.SignedAt != nil
+}
+
+// ClinicalNoteAddendum is a correction to a signed clinical note.
+type ClinicalNoteAddendum struct {
+	ID             uint         `json:"id" gorm:"primaryKey"`
+	ClinicalNoteID uint         `json:"clinicalNoteId" gorm:"index;not null"`
+	AuthorID       uint         `json:"authorId" gorm:"not null"`
+	Author         *UserSummary `json:"author,omitempty" gorm:"foreignKey:AuthorID"`
+	Content        string       `json:"content" gorm:"type:text;not null"`
+	CreatedAt      time.Time    `json:"createdAt"`
+}
+
+type ClinicalNoteRequest struct {
+	Subjective string `json:"subjective" valid:"optional,length(0|5000)"`
+	Objective  string `json:"objective" valid:"optional,length(0|5000)"`
+	Assessment string `json:"assessment" valid:"optional,length(0|5000)"`
+	Plan       string `json:"plan" valid:"optional,length(0|5000)"`
+}
+
+func (r *ClinicalNoteRequest) Validate() error {
+	if _, err := govalidator.ValidateStruct(r); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(r.Subjective+r.Objective+r.Assessment+r.Plan) == "" {
+		return errors.New("at least one of subjective, objective, assessment or plan is required")
+	}
+	return nil
+}
+
+type ClinicalNoteAddendumRequest struct {
+	Content string `json:"content" valid:"required,length(1|5000)"`
+}
+
+func (r *ClinicalNoteAddendumRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
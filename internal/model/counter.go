@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// Counter hands out increasing sequence numbers per period, e.g. for patient
+// registration codes. Numbers are drawn outside the transaction that uses
+// them, so one drawn for an insert that fails is skipped and never reused;
+// the sequence can have gaps. Period is empty for counters that never reset.
+type Counter struct {
+	Name      string    `json:"name" gorm:"primaryKey"`
+	Period    string    `json:"period" gorm:"primaryKey"`
+	Value     int64     `json:"value" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
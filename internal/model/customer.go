@@ -1,9 +1,12 @@
 package model
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/asaskevich/govalidator"
+	"gorm.io/gorm"
 )
 
 func init() {
@@ -12,23 +15,30 @@ func init() {
 	})
 }
 
+// DateLayout is the format of date-only query parameters.
+const DateLayout = "2006-01-02"
+
 type Customer struct {
-	Id                 string    `json:"id" gorm:"primary_key;unique"`
-	CodeRegister       string    `json:"codeRegister" gorm:"code_register"`
-	CustomerName       string    `json:"customerName" gorm:"customer_name"`
-	PhoneNumber        string    `json:"phoneNumber" gorm:"phone_number"`
-	CustomerAddress    string    `json:"customerAddress" gorm:"customer_address"`
-	Gender             string    `json:"gender" gorm:"gender"`
-	InformedConsent    string    `json:"informedConsent" gorm:"informed_consent"`
-	SourceTerapistInfo string    `json:"sourceTerapistInfo" gorm:"source_terapist_info"`
-	City               string    `json:"city" gorm:"city"`
-	CreatedAt          time.Time `json:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at"`
+	Id                 string         `json:"id" gorm:"primary_key;unique"`
+	CodeRegister       string         `json:"codeRegister" gorm:"uniqueIndex"`
+	CustomerName       string         `json:"customerName" gorm:"customer_name"`
+	PhoneNumber        string         `json:"phoneNumber" gorm:"phone_number"`
+	CustomerAddress    string         `json:"customerAddress" gorm:"customer_address"`
+	Gender             string         `json:"gender" gorm:"gender"`
+	InformedConsent    string         `json:"informedConsent" gorm:"informed_consent"`
+	SourceTerapistInfo string         `json:"sourceTerapistInfo" gorm:"source_terapist_info"`
+	City               string         `json:"city" gorm:"city"`
+	MergedIntoID       *string        `json:"mergedIntoId,omitempty" gorm:"index"`
+	NotificationOptOut bool           `json:"notificationOptOut" gorm:"not null;default:false"`
+	PreferredLanguage  string         `json:"preferredLanguage" gorm:"size:5"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
 type AddCustomerRequest struct {
 	CustomerName       string `json:"customerName" valid:"required,length(3|100)"`
-	PhoneNumber        string `json:"phoneNumber" valid:"required,length(10|13)"`
+	PhoneNumber        string `json:"phoneNumber" valid:"required,length(8|20)"`
 	CustomerAddress    string `json:"customerAddress"`
 	Gender             string `json:"gender" valid:"required,gender"`
 	InformedConsent    string `json:"informedConsent"`
@@ -37,15 +47,103 @@ type AddCustomerRequest struct {
 }
 
 type UpdateCustomerRequest struct {
-	CustomerName    string `json:"customerName" valid:"required,length(3|100)"`
-	PhoneNumber     string `json:"phoneNumber" valid:"length(10|13)"`
-	CustomerAddress string `json:"customerAddress" valid:"length(10|13)"`
-	InformedConsent string `json:"informedConsent"`
-	City            string `json:"city"`
+	CustomerName       string `json:"customerName" valid:"required,length(3|100)"`
+	PhoneNumber        string `json:"phoneNumber" valid:"required,length(8|20)"`
+	CustomerAddress    string `json:"customerAddress"`
+	Gender             string `json:"gender" valid:"required,gender"`
+	InformedConsent    string `json:"informedConsent"`
+	SourceTerapistInfo string `json:"sourceTerapistInfo"`
+	City               string `json:"city"`
+}
+
+// CustomerSortFields whitelists the sort_by values of the customer list and maps them to columns.
+var CustomerSortFields = map[string]string{
+	"customer_name": "customer_name",
+	"code_register": "code_register",
+	"city":          "city",
+	"created_at":    "created_at",
+}
+
+type CustomerListRequest struct {
+	Page        string `query:"page" valid:"optional,int"`
+	Limit       string `query:"limit" valid:"optional,int"`
+	Search      string `query:"search"`
+	Gender      string `query:"gender" valid:"optional,gender"`
+	City        string `query:"city"`
+	CreatedFrom string `query:"created_from"`
+	CreatedTo   string `query:"created_to"`
+	SortBy      string `query:"sort_by" valid:"optional,in(customer_name|code_register|city|created_at)"`
+	SortOrder   string `query:"sort_order" valid:"optional,in(asc|desc)"`
+
+	// Diisi service jika Search berupa nomor HP yang bisa dinormalisasi
+	SearchPhone string `query:"-"`
+	// Diisi service dari CreatedFrom dan CreatedTo dalam zona waktu klinik
+	CreatedAfter  *time.Time `query:"-"`
+	CreatedBefore *time.Time `query:"-"`
+}
+
+func (r CustomerListRequest) Validate() error {
+	if _, err := govalidator.ValidateStruct(r); err != nil {
+		return err
+	}
+
+	for name, value := range map[string]string{"created_from": r.CreatedFrom, "created_to": r.CreatedTo} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse(DateLayout, value); err != nil {
+			return fmt.Errorf("%s: must be a date formatted as YYYY-MM-DD", name)
+		}
+	}
+	return nil
 }
 
 type CheckCustomerByPhoneRequest struct {
-	PhoneNumber string `param:"phoneNumber" valid:"length(10|13)"`
+	PhoneNumber string `param:"phoneNumber" valid:"length(8|20)"`
+}
+
+type CustomerDuplicatesRequest struct {
+	MinSimilarity float64 `query:"min_similarity"`
+	Limit         int     `query:"limit"`
+}
+
+func (r CustomerDuplicatesRequest) Validate() error {
+	if r.MinSimilarity < 0 || r.MinSimilarity > 1 {
+		return errors.New("min_similarity: must be between 0 and 1")
+	}
+	if r.Limit < 0 || r.Limit > 500 {
+		return errors.New("limit: must be between 1 and 500")
+	}
+	return nil
+}
+
+// Alasan sebuah kelompok customer dicurigai sebagai pasien yang sama
+const (
+	DuplicateReasonPhone    = "same_phone"
+	DuplicateReasonNameCity = "similar_name_same_city"
+)
+
+// CustomerDuplicateGroup lists customers that are probably the same patient.
+// Similarity is the name similarity (0-1) for name matches and 1 for phone matches.
+type CustomerDuplicateGroup struct {
+	Reason     string     `json:"reason"`
+	Similarity float64    `json:"similarity"`
+	Customers  []Customer `json:"customers"`
+}
+
+// CustomerSimilarPair is a pair of customers in the same city with similar names.
+type CustomerSimilarPair struct {
+	FirstID    string
+	SecondID   string
+	Similarity float64
+}
+
+// PhoneNormalizationResult summarizes a phone number backfill run.
+type PhoneNormalizationResult struct {
+	Checked   int               `json:"checked"`
+	Updated   int               `json:"updated"`
+	Invalid   map[string]string `json:"invalid"`
+	Duplicate map[string]int    `json:"duplicate"`
 }
 
 func (r AddCustomerRequest) Validate() error {
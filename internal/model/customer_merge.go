@@ -0,0 +1,76 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// Jenis alias yang disimpan saat customer digabung
+const (
+	CustomerAliasCodeRegister = "code_register"
+	CustomerAliasPhone        = "phone"
+)
+
+// CustomerAlias keeps a register code or phone number of a customer that was
+// merged into another one, so lookups by the old value still find the
+// surviving record.
+type CustomerAlias struct {
+	Id           string    `json:"id" gorm:"primaryKey"`
+	CustomerID   string    `json:"customerId" gorm:"index;not null"`
+	Kind         string    `json:"kind" gorm:"index:idx_customer_aliases_kind_value;not null"`
+	Value        string    `json:"value" gorm:"index:idx_customer_aliases_kind_value;not null"`
+	MergedFromID string    `json:"mergedFromId" gorm:"not null"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// CustomerMergeLog is the audit entry written for every merge. Snapshot holds
+// the retired customers as they were before the merge.
+type CustomerMergeLog struct {
+	Id           string           `json:"id" gorm:"primaryKey"`
+	SurvivorID   string           `json:"survivorId" gorm:"index;not null"`
+	MergedIDs    []string         `json:"mergedIds" gorm:"serializer:json;not null"`
+	Snapshot     []Customer       `json:"snapshot" gorm:"serializer:json"`
+	MovedRecords map[string]int64 `json:"movedRecords" gorm:"serializer:json"`
+	PerformedBy  uint             `json:"performedBy" gorm:"not null"`
+	CreatedAt    time.Time        `json:"createdAt"`
+}
+
+type MergeCustomerRequest struct {
+	SurvivorID string   `json:"survivorId" valid:"required,uuid"`
+	MergedIDs  []string `json:"mergedIds"`
+	DryRun     bool     `json:"dryRun"`
+}
+
+func (r *MergeCustomerRequest) Validate() error {
+	if _, err := govalidator.ValidateStruct(r); err != nil {
+		return err
+	}
+
+	if len(r.MergedIDs) == 0 {
+		return errors.New("mergedIds: at least one customer to merge is required")
+	}
+
+	seen := map[string]bool{r.SurvivorID: true}
+	for _, id := range r.MergedIDs {
+		if !govalidator.IsUUID(id) {
+			return errors.New("mergedIds: " + id + " is not a valid id")
+		}
+		if seen[id] {
+			return errors.New("mergedIds: " + id + " is listed twice or is the surviving customer")
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// CustomerMergeResult describes a merge, or what a merge would do when DryRun is set.
+type CustomerMergeResult struct {
+	DryRun       bool             `json:"dryRun"`
+	Survivor     Customer         `json:"survivor"`
+	Merged       []Customer       `json:"merged"`
+	Aliases      []CustomerAlias  `json:"aliases"`
+	MovedRecords map[string]int64 `json:"movedRecords"`
+	LogID        string           `json:"logId,omitempty"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// Alasan login gagal yang dicatat di LoginAttempt
+const (
+	LoginFailureUnknownUser     = "unknown_user"
+	LoginFailureInvalidPassword = "invalid_password"
+	LoginFailureLocked          = "locked"
+	LoginFailureThrottled       = "throttled"
+	LoginFailureIPBlocked       = "ip_blocked"
+)
+
+// LoginCredentialFailures are the failures caused by wrong credentials. Only
+// these count towards throttling; attempts refused by the throttle itself do
+// not, otherwise a client that keeps retrying would never be let through.
+var LoginCredentialFailures = []string{LoginFailureUnknownUser, LoginFailureInvalidPassword}
+
+type LoginAttempt struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Username  string    `json:"username" gorm:"index"`
+	UserID    *uint     `json:"user_id" gorm:"index"`
+	IPAddress string    `json:"ip_address" gorm:"index"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
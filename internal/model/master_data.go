@@ -6,13 +6,19 @@ import (
 	"time"
 )
 
+// DefaultLayananDurationMinutes is used when a layanan terapi is created without a duration.
+const DefaultLayananDurationMinutes = 60
+
+// LayananTerapi is a service the clinic offers. DurationMinutes is the length
+// of an appointment for it.
 type LayananTerapi struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Code      string         `json:"code" gorm:"uniqueIndex;not null" valid:"required,alphanum,length(3|20)"`
-	Name      string         `json:"name" gorm:"not null" valid:"required,length(3|100)"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	Code            string         `json:"code" gorm:"uniqueIndex;not null" valid:"required,alphanum,length(3|20)"`
+	Name            string         `json:"name" gorm:"not null" valid:"required,length(3|100)"`
+	DurationMinutes int            `json:"duration_minutes" gorm:"not null;default:60"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
 type RiwayatPenyakit struct {
@@ -36,8 +42,9 @@ type TeknikTerapi struct {
 }
 
 type LayananTerapiRequest struct {
-	Code string `json:"code" valid:"required,alphanum,length(3|20)"`
-	Name string `json:"name" valid:"required,length(3|100)"`
+	Code            string `json:"code" valid:"required,alphanum,length(3|20)"`
+	Name            string `json:"name" valid:"required,length(3|100)"`
+	DurationMinutes int    `json:"duration_minutes" valid:"optional,range(5|480)"`
 }
 
 type RiwayatPenyakitRequest struct {
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"gorm.io/gorm"
+)
+
+// Status riwayat penyakit pasien
+const (
+	MedicalHistoryActive   = "active"
+	MedicalHistoryResolved = "resolved"
+)
+
+// CustomerMedicalHistory records that a customer has, or had, a condition
+// from the RiwayatPenyakit master data.
+type CustomerMedicalHistory struct {
+	ID                uint             `json:"id" gorm:"primaryKey"`
+	CustomerID        string           `json:"customerId" gorm:"index;not null"`
+	RiwayatPenyakitID uint             `json:"riwayatPenyakitId" gorm:"index;not null"`
+	RiwayatPenyakit   *RiwayatPenyakit `json:"riwayatPenyakit,omitempty"`
+	OnsetDate         *time.Time       `json:"onsetDate" gorm:"type:date"`
+	Status            string           `json:"status" gorm:"not null;default:active"`
+	Notes             string           `json:"notes" gorm:"type:text"`
+	RecordedBy        uint             `json:"recordedBy" gorm:"not null"`
+	CreatedAt         time.Time        `json:"createdAt"`
+	UpdatedAt         time.Time        `json:"updatedAt"`
+	DeletedAt         gorm.DeletedAt   `json:"deletedAt" gorm:"index"`
+}
+
+type MedicalHistoryRequest struct {
+	RiwayatPenyakitID uint   `json:"riwayatPenyakitId" valid:"required"`
+	OnsetDate         string `json:"onsetDate"`
+	Status            string `json:"status" valid:"optional,in(active|resolved)"`
+	Notes             string `json:"notes" valid:"optional,length(0|2000)"`
+}
+
+func (r *MedicalHistoryRequest) Validate() error {
+	if _, err := govalidator.ValidateStruct(r); err != nil {
+		return err
+	}
+
+	if r.OnsetDate != "" {
+		onset, err := time.Parse(DateLayout, r.OnsetDate)
+		if err != nil {
+			return errors.New("onsetDate: must be a date formatted as YYYY-MM-DD")
+		}
+		if onset.After(time.Now()) {
+			return errors.New("onsetDate: cannot be in the future")
+		}
+	}
+	return nil
+}
+
+// OnsetTime returns the parsed onset date, or nil if none was given.
+func (r *MedicalHistoryRequest) OnsetTime() *time.Time {
+	if r.OnsetDate == "" {
+		return nil
+	}
+	onset, err := time.Parse(DateLayout, r.OnsetDate)
+	if err != nil {
+		return nil
+	}
+	return &onset
+}
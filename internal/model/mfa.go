@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+
+	"github.com/asaskevich/govalidator"
+)
+
+type MFARecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"index;not null"`
+	CodeHash  string     `json:"-" gorm:"uniqueIndex;not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken     string `json:"mfa_token" valid:"required"`
+	Code         string `json:"code" valid:"optional,numeric,length(6|6)"`
+	RecoveryCode string `json:"recovery_code"`
+
+	// Diisi oleh handler dari request HTTP
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+func (r *MFAVerifyRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
+
+type MFAChallengeRequest struct {
+	MFAToken string `json:"mfa_token" valid:"required"`
+}
+
+func (r *MFAChallengeRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" valid:"required,numeric,length(6|6)"`
+}
+
+func (r *MFACodeRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
+
+type MFAEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
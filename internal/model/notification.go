@@ -0,0 +1,66 @@
+package model
+
+import (
+	"time"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// Status pesan di outbox
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxFailed  = "failed"
+	OutboxSkipped = "skipped"
+)
+
+// OutboxMessage is a notification waiting to be delivered by the worker.
+// Messages caused by an appointment change are written in the same
+// transaction as its AppointmentEvent, so none is lost or sent for a change
+// that was rolled back.
+type OutboxMessage struct {
+	ID                 uint    `json:"id" gorm:"primaryKey"`
+	Channel            string  `json:"channel" gorm:"not null"`
+	Recipient          string  `json:"recipient" gorm:"not null"`
+	Template           string  `json:"template" gorm:"not null"`
+	Language           string  `json:"language" gorm:"size:5;not null"`
+	Subject            string  `json:"subject"`
+	Body               string  `json:"body" gorm:"type:text;not null"`
+	CustomerID         *string `json:"customerId,omitempty" gorm:"index"`
+	AppointmentEventID *uint   `json:"appointmentEventId,omitempty" gorm:"index"`
+	// Janji temu yang dimaksud pesan dan jamnya saat pesan dibuat
+	AppointmentID      *uint      `json:"appointmentId,omitempty" gorm:"index"`
+	AppointmentStartAt *time.Time `json:"appointmentStartAt,omitempty"`
+	// Mencegah pesan yang sama (mis. pengingat) masuk dua kali
+	DedupKey      *string    `json:"dedupKey,omitempty" gorm:"uniqueIndex"`
+	Status        string     `json:"status" gorm:"not null;default:pending;index:idx_outbox_messages_due,priority:1"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt" gorm:"not null;index:idx_outbox_messages_due,priority:2"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+	LastError     string     `json:"lastError,omitempty" gorm:"type:text"`
+	SentAt        *time.Time `json:"sentAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+type OutboxListRequest struct {
+	Status     string `query:"status" valid:"optional,in(pending|sent|failed|skipped)"`
+	CustomerID string `query:"customer_id" valid:"optional,uuid"`
+	Limit      int    `query:"limit" valid:"optional,range(1|200)"`
+}
+
+func (r OutboxListRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
+
+type NotificationPreferencesRequest struct {
+	OptOut bool `json:"optOut"`
+	// Kosong berarti memakai bahasa bawaan klinik
+	Language string `json:"language" valid:"optional,in(id|en)"`
+}
+
+func (r *NotificationPreferencesRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"time"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// Permission names. Code checks these names; which roles hold them is data.
+const (
+	PermUserRead            = "user:read"
+	PermUserWrite           = "user:write"
+	PermUserDelete          = "user:delete"
+	PermUserManageAll       = "user:manage_all"
+	PermRoleRead            = "role:read"
+	PermRoleWrite           = "role:write"
+	PermAPIKeyManage        = "api_key:manage"
+	PermCustomerRead        = "customer:read"
+	PermCustomerWrite       = "customer:write"
+	PermCustomerDelete      = "customer:delete"
+	PermCustomerMerge       = "customer:merge"
+	PermMedicalRead         = "medical_record:read"
+	PermMedicalWrite        = "medical_record:write"
+	PermMedicalAmend        = "medical_record:amend"
+	PermTherapist           = "therapist:treat"
+	PermAppointmentRead     = "appointment:read"
+	PermAppointmentWrite    = "appointment:write"
+	PermAppointmentDesk     = "appointment:front_desk"
+	PermScheduleManage      = "schedule:manage"
+	PermBookingPolicyManage = "booking_policy:manage"
+	PermCalendarAll         = "calendar:all"
+	PermResourceManage      = "resource:manage"
+	PermQueueRead           = "queue:read"
+	PermQueueWrite          = "queue:write"
+	PermNotificationManage  = "notification:manage"
+	PermMasterRead          = "master:read"
+	PermMasterWrite         = "master:write"
+)
+
+// Permissions is the catalogue seeded into the database on startup.
+var Permissions = []Permission{
+	{Name: PermUserRead, Description: "View users with an equal or lower role level"},
+	{Name: PermUserWrite, Description: "Update, unlock and reset users with a lower role level"},
+	{Name: PermUserDelete, Description: "Delete users with a lower role level"},
+	{Name: PermUserManageAll, Description: "Manage users regardless of role level"},
+	{Name: PermRoleRead, Description: "View roles and permissions"},
+	{Name: PermRoleWrite, Description: "Create, update and delete roles"},
+	{Name: PermAPIKeyManage, Description: "Create, list and revoke API keys"},
+	{Name: PermCustomerRead, Description: "View customers"},
+	{Name: PermCustomerWrite, Description: "Create and update customers"},
+	{Name: PermCustomerDelete, Description: "Delete and restore customers"},
+	{Name: PermCustomerMerge, Description: "Merge duplicate customers"},
+	{Name: PermMedicalRead, Description: "View patient medical records"},
+	{Name: PermMedicalWrite, Description: "Record and update patient medical records"},
+	{Name: PermMedicalAmend, Description: "Add addenda to clinical notes signed by someone else"},
+	{Name: PermTherapist, Description: "Treat patients: be booked for appointments and recorded on treatment sessions"},
+	{Name: PermAppointmentRead, Description: "View appointments, slots and therapist schedules"},
+	{Name: PermAppointmentWrite, Description: "Book, reschedule, cancel and check in appointments"},
+	{Name: PermAppointmentDesk, Description: "Book at the front desk: online booking restrictions do not apply and paid deposits can be recorded"},
+	{Name: PermScheduleManage, Description: "Manage therapist working hours, leave and holidays"},
+	{Name: PermBookingPolicyManage, Description: "Manage booking policies for no-shows and late cancellations"},
+	{Name: PermCalendarAll, Description: "Subscribe to the calendar feed of any layanan terapi or room"},
+	{Name: PermResourceManage, Description: "Manage treatment rooms, equipment and what layanan and teknik terapi need"},
+	{Name: PermQueueRead, Description: "View the walk-in queue and its live display feed"},
+	{Name: PermQueueWrite, Description: "Check in customers and call, skip and finish queue tickets"},
+	{Name: PermNotificationManage, Description: "View the notification outbox and retry failed messages"},
+	{Name: PermMasterRead, Description: "View master data"},
+	{Name: PermMasterWrite, Description: "Create, update and delete master data"},
+}
+
+type Permission struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"uniqueIndex;not null"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type RoleRequest struct {
+	Name        string   `json:"name" valid:"required,matches(^[a-z][a-z0-9_]*$),length(3|50)"`
+	Description string   `json:"description" valid:"length(0|255)"`
+	Level       int      `json:"level" valid:"range(1|1000)"`
+	Permissions []string `json:"permissions"`
+}
+
+func (r *RoleRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
@@ -0,0 +1,159 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// Status antrian walk-in
+const (
+	QueueWaiting  = "waiting"
+	QueueCalled   = "called"
+	QueueSkipped  = "skipped"
+	QueueFinished = "finished"
+)
+
+// Aksi petugas pada antrian, sekaligus jenis kejadian yang dikirim ke layar
+const (
+	QueueActionCheckIn = "checked_in"
+	QueueActionCall    = "called"
+	QueueActionRecall  = "recalled"
+	QueueActionSkip    = "skipped"
+	QueueActionFinish  = "finished"
+)
+
+// queueActions maps each action onto the statuses it may start from and the
+// status it leads to. A skipped customer who comes back is recalled.
+var queueActions = map[string]struct {
+	From []string
+	To   string
+}{
+	QueueActionCall:   {From: []string{QueueWaiting}, To: QueueCalled},
+	QueueActionRecall: {From: []string{QueueCalled, QueueSkipped}, To: QueueCalled},
+	QueueActionSkip:   {From: []string{QueueWaiting, QueueCalled}, To: QueueSkipped},
+	QueueActionFinish: {From: []string{QueueCalled}, To: QueueFinished},
+}
+
+// QueueActionTarget returns the status an action leads to from status, and
+// false if the action is not allowed from there.
+func QueueActionTarget(action, status string) (string, bool) {
+	rule, ok := queueActions[action]
+	if !ok {
+		return "", false
+	}
+	for _, from := range rule.From {
+		if from == status {
+			return rule.To, true
+		}
+	}
+	return "", false
+}
+
+// QueueEntry is a customer waiting for a layanan terapi on a given day. Number
+// restarts at 1 every day for every layanan terapi.
+type QueueEntry struct {
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	QueueDate       string         `json:"queueDate" gorm:"size:10;not null;uniqueIndex:idx_queue_entries_number"`
+	LayananTerapiID uint           `json:"layananTerapiId" gorm:"not null;uniqueIndex:idx_queue_entries_number"`
+	LayananTerapi   *LayananTerapi `json:"layananTerapi,omitempty"`
+	Number          int64          `json:"number" gorm:"not null;uniqueIndex:idx_queue_entries_number"`
+	Ticket          string         `json:"ticket" gorm:"not null"`
+	CustomerID      string         `json:"customerId" gorm:"index;not null"`
+	Customer        *Customer      `json:"customer,omitempty"`
+	Status          string         `json:"status" gorm:"index;not null;default:waiting"`
+	// Ruangan tempat pasien dipanggil, ditampilkan di layar ruang tunggu
+	Room       string       `json:"room,omitempty"`
+	CallCount  int          `json:"callCount" gorm:"not null;default:0"`
+	CalledBy   *uint        `json:"calledBy,omitempty"`
+	Caller     *UserSummary `json:"caller,omitempty" gorm:"foreignKey:CalledBy"`
+	CalledAt   *time.Time   `json:"calledAt,omitempty"`
+	FinishedAt *time.Time   `json:"finishedAt,omitempty"`
+	CreatedBy  uint         `json:"createdBy" gorm:"not null"`
+	CreatedAt  time.Time    `json:"createdAt"`
+	UpdatedAt  time.Time    `json:"updatedAt"`
+}
+
+// QueueEvent is one change to the queue as pushed to displays. Its ID is the
+// SSE event id, so a reconnecting display can resume with Last-Event-ID; ids
+// are handed out in commit order so none is missed that way. It carries no
+// patient data because displays are shown in the waiting room.
+type QueueEvent struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	QueueEntryID    uint      `json:"queueEntryId" gorm:"index;not null"`
+	QueueDate       string    `json:"queueDate" gorm:"size:10;index;not null"`
+	LayananTerapiID uint      `json:"layananTerapiId" gorm:"not null"`
+	Type            string    `json:"type" gorm:"not null"`
+	Ticket          string    `json:"ticket" gorm:"not null"`
+	Status          string    `json:"status" gorm:"not null"`
+	Room            string    `json:"room,omitempty"`
+	UserID          uint      `json:"userId" gorm:"not null"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+type QueueCheckInRequest struct {
+	CustomerID      string `json:"customerId" valid:"required,uuid"`
+	LayananTerapiID uint   `json:"layananTerapiId" valid:"required"`
+}
+
+func (r *QueueCheckInRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
+
+type QueueCallRequest struct {
+	Room string `json:"room" valid:"optional,length(0|50)"`
+}
+
+func (r *QueueCallRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
+
+type QueueCallNextRequest struct {
+	LayananTerapiID uint   `json:"layananTerapiId" valid:"required"`
+	Room            string `json:"room" valid:"optional,length(0|50)"`
+}
+
+func (r *QueueCallNextRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
+
+type QueueListRequest struct {
+	// Kosong berarti hari ini
+	Date            string `query:"date"`
+	LayananTerapiID uint   `query:"layanan_terapi_id"`
+	Status          string `query:"status" valid:"optional,in(waiting|called|skipped|finished)"`
+}
+
+func (r QueueListRequest) Validate() error {
+	if _, err := govalidator.ValidateStruct(r); err != nil {
+		return err
+	}
+
+	if r.Date != "" {
+		if _, err := time.Parse(DateLayout, r.Date); err != nil {
+			return errors.New("date: must be a date formatted as YYYY-MM-DD")
+		}
+	}
+	return nil
+}
+
+// QueueTicket is what a waiting-room display shows of a queue entry.
+type QueueTicket struct {
+	QueueEntryID    uint   `json:"queueEntryId"`
+	LayananTerapiID uint   `json:"layananTerapiId"`
+	Ticket          string `json:"ticket"`
+	Status          string `json:"status"`
+	Room            string `json:"room,omitempty"`
+}
+
+// QueueSnapshot is the state of today's queue sent to a display that connects
+// without Last-Event-ID. LastEventID is where it picks up the live feed.
+type QueueSnapshot struct {
+	QueueDate   string        `json:"queueDate"`
+	LastEventID uint          `json:"lastEventId"`
+	Tickets     []QueueTicket `json:"tickets"`
+}
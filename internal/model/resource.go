@@ -0,0 +1,176 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"gorm.io/gorm"
+)
+
+// Jenis sumber daya yang bisa dipesan
+const (
+	ResourceKindRoom      = "room"
+	ResourceKindEquipment = "equipment"
+)
+
+// ResourceType groups interchangeable resources, e.g. every traction bed or
+// every treatment room. Layanan and teknik terapi require resource types, and
+// the scheduler picks a free resource of that type for each appointment.
+type ResourceType struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	Code      string         `json:"code" gorm:"uniqueIndex;not null"`
+	Name      string         `json:"name" gorm:"not null"`
+	Kind      string         `json:"kind" gorm:"not null"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// Resource is one room or device. Capacity is how many appointments it can
+// host at the same time. OpenTime and CloseTime limit it to daily active
+// hours in clinic local time; when empty it is available all day.
+type Resource struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	ResourceTypeID uint           `json:"resourceTypeId" gorm:"index;not null"`
+	ResourceType   *ResourceType  `json:"resourceType,omitempty"`
+	Name           string         `json:"name" gorm:"not null"`
+	Capacity       int            `json:"capacity" gorm:"not null;default:1"`
+	OpenTime       string         `json:"openTime" gorm:"size:5"`
+	CloseTime      string         `json:"closeTime" gorm:"size:5"`
+	Active         bool           `json:"active" gorm:"not null;default:true"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// ResourceRequirement says that appointments for a layanan terapi, or using a
+// teknik terapi, need Quantity resources of a type. Exactly one of
+// LayananTerapiID and TeknikTerapiID is set.
+type ResourceRequirement struct {
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	ResourceTypeID  uint           `json:"resourceTypeId" gorm:"index;not null"`
+	ResourceType    *ResourceType  `json:"resourceType,omitempty"`
+	LayananTerapiID *uint          `json:"layananTerapiId,omitempty" gorm:"index"`
+	LayananTerapi   *LayananTerapi `json:"layananTerapi,omitempty"`
+	TeknikTerapiID  *uint          `json:"teknikTerapiId,omitempty" gorm:"index"`
+	TeknikTerapi    *TeknikTerapi  `json:"teknikTerapi,omitempty"`
+	Quantity        int            `json:"quantity" gorm:"not null;default:1"`
+	CreatedAt       time.Time      `json:"createdAt"`
+}
+
+// AppointmentResource reserves a resource for an appointment. The reservation
+// lasts as long as the appointment and ends when it is cancelled or missed.
+type AppointmentResource struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	AppointmentID uint      `json:"appointmentId" gorm:"index;not null"`
+	ResourceID    uint      `json:"resourceId" gorm:"index;not null"`
+	Resource      *Resource `json:"resource,omitempty"`
+}
+
+// ResourceBooking is a period in which a resource is reserved.
+type ResourceBooking struct {
+	ResourceID    uint
+	AppointmentID uint
+	StartAt       time.Time
+	EndAt         time.Time
+}
+
+// PeakBookings returns the largest number of bookings that take place at the
+// same moment within [start, end). Back-to-back bookings never count together.
+func PeakBookings(bookings []ResourceBooking, start, end time.Time) int {
+	peak := 0
+	for _, candidate := range bookings {
+		// Jumlah terbanyak selalu tercapai di awal periode atau saat suatu booking dimulai
+		at := candidate.StartAt
+		if at.Before(start) {
+			at = start
+		}
+		if !at.Before(end) || !candidate.EndAt.After(at) {
+			continue
+		}
+
+		count := 0
+		for _, booking := range bookings {
+			if !booking.StartAt.After(at) && booking.EndAt.After(at) {
+				count++
+			}
+		}
+		if count > peak {
+			peak = count
+		}
+	}
+	return peak
+}
+
+type ResourceTypeRequest struct {
+	Code string `json:"code" valid:"required,alphanum,length(3|20)"`
+	Name string `json:"name" valid:"required,length(3|100)"`
+	Kind string `json:"kind" valid:"required,in(room|equipment)"`
+}
+
+func (r *ResourceTypeRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
+
+type ResourceRequest struct {
+	ResourceTypeID uint   `json:"resourceTypeId" valid:"required"`
+	Name           string `json:"name" valid:"required,length(2|100)"`
+	Capacity       int    `json:"capacity" valid:"optional,range(1|100)"`
+	OpenTime       string `json:"openTime"`
+	CloseTime      string `json:"closeTime"`
+	// Kosong berarti aktif
+	Active *bool `json:"active"`
+}
+
+func (r *ResourceRequest) Validate() error {
+	if _, err := govalidator.ValidateStruct(r); err != nil {
+		return err
+	}
+
+	if r.OpenTime == "" && r.CloseTime == "" {
+		return nil
+	}
+	open, err := time.Parse(ClockLayout, r.OpenTime)
+	if err != nil {
+		return errors.New("openTime: must be formatted as HH:MM")
+	}
+	closing, err := time.Parse(ClockLayout, r.CloseTime)
+	if err != nil {
+		return errors.New("closeTime: must be formatted as HH:MM")
+	}
+	if !closing.After(open) {
+		return errors.New("closeTime: must be after openTime")
+	}
+
+	r.OpenTime = open.Format(ClockLayout)
+	r.CloseTime = closing.Format(ClockLayout)
+	return nil
+}
+
+type ResourceListRequest struct {
+	ResourceTypeID uint `query:"resource_type_id"`
+}
+
+type ResourceRequirementRequest struct {
+	ResourceTypeID  uint  `json:"resourceTypeId" valid:"required"`
+	LayananTerapiID *uint `json:"layananTerapiId"`
+	TeknikTerapiID  *uint `json:"teknikTerapiId"`
+	Quantity        int   `json:"quantity" valid:"optional,range(1|10)"`
+}
+
+func (r *ResourceRequirementRequest) Validate() error {
+	if _, err := govalidator.ValidateStruct(r); err != nil {
+		return err
+	}
+	if (r.LayananTerapiID == nil) == (r.TeknikTerapiID == nil) {
+		return errors.New("set exactly one of layananTerapiId and teknikTerapiId")
+	}
+	return nil
+}
+
+type ResourceRequirementListRequest struct {
+	LayananTerapiID uint `query:"layanan_terapi_id"`
+	TeknikTerapiID  uint `query:"teknik_terapi_id"`
+}
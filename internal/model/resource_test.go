@@ -0,0 +1,64 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeakBookings(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 1, 5, hour, minute, 0, 0, time.UTC)
+	}
+	booking := func(fromHour, fromMinute, toHour, toMinute int) ResourceBooking {
+		return ResourceBooking{StartAt: at(fromHour, fromMinute), EndAt: at(toHour, toMinute)}
+	}
+
+	tests := []struct {
+		name     string
+		bookings []ResourceBooking
+		want     int
+	}{
+		{
+			name: "none",
+			want: 0,
+		},
+		{
+			name:     "back to back",
+			bookings: []ResourceBooking{booking(9, 0, 9, 30), booking(9, 30, 10, 0)},
+			want:     1,
+		},
+		{
+			name:     "overlapping",
+			bookings: []ResourceBooking{booking(9, 0, 9, 45), booking(9, 30, 10, 0)},
+			want:     2,
+		},
+		{
+			name:     "running since before the period",
+			bookings: []ResourceBooking{booking(8, 0, 9, 15), booking(8, 30, 9, 10), booking(9, 20, 9, 40)},
+			want:     2,
+		},
+		{
+			name:     "ending when the period starts",
+			bookings: []ResourceBooking{booking(8, 0, 9, 0), booking(9, 0, 9, 30)},
+			want:     1,
+		},
+		{
+			name:     "starting when the period ends",
+			bookings: []ResourceBooking{booking(9, 0, 9, 30), booking(10, 0, 10, 30)},
+			want:     1,
+		},
+		{
+			name:     "three at the busiest moment",
+			bookings: []ResourceBooking{booking(9, 0, 10, 0), booking(9, 10, 9, 20), booking(9, 15, 9, 50), booking(9, 50, 10, 0)},
+			want:     3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PeakBookings(tt.bookings, at(9, 0), at(10, 0)); got != tt.want {
+				t.Errorf("PeakBookings() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,12 +5,28 @@ import (
 	"time"
 )
 
+// Role groups permissions. Level orders roles so users can only manage
+// users whose role is below their own; IsSystem marks the seeded roles,
+// which cannot be renamed or deleted.
 type Role struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
-	Name        string         `json:"name" gorm:"uniqueIndex;not null"`
+	Name        string         `json:"name" gorm:"uniqueIndex:idx_roles_name,where:deleted_at IS NULL;not null"`
 	Description string         `json:"description"`
+	Level       int            `json:"level" gorm:"not null;default:0"`
+	IsSystem    bool           `json:"is_system" gorm:"not null;default:false"`
+	Permissions []Permission   `json:"permissions,omitempty" gorm:"many2many:role_permissions"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 	Users       []User         `json:"users" gorm:"foreignKey:RoleID"`
 }
+
+// HasPermission reports whether the role was granted name. Permissions must be loaded.
+func (r *Role) HasPermission(name string) bool {
+	for _, p := range r.Permissions {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
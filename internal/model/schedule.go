@@ -0,0 +1,124 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// ClockLayout is the format of times of day in working hours.
+const ClockLayout = "15:04"
+
+// Jenis pengecualian jadwal
+const (
+	ScheduleExceptionLeave   = "leave"
+	ScheduleExceptionHoliday = "holiday"
+)
+
+// WorkingHour is a weekly recurring block in which a therapist can be booked.
+// A therapist may have several blocks per weekday, e.g. a split shift.
+// Weekday follows time.Weekday (0 = Sunday); times are clinic local time.
+type WorkingHour struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	TherapistID uint         `json:"therapistId" gorm:"index:idx_working_hours_therapist_weekday;not null"`
+	Therapist   *UserSummary `json:"therapist,omitempty" gorm:"foreignKey:TherapistID"`
+	Weekday     int          `json:"weekday" gorm:"index:idx_working_hours_therapist_weekday;not null"`
+	StartTime   string       `json:"startTime" gorm:"size:5;not null"`
+	EndTime     string       `json:"endTime" gorm:"size:5;not null"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
+}
+
+// Overlaps reports whether two blocks on the same weekday overlap.
+func (w *WorkingHour) Overlaps(other *WorkingHour) bool {
+	return w.Weekday == other.Weekday && w.StartTime < other.EndTime && other.StartTime < w.EndTime
+}
+
+type WorkingHourRequest struct {
+	TherapistID uint   `json:"therapistId" valid:"required"`
+	Weekday     int    `json:"weekday" valid:"range(0|6)"`
+	StartTime   string `json:"startTime" valid:"required"`
+	EndTime     string `json:"endTime" valid:"required"`
+}
+
+func (r *WorkingHourRequest) Validate() error {
+	if _, err := govalidator.ValidateStruct(r); err != nil {
+		return err
+	}
+
+	start, err := time.Parse(ClockLayout, r.StartTime)
+	if err != nil {
+		return errors.New("startTime: must be formatted as HH:MM")
+	}
+	end, err := time.Parse(ClockLayout, r.EndTime)
+	if err != nil {
+		return errors.New("endTime: must be formatted as HH:MM")
+	}
+	if !end.After(start) {
+		return errors.New("endTime: must be after startTime")
+	}
+
+	// Simpan dalam bentuk baku agar perbandingan string tetap benar
+	r.StartTime = start.Format(ClockLayout)
+	r.EndTime = end.Format(ClockLayout)
+	return nil
+}
+
+// ScheduleException blocks time in which a therapist normally works: leave for
+// one therapist, or a clinic holiday for everyone when TherapistID is nil.
+type ScheduleException struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	TherapistID *uint        `json:"therapistId" gorm:"index"`
+	Therapist   *UserSummary `json:"therapist,omitempty" gorm:"foreignKey:TherapistID"`
+	Kind        string       `json:"kind" gorm:"not null"`
+	StartAt     time.Time    `json:"startAt" gorm:"index;not null"`
+	EndAt       time.Time    `json:"endAt" gorm:"index;not null"`
+	Reason      string       `json:"reason"`
+	CreatedBy   uint         `json:"createdBy" gorm:"not null"`
+	CreatedAt   time.Time    `json:"createdAt"`
+}
+
+type ScheduleExceptionRequest struct {
+	TherapistID *uint     `json:"therapistId"`
+	Kind        string    `json:"kind" valid:"required,in(leave|holiday)"`
+	StartAt     time.Time `json:"startAt" valid:"required"`
+	EndAt       time.Time `json:"endAt" valid:"required"`
+	Reason      string    `json:"reason" valid:"optional,length(0|500)"`
+}
+
+func (r *ScheduleExceptionRequest) Validate() error {
+	if _, err := govalidator.ValidateStruct(r); err != nil {
+		return err
+	}
+
+	if !r.EndAt.After(r.StartAt) {
+		return errors.New("endAt: must be after startAt")
+	}
+	switch {
+	case r.Kind == ScheduleExceptionLeave && r.TherapistID == nil:
+		return errors.New("therapistId: is required for leave")
+	case r.Kind == ScheduleExceptionHoliday && r.TherapistID != nil:
+		return errors.New("therapistId: a holiday applies to every therapist")
+	}
+	return nil
+}
+
+type ScheduleListRequest struct {
+	TherapistID uint   `query:"therapist_id"`
+	From        string `query:"from"`
+	To          string `query:"to"`
+}
+
+func (r ScheduleListRequest) Validate() error {
+	for name, value := range map[string]string{"from": r.From, "to": r.To} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse(DateLayout, value); err != nil {
+			return fmt.Errorf("%s: must be a date formatted as YYYY-MM-DD", name)
+		}
+	}
+	return nil
+}
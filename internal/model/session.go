@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// UserSession represents one login on one device. Its ID is carried in the
+// access token ("sid" claim) and is also the family ID of the session's
+// refresh tokens, so revoking the session ends both.
+type UserSession struct {
+	ID           string     `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"index;not null"`
+	UserAgent    string     `json:"user_agent"`
+	IPAddress    string     `json:"ip_address"`
+	LastSeenAt   time.Time  `json:"last_seen_at"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"index;not null"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	RevokeReason string     `json:"revoke_reason"`
+	CreatedAt    time.Time  `json:"created_at"`
+
+	// Diisi saat menampilkan daftar sesi milik user yang sedang login
+	Current bool `json:"current" gorm:"-"`
+}
+
+// IsActive reports whether the session can still be used at t.
+func (s *UserSession) IsActive(t time.Time) bool {
+	return s.RevokedAt == nil && t.Before(s.ExpiresAt)
+}
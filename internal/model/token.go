@@ -8,9 +8,37 @@ import (
 type BlacklistedToken struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
 	Token     string         `json:"token" gorm:"uniqueIndex;not null"`
+	JTI       string         `json:"jti" gorm:"index"`
 	ExpiresAt time.Time      `json:"expires_at" gorm:"not null"`
 	UserID    uint           `json:"user_id" gorm:"not null"`
 	Reason    string         `json:"reason" gorm:"default:'logout'"`
 	CreatedAt time.Time      `json:"created_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
+
+// RefreshToken stores the hash of an opaque refresh token. Tokens issued by
+// rotating one another share the same FamilyID, so replaying an already
+// rotated token can revoke the whole chain.
+type RefreshToken struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	TokenHash    string     `json:"-" gorm:"uniqueIndex;not null"`
+	FamilyID     string     `json:"family_id" gorm:"index;not null"`
+	UserID       uint       `json:"user_id" gorm:"index;not null"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	RevokeReason string     `json:"revoke_reason"`
+	ReplacedByID *uint      `json:"replaced_by_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// PasswordResetToken is a single-use token issued by an administrator so a
+// user can choose a new password.
+type PasswordResetToken struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	TokenHash   string     `json:"-" gorm:"uniqueIndex;not null"`
+	UserID      uint       `json:"user_id" gorm:"index;not null"`
+	RequestedBy uint       `json:"requested_by" gorm:"not null"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt      *time.Time `json:"used_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
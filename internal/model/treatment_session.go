@@ -0,0 +1,94 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"gorm.io/gorm"
+)
+
+// TreatmentSession is one therapy session given to a customer: which
+// therapist performed which LayananTerapi, using which TeknikTerapi.
+type TreatmentSession struct {
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	CustomerID      string         `json:"customerId" gorm:"index:idx_treatment_sessions_customer_started;not null"`
+	TherapistID     uint           `json:"therapistId" gorm:"index;not null"`
+	Therapist       *UserSummary   `json:"therapist,omitempty" gorm:"foreignKey:TherapistID"`
+	StartedAt       time.Time      `json:"startedAt" gorm:"index:idx_treatment_sessions_customer_started;not null"`
+	DurationMinutes int            `json:"durationMinutes" gorm:"not null"`
+	LayananTerapiID uint           `json:"layananTerapiId" gorm:"index;not null"`
+	LayananTerapi   *LayananTerapi `json:"layananTerapi,omitempty"`
+	TeknikTerapi    []TeknikTerapi `json:"teknikTerapi" gorm:"many2many:treatment_session_teknik_terapi"`
+	Complaint       string         `json:"complaint" gorm:"type:text"`
+	Outcome         string         `json:"outcome" gorm:"type:text"`
+	RecordedBy      uint           `json:"recordedBy" gorm:"not null"`
+	CreatedAt       time.Time      `json:"createdAt"`
+	UpdatedAt       time.Time      `json:"updatedAt"`
+	DeletedAt       gorm.DeletedAt `json:"deletedAt" gorm:"index"`
+}
+
+type TreatmentSessionRequest struct {
+	TherapistID     uint      `json:"therapistId" valid:"required"`
+	StartedAt       time.Time `json:"startedAt" valid:"required"`
+	DurationMinutes int       `json:"durationMinutes" valid:"required,range(1|600)"`
+	LayananTerapiID uint      `json:"layananTerapiId" valid:"required"`
+	TeknikTerapiIDs []uint    `json:"teknikTerapiIds"`
+	Complaint       string    `json:"complaint" valid:"optional,length(0|2000)"`
+	Outcome         string    `json:"outcome" valid:"optional,length(0|2000)"`
+}
+
+func (r *TreatmentSessionRequest) Validate() error {
+	if _, err := govalidator.ValidateStruct(r); err != nil {
+		return err
+	}
+
+	if len(r.TeknikTerapiIDs) == 0 {
+		return errors.New("teknikTerapiIds: at least one teknik terapi is required")
+	}
+	seen := make(map[uint]bool, len(r.TeknikTerapiIDs))
+	for _, id := range r.TeknikTerapiIDs {
+		if id == 0 || seen[id] {
+			return errors.New("teknikTerapiIds: ids must be unique and non-zero")
+		}
+		seen[id] = true
+	}
+
+	// Sesi boleh dicatat belakangan, tapi tidak untuk masa depan
+	if r.StartedAt.After(time.Now().Add(time.Hour)) {
+		return errors.New("startedAt: cannot be in the future")
+	}
+	return nil
+}
+
+// Jenis kejadian pada timeline pasien
+const (
+	TimelineTreatmentSession = "treatment_session"
+	TimelineMedicalHistory   = "medical_history"
+)
+
+// TimelineEntry is one event in a customer's timeline. Exactly one of the
+// record fields is set, matching Type.
+type TimelineEntry struct {
+	Type             string                  `json:"type"`
+	OccurredAt       time.Time               `json:"occurredAt"`
+	TreatmentSession *TreatmentSession       `json:"treatmentSession,omitempty"`
+	MedicalHistory   *CustomerMedicalHistory `json:"medicalHistory,omitempty"`
+}
+
+type TimelineRequest struct {
+	From string `query:"from"`
+	To   string `query:"to"`
+}
+
+func (r TimelineRequest) Validate() error {
+	for name, value := range map[string]string{"from": r.From, "to": r.To} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse(DateLayout, value); err != nil {
+			return errors.New(name + ": must be a date formatted as YYYY-MM-DD")
+		}
+	}
+	return nil
+}
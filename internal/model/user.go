@@ -19,6 +19,18 @@ type User struct {
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 	Fullname  string         `json:"fullname" gorm:"nullable"`
 	Jabatan   string         `json:"jabatan" gorm:"nullable"`
+
+	PasswordChangedAt *time.Time `json:"password_changed_at"`
+	// Naik setiap password diganti; token dengan versi lain tidak berlaku lagi
+	TokenVersion uint `json:"-" gorm:"not null;default:0"`
+
+	FailedLoginCount  int        `json:"failed_login_count" gorm:"not null;default:0"`
+	LastFailedLoginAt *time.Time `json:"last_failed_login_at"`
+	LockedUntil       *time.Time `json:"locked_until"`
+
+	MFAEnabled      bool   `json:"mfa_enabled" gorm:"not null;default:false"`
+	MFASecret       string `json:"-"`
+	MFALastUsedStep int64  `json:"-" gorm:"not null;default:0"`
 }
 
 func (u *User) Validate() error {
@@ -43,6 +55,10 @@ func (r *RegisterRequest) Validate() error {
 type LoginRequest struct {
 	Username string `json:"username" valid:"required"`
 	Password string `json:"password" valid:"required"`
+
+	// Diisi oleh handler dari request HTTP
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
 func (l *LoginRequest) Validate() error {
@@ -51,11 +67,35 @@ func (l *LoginRequest) Validate() error {
 }
 
 type LoginResponse struct {
-	AccessToken string `json:"access_token"`
-	Role        string `json:"role"`
-	UserID      uint   `json:"user_id"`
-	Username    string `json:"username"`
-	Email       string `json:"email"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	Role         string `json:"role"`
+	UserID       uint   `json:"user_id"`
+	Username     string `json:"username"`
+	Email        string `json:"email"`
+
+	// Diisi jika login membutuhkan verifikasi MFA
+	MFARequired           bool     `json:"mfa_required,omitempty"`
+	MFAEnrollmentRequired bool     `json:"mfa_enrollment_required,omitempty"`
+	MFAToken              string   `json:"mfa_token,omitempty"`
+	RecoveryCodes         []string `json:"recovery_codes,omitempty"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" valid:"required"`
+
+	// Diisi oleh handler dari request HTTP
+	IPAddress string `json:"-"`
+}
+
+func (r *RefreshTokenRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
 type UpdateUserRequest struct {
@@ -73,3 +113,36 @@ func (r *UpdateUserRequest) Validate() error {
 	_, err := govalidator.ValidateStruct(r)
 	return err
 }
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" valid:"required"`
+	NewPassword     string `json:"new_password" valid:"required,length(6|100)"`
+}
+
+func (r *ChangePasswordRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" valid:"required"`
+	NewPassword string `json:"new_password" valid:"required,length(6|100)"`
+}
+
+func (r *ResetPasswordRequest) Validate() error {
+	_, err := govalidator.ValidateStruct(r)
+	return err
+}
+
+// UserSummary is the part of a user shown on records they took part in, e.g.
+// as the therapist of a treatment session.
+type UserSummary struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Fullname string `json:"fullname"`
+	Jabatan  string `json:"jabatan"`
+}
+
+func (UserSummary) TableName() string {
+	return "users"
+}
@@ -0,0 +1,63 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const gatewayTimeout = 15 * time.Second
+
+// gatewayNotifier posts messages to a WhatsApp or SMS HTTP gateway as
+// {"channel", "to", "subject", "body"} JSON, authenticated with a Bearer token.
+type gatewayNotifier struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewGatewayNotifier returns a notifier delivering through the HTTP gateway at url.
+func NewGatewayNotifier(url, token string) Notifier {
+	return &gatewayNotifier{url: url, token: token, client: &http.Client{Timeout: gatewayTimeout}}
+}
+
+func (n *gatewayNotifier) Send(msg Message) error {
+	payload, err := json.Marshal(map[string]string{
+		"channel": msg.Channel,
+		"to":      msg.To,
+		"subject": msg.Subject,
+		"body":    msg.Body,
+	})
+	if err != nil {
+		return Permanent(err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, n.url, bytes.NewReader(payload))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("gateway responded %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	// Nomor atau isi pesan ditolak; mengulang tidak akan berhasil
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return err
+}
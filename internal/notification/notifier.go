@@ -0,0 +1,115 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Message is a single outgoing notification.
+type Message struct {
+	Channel string
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages to their recipient.
+type Notifier interface {
+	Send(msg Message) error
+}
+
+// Channel tujuan pesan
+const (
+	ChannelEmail    = "email"
+	ChannelWhatsApp = "whatsapp"
+	ChannelSMS      = "sms"
+)
+
+// PermanentError marks a delivery failure that will not go away by retrying,
+// such as a recipient the gateway rejects.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// Permanent wraps err so that IsPermanent reports true for it.
+func Permanent(err error) error {
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent reports whether err should not be retried.
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// NewNotifier returns the notifier for the given driver ("log" or "file").
+func NewNotifier(driver, filePath string) (Notifier, error) {
+	switch driver {
+	case "", "log":
+		return &logNotifier{}, nil
+	case "file":
+		return &fileNotifier{path: filePath}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier driver: %s", driver)
+	}
+}
+
+// logNotifier writes messages to the application log. Only meant for local use.
+type logNotifier struct{}
+
+func (n *logNotifier) Send(msg Message) error {
+	logrus.WithFields(logrus.Fields{
+		"channel": msg.Channel,
+		"to":      msg.To,
+		"subject": msg.Subject,
+	}).Info(msg.Body)
+	return nil
+}
+
+// fileNotifier appends messages to a file so they can be read during development.
+type fileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func (n *fileNotifier) Send(msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "=== %s\nChannel: %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC3339), msg.Channel, msg.To, msg.Subject, msg.Body)
+	return err
+}
+
+// router hands every message to the notifier configured for its channel, and
+// to fallback for channels without one.
+type router struct {
+	channels map[string]Notifier
+	fallback Notifier
+}
+
+// NewRouter returns a notifier dispatching on Message.Channel.
+func NewRouter(fallback Notifier, channels map[string]Notifier) Notifier {
+	return &router{channels: channels, fallback: fallback}
+}
+
+func (r *router) Send(msg Message) error {
+	if notifier, ok := r.channels[msg.Channel]; ok {
+		return notifier.Send(msg)
+	}
+	return r.fallback.Send(msg)
+}
@@ -0,0 +1,45 @@
+package notification
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// smtpNotifier sends messages as plain-text email. Authentication is only
+// used when a username is set, so a local stand-in such as cmd/smtpsink or
+// Mailpit works without credentials.
+type smtpNotifier struct {
+	addr     string
+	from     string
+	username string
+	password string
+}
+
+// NewSMTPNotifier returns a notifier delivering through the SMTP server at
+// addr ("host:port").
+func NewSMTPNotifier(addr, from, username, password string) Notifier {
+	return &smtpNotifier{addr: addr, from: from, username: username, password: password}
+}
+
+func (n *smtpNotifier) Send(msg Message) error {
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return Permanent(fmt.Errorf("invalid email header"))
+	}
+
+	var auth smtp.Auth
+	if n.username != "" {
+		host, _, err := net.SplitHostPort(n.addr)
+		if err != nil {
+			return Permanent(err)
+		}
+		auth = smtp.PlainAuth("", n.username, n.password, host)
+	}
+
+	body := strings.ReplaceAll(msg.Body, "\n", "\r\n")
+	data := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		n.from, msg.To, msg.Subject, time.Now().Format(time.RFC1123Z), body)
+	return smtp.SendMail(n.addr, auth, n.from, []string{msg.To}, []byte(data))
+}
@@ -0,0 +1,109 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Nama template pesan untuk pasien
+const (
+	TemplateAppointmentBooked    = "appointment_booked"
+	TemplateAppointmentReminder  = "appointment_reminder"
+	TemplateAppointmentCancelled = "appointment_cancelled"
+)
+
+// Bahasa pesan yang didukung
+const (
+	LanguageIndonesian = "id"
+	LanguageEnglish    = "en"
+)
+
+// TemplateData holds the values a message template can use. At is shown in
+// its own location.
+type TemplateData struct {
+	CustomerName  string
+	LayananTerapi string
+	ClinicName    string
+	At            time.Time
+}
+
+type messageTemplate struct {
+	subject string
+	body    *template.Template
+}
+
+// templates holds every template per language. Date and Time are formatted
+// for the language before rendering.
+var templates = map[string]map[string]messageTemplate{
+	LanguageIndonesian: {
+		TemplateAppointmentBooked: newMessageTemplate("Konfirmasi janji temu",
+			"Halo {{.CustomerName}}, janji temu {{.LayananTerapi}} Anda di {{.ClinicName}} sudah terjadwal pada {{.Date}} pukul {{.Time}}. "+
+				"Hubungi kami jika perlu mengubah jadwal."),
+		TemplateAppointmentReminder: newMessageTemplate("Pengingat janji temu",
+			"Halo {{.CustomerName}}, kami mengingatkan janji temu {{.LayananTerapi}} Anda di {{.ClinicName}} pada {{.Date}} pukul {{.Time}}. "+
+				"Mohon datang 10 menit lebih awal."),
+		TemplateAppointmentCancelled: newMessageTemplate("Janji temu dibatalkan",
+			"Halo {{.CustomerName}}, janji temu {{.LayananTerapi}} Anda di {{.ClinicName}} pada {{.Date}} pukul {{.Time}} telah dibatalkan. "+
+				"Hubungi kami untuk menjadwalkan ulang."),
+	},
+	LanguageEnglish: {
+		TemplateAppointmentBooked: newMessageTemplate("Appointment confirmed",
+			"Hello {{.CustomerName}}, your {{.LayananTerapi}} appointment at {{.ClinicName}} is scheduled for {{.Date}} at {{.Time}}. "+
+				"Please contact us if you need to change it."),
+		TemplateAppointmentReminder: newMessageTemplate("Appointment reminder",
+			"Hello {{.CustomerName}}, this is a reminder of your {{.LayananTerapi}} appointment at {{.ClinicName}} on {{.Date}} at {{.Time}}. "+
+				"Please arrive 10 minutes early."),
+		TemplateAppointmentCancelled: newMessageTemplate("Appointment cancelled",
+			"Hello {{.CustomerName}}, your {{.LayananTerapi}} appointment at {{.ClinicName}} on {{.Date}} at {{.Time}} has been cancelled. "+
+				"Please contact us to book a new time."),
+	},
+}
+
+var (
+	indonesianDays   = []string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+	indonesianMonths = []string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+)
+
+func newMessageTemplate(subject, body string) messageTemplate {
+	return messageTemplate{subject: subject, body: template.Must(template.New("").Parse(body))}
+}
+
+// SupportedLanguage reports whether messages can be written in language.
+func SupportedLanguage(language string) bool {
+	_, ok := templates[language]
+	return ok
+}
+
+// Render returns the subject and body of a template in the given language.
+func Render(name, language string, data TemplateData) (string, string, error) {
+	tmpl, ok := templates[language][name]
+	if !ok {
+		return "", "", fmt.Errorf("no %s template %q", language, name)
+	}
+
+	values := struct {
+		TemplateData
+		Date string
+		Time string
+	}{
+		TemplateData: data,
+		Date:         formatDate(data.At, language),
+		Time:         data.At.Format("15:04 MST"),
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.body.Execute(&body, values); err != nil {
+		return "", "", err
+	}
+	return tmpl.subject, strings.TrimSpace(body.String()), nil
+}
+
+func formatDate(t time.Time, language string) string {
+	if language == LanguageIndonesian {
+		return fmt.Sprintf("%s, %d %s %d", indonesianDays[t.Weekday()], t.Day(), indonesianMonths[t.Month()-1], t.Year())
+	}
+	return t.Format("Monday, 2 January 2006")
+}
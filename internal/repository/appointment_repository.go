@@ -0,0 +1,426 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"sim-clinic-api/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrAppointmentConflict is returned when an appointment would overlap
+// another appointment of the same therapist.
+var ErrAppointmentConflict = errors.New("appointment overlaps another appointment")
+
+// ErrResourceConflict is returned when a room or device reserved for an
+// appointment was fully booked by someone else in the meantime.
+var ErrResourceConflict = errors.New("resource is fully booked")
+
+// errAppointmentChanged rolls back UpdateMany when an appointment changed status.
+var errAppointmentChanged = errors.New("appointment changed")
+
+// exclusionViolation is the SQLSTATE of a violated exclusion constraint.
+const exclusionViolation = "23P01"
+
+// therapistOverlapConstraint keeps appointments of one therapist apart. It is
+// created by database.AutoMigrate and checked at commit time when deferred.
+const therapistOverlapConstraint = "appointments_therapist_no_overlap"
+
+type appointmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAppointmentRepository(db *gorm.DB) AppointmentRepository {
+	return &appointmentRepository{db: db}
+}
+
+func preloadAppointment(db *gorm.DB) *gorm.DB {
+	unscoped := func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped()
+	}
+	return db.
+		Preload("Customer", unscoped).
+		Preload("Therapist", unscoped).
+		Preload("LayananTerapi", unscoped).
+		Preload("TeknikTerapi", unscoped).
+		Preload("Resources.Resource", unscoped)
+}
+
+// Create stores a new appointment together with its booking event and the
+// resources reserved for it.
+func (r *appointmentRepository) Create(appointment *model.Appointment, event *model.AppointmentEvent) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockResources(tx, []model.Appointment{*appointment}); err != nil {
+			return err
+		}
+		if err := tx.Omit(appointmentAssociations...).Create(appointment).Error; err != nil {
+			return err
+		}
+		if err := reserveResources(tx, appointment); err != nil {
+			return err
+		}
+		return createAppointmentEvent(tx, appointment, event)
+	})
+	return mapAppointmentError(err)
+}
+
+// FindByID returns an appointment, or nil if there is none.
+func (r *appointmentRepository) FindByID(id uint) (*model.Appointment, error) {
+	var appointments []model.Appointment
+	err := preloadAppointment(r.db).Where("id = ?", id).Find(&appointments).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(appointments) < 1 {
+		return nil, nil
+	}
+	return &appointments[0], nil
+}
+
+// Find returns the appointments starting within [from, to) that match the
+// filters, in chronological order.
+func (r *appointmentRepository) Find(from, to time.Time, therapistID uint, customerID, status string) ([]model.Appointment, error) {
+	var appointments []model.Appointment
+	query := preloadAppointment(r.db)
+	if !from.IsZero() {
+		query = query.Where("start_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("start_at < ?", to)
+	}
+	if therapistID != 0 {
+		query = query.Where("therapist_id = ?", therapistID)
+	}
+	if customerID != "" {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("start_at, id").Find(&appointments).Error
+	return appointments, err
+}
+
+// FindForCalendar returns the appointments starting within [from, to) of one
+// therapist, of one layanan terapi or reserving one resource. Cancelled
+// appointments are included if they were cancelled after cancelledSince, so
+// calendar apps can remove them.
+func (r *appointmentRepository) FindForCalendar(from, to, cancelledSince time.Time, therapistID, layananTerapiID, resourceID uint) ([]model.Appointment, error) {
+	var appointments []model.Appointment
+	query := preloadAppointment(r.db).
+		Where("start_at >= ? AND start_at < ?", from, to).
+		Where("status <> ? OR cancelled_at >= ?", model.AppointmentCancelled, cancelledSince)
+	if therapistID != 0 {
+		query = query.Where("therapist_id = ?", therapistID)
+	}
+	if layananTerapiID != 0 {
+		query = query.Where("layanan_terapi_id = ?", layananTerapiID)
+	}
+	if resourceID != 0 {
+		// Reservasi janji yang batal tidak dihapus, jadi tetap ikut tampil
+		query = query.Where("id IN (SELECT appointment_id FROM appointment_resources WHERE resource_id = ?)", resourceID)
+	}
+	err := query.Order("start_at, id").Find(&appointments).Error
+	return appointments, err
+}
+
+// FindBusy returns the appointments of a therapist that keep them busy
+// somewhere within [from, to).
+func (r *appointmentRepository) FindBusy(therapistID uint, from, to time.Time) ([]model.Appointment, error) {
+	var appointments []model.Appointment
+	err := r.db.
+		Where("therapist_id = ? AND start_at < ? AND end_at > ?", therapistID, to, from).
+		Where("status NOT IN ?", []string{model.AppointmentCancelled, model.AppointmentNoShow}).
+		Order("start_at").
+		Find(&appointments).Error
+	return appointments, err
+}
+
+func (r *appointmentRepository) FindEvents(appointmentID uint) ([]model.AppointmentEvent, error) {
+	var events []model.AppointmentEvent
+	err := r.db.Where("appointment_id = ?", appointmentID).Order("created_at, id").Find(&events).Error
+	return events, err
+}
+
+// Update saves the schedule and status of an appointment and records the
+// event, provided the appointment still has expectedStatus. It returns false
+// if the status changed in the meantime. When Resources is not nil it replaces
+// the resources reserved for the appointment.
+func (r *appointmentRepository) Update(appointment *model.Appointment, expectedStatus string, event *model.AppointmentEvent) (bool, error) {
+	return r.UpdateMany([]model.Appointment{*appointment}, expectedStatus, []model.AppointmentEvent{*event})
+}
+
+// UpdateMany is Update for several appointments at once; events[i] belongs to
+// appointments[i]. Either all appointments are saved or none: it returns
+// false if any of them no longer has expectedStatus. Overlaps are checked
+// when the transaction commits, so appointments of a series may move into
+// each other's old slots.
+func (r *appointmentRepository) UpdateMany(appointments []model.Appointment, expectedStatus string, events []model.AppointmentEvent) (bool, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Baris diperbarui satu per satu; janji yang belum digeser tidak boleh menghalangi
+		if err := tx.Exec("SET CONSTRAINTS " + therapistOverlapConstraint + " DEFERRED").Error; err != nil {
+			return err
+		}
+		if err := lockResources(tx, appointments); err != nil {
+			return err
+		}
+
+		// Resources nil berarti reservasi tidak berubah, misalnya saat ganti status.
+		// Reservasi lama dilepas dulu semuanya agar janji yang digeser tidak saling menghalangi.
+		var moving []uint
+		for i := range appointments {
+			if appointments[i].Resources != nil {
+				moving = append(moving, appointments[i].ID)
+			}
+		}
+		if len(moving) > 0 {
+			if err := tx.Where("appointment_id IN ?", moving).Delete(&model.AppointmentResource{}).Error; err != nil {
+				return err
+			}
+		}
+
+		for i := range appointments {
+			result := tx.Model(&model.Appointment{}).
+				Where("id = ? AND status = ?", appointments[i].ID, expectedStatus).
+				Updates(map[string]interface{}{
+					"therapist_id":  appointments[i].TherapistID,
+					"start_at":      appointments[i].StartAt,
+					"end_at":        appointments[i].EndAt,
+					"status":        appointments[i].Status,
+					"cancel_reason": appointments[i].CancelReason,
+					"cancelled_by":  appointments[i].CancelledBy,
+					"cancelled_at":  appointments[i].CancelledAt,
+					"updated_at":    time.Now(),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errAppointmentChanged
+			}
+			if err := reserveResources(tx, &appointments[i]); err != nil {
+				return err
+			}
+
+			if err := createAppointmentEvent(tx, &appointments[i], &events[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errAppointmentChanged) {
+		return false, nil
+	}
+	if err != nil {
+		return false, mapAppointmentError(err)
+	}
+	return true, nil
+}
+
+// createAppointmentEvent records an event of appointment with its outbox
+// messages. The messages remember the appointment and its start, so the
+// worker can drop them if it changes again before they are sent.
+func createAppointmentEvent(tx *gorm.DB, appointment *model.Appointment, event *model.AppointmentEvent) error {
+	event.AppointmentID = appointment.ID
+	for i := range event.Notifications {
+		startAt := appointment.StartAt
+		event.Notifications[i].AppointmentID = &event.AppointmentID
+		event.Notifications[i].AppointmentStartAt = &startAt
+	}
+	return tx.Create(event).Error
+}
+
+// CreateSeries stores a series with its appointments and their booking
+// events; events[i] belongs to appointments[i].
+func (r *appointmentRepository) CreateSeries(series *model.AppointmentSeries, appointments []model.Appointment, events []model.AppointmentEvent) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockResources(tx, appointments); err != nil {
+			return err
+		}
+		if err := tx.Omit("Appointments").Create(series).Error; err != nil {
+			return err
+		}
+
+		for i := range appointments {
+			appointments[i].SeriesID = &series.ID
+			if err := tx.Omit(appointmentAssociations...).Create(&appointments[i]).Error; err != nil {
+				return err
+			}
+			if err := reserveResources(tx, &appointments[i]); err != nil {
+				return err
+			}
+			if err := createAppointmentEvent(tx, &appointments[i], &events[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return mapAppointmentError(err)
+}
+
+// FindSeriesByID returns a series with all its appointments, or nil if there is none.
+func (r *appointmentRepository) FindSeriesByID(id uint) (*model.AppointmentSeries, error) {
+	var series []model.AppointmentSeries
+	err := r.db.
+		Preload("Appointments", func(tx *gorm.DB) *gorm.DB {
+			return preloadAppointment(tx).Order("start_at, id")
+		}).
+		Where("id = ?", id).
+		Find(&series).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(series) < 1 {
+		return nil, nil
+	}
+	return &series[0], nil
+}
+
+// FindBookedInSeries returns the booked appointments of a series starting at
+// or after from, in chronological order.
+func (r *appointmentRepository) FindBookedInSeries(seriesID uint, from time.Time) ([]model.Appointment, error) {
+	var appointments []model.Appointment
+	err := r.db.
+		Where("series_id = ? AND status = ? AND start_at >= ?", seriesID, model.AppointmentBooked, from).
+		Order("start_at, id").
+		Find(&appointments).Error
+	return appointments, err
+}
+
+// FindReminderCandidates returns the booked appointments starting within
+// (from, to] that were booked at least lead before they start. Appointments
+// booked at short notice only get their booking confirmation.
+func (r *appointmentRepository) FindReminderCandidates(from, to time.Time, lead time.Duration) ([]model.Appointment, error) {
+	var appointments []model.Appointment
+	err := preloadAppointment(r.db).
+		Where("status = ? AND start_at > ? AND start_at <= ?", model.AppointmentBooked, from, to).
+		Where("created_at <= start_at - make_interval(secs => ?)", lead.Seconds()).
+		Order("start_at, id").
+		Find(&appointments).Error
+	return appointments, err
+}
+
+// lateCancellation matches cancellations the customer made less than the
+// late-cancellation window before the start.
+const lateCancellation = `status = 'cancelled' AND cancelled_by IS DISTINCT FROM 'clinic'
+	AND cancelled_at > start_at - make_interval(secs => @late_window)`
+
+const attendanceColumns = `
+	COUNT(*) FILTER (WHERE status = 'completed') AS completed,
+	COUNT(*) FILTER (WHERE ` + lateCancellation + `) AS late_cancelled,
+	COUNT(*) FILTER (WHERE status = 'no_show') AS no_show`
+
+// CountAttendance returns the attendance of a customer over the appointments
+// starting from since; a zero since counts every appointment.
+func (r *appointmentRepository) CountAttendance(customerID string, since time.Time, lateWindow time.Duration) (*model.AttendanceCounts, error) {
+	var counts model.AttendanceCounts
+	query := r.db.Model(&model.Appointment{}).
+		Select(attendanceColumns, sql.Named("late_window", lateWindow.Seconds())).
+		Where("customer_id = ?", customerID)
+	if !since.IsZero() {
+		query = query.Where("start_at >= ?", since)
+	}
+	err := query.Scan(&counts).Error
+	return &counts, err
+}
+
+// FindAttendanceIssues returns the attendance of every customer with at least
+// one no-show or late cancellation among the appointments starting from since.
+func (r *appointmentRepository) FindAttendanceIssues(since time.Time, lateWindow time.Duration) ([]model.CustomerAttendance, error) {
+	var attendance []model.CustomerAttendance
+	err := r.db.Raw(`
+		SELECT customer_id, `+attendanceColumns+`
+		FROM appointments
+		WHERE start_at >= @since
+		GROUP BY customer_id
+		HAVING COUNT(*) FILTER (WHERE status = 'no_show' OR (`+lateCancellation+`)) > 0`,
+		sql.Named("late_window", lateWindow.Seconds()), sql.Named("since", since)).
+		Scan(&attendance).Error
+	return attendance, err
+}
+
+// FindResourceBookings returns when the given resources are reserved within
+// [from, to) by appointments that still take place.
+func (r *appointmentRepository) FindResourceBookings(resourceIDs []uint, from, to time.Time) ([]model.ResourceBooking, error) {
+	var bookings []model.ResourceBooking
+	if len(resourceIDs) == 0 {
+		return bookings, nil
+	}
+	err := r.db.Table("appointment_resources").
+		Select("appointment_resources.resource_id, appointments.id AS appointment_id, appointments.start_at, appointments.end_at").
+		Joins("JOIN appointments ON appointments.id = appointment_resources.appointment_id").
+		Where("appointment_resources.resource_id IN ?", resourceIDs).
+		Where("appointments.start_at < ? AND appointments.end_at > ?", to, from).
+		Where("appointments.status NOT IN ?", []string{model.AppointmentCancelled, model.AppointmentNoShow}).
+		Order("appointments.start_at").
+		Scan(&bookings).Error
+	return bookings, err
+}
+
+// appointmentAssociations are saved separately from the appointment itself.
+var appointmentAssociations = []string{"Customer", "Therapist", "LayananTerapi", "TeknikTerapi", "Resources"}
+
+// lockResources locks the resources the appointments want, in id order, so
+// concurrent bookings of the same resource take turns.
+func lockResources(tx *gorm.DB, appointments []model.Appointment) error {
+	var ids []uint
+	for _, appointment := range appointments {
+		for _, reserved := range appointment.Resources {
+			ids = append(ids, reserved.ResourceID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	var resources []model.Resource
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&resources).Error
+}
+
+// reserveResources stores the resources of a saved appointment after checking
+// that none of them is at capacity. The resources must be locked.
+func reserveResources(tx *gorm.DB, appointment *model.Appointment) error {
+	for i := range appointment.Resources {
+		reserved := &appointment.Resources[i]
+
+		var resource model.Resource
+		if err := tx.Unscoped().First(&resource, reserved.ResourceID).Error; err != nil {
+			return err
+		}
+		var bookings []model.ResourceBooking
+		err := tx.Table("appointment_resources").
+			Select("appointment_resources.resource_id, appointments.id AS appointment_id, appointments.start_at, appointments.end_at").
+			Joins("JOIN appointments ON appointments.id = appointment_resources.appointment_id").
+			Where("appointment_resources.resource_id = ? AND appointments.id <> ?", reserved.ResourceID, appointment.ID).
+			Where("appointments.start_at < ? AND appointments.end_at > ?", appointment.EndAt, appointment.StartAt).
+			Where("appointments.status NOT IN ?", []string{model.AppointmentCancelled, model.AppointmentNoShow}).
+			Scan(&bookings).Error
+		if err != nil {
+			return err
+		}
+		if model.PeakBookings(bookings, appointment.StartAt, appointment.EndAt) >= resource.Capacity {
+			return ErrResourceConflict
+		}
+
+		reserved.ID = 0
+		reserved.AppointmentID = appointment.ID
+		if err := tx.Omit("Resource").Create(reserved).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapAppointmentError turns a violation of the overlap constraint into ErrAppointmentConflict.
+func mapAppointmentError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == exclusionViolation {
+		return ErrAppointmentConflict
+	}
+	return err
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"errors"
+	"os"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/pkg/database"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB connects to the database in TEST_DATABASE_DSN and migrates it.
+// Tests that need Postgres are skipped when it is not set. They commit for
+// real, because deferred constraints are only checked on commit, so they
+// clean up after themselves.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := database.AutoMigrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// appointmentFixture is a therapist, customer and layanan terapi to book with.
+type appointmentFixture struct {
+	therapistID     uint
+	customerID      string
+	layananTerapiID uint
+}
+
+func newAppointmentFixture(t *testing.T, db *gorm.DB) appointmentFixture {
+	t.Helper()
+	suffix := uuid.New().String()[:8]
+
+	var role model.Role
+	if err := db.Where("name = ?", "user").First(&role).Error; err != nil {
+		t.Fatalf("find role: %v", err)
+	}
+	therapist := &model.User{Username: "therapist" + suffix, Email: suffix + "@example.test", Password: "x", RoleID: role.ID}
+	customer := &model.Customer{Id: uuid.New().String(), CodeRegister: "TEST-" + suffix, CustomerName: "Test " + suffix}
+	layanan := &model.LayananTerapi{Code: "T" + suffix, Name: "Test " + suffix, DurationMinutes: 60}
+	for _, record := range []interface{}{therapist, customer, layanan} {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatalf("create fixture: %v", err)
+		}
+	}
+
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM appointment_events WHERE appointment_id IN (SELECT id FROM appointments WHERE therapist_id = ?)", therapist.ID)
+		db.Exec("DELETE FROM appointments WHERE therapist_id = ?", therapist.ID)
+		db.Unscoped().Delete(layanan)
+		db.Unscoped().Delete(customer)
+		db.Unscoped().Delete(therapist)
+	})
+	return appointmentFixture{therapistID: therapist.ID, customerID: customer.Id, layananTerapiID: layanan.ID}
+}
+
+// bookWeekly books count weekly one-hour appointments starting at start.
+func bookWeekly(t *testing.T, repo AppointmentRepository, f appointmentFixture, start time.Time, count int) []model.Appointment {
+	t.Helper()
+	appointments := make([]model.Appointment, count)
+	for i := range appointments {
+		startAt := start.AddDate(0, 0, 7*i)
+		appointments[i] = model.Appointment{
+			CustomerID:      f.customerID,
+			TherapistID:     f.therapistID,
+			LayananTerapiID: f.layananTerapiID,
+			StartAt:         startAt,
+			EndAt:           startAt.Add(time.Hour),
+			Status:          model.AppointmentBooked,
+			CreatedBy:       f.therapistID,
+		}
+		event := &model.AppointmentEvent{Type: model.AppointmentEventBooked, ToStatus: model.AppointmentBooked, UserID: f.therapistID}
+		if err := repo.Create(&appointments[i], event); err != nil {
+			t.Fatalf("book occurrence %d: %v", i, err)
+		}
+	}
+	return appointments
+}
+
+func shiftEvents(appointments []model.Appointment, userID uint) []model.AppointmentEvent {
+	events := make([]model.AppointmentEvent, len(appointments))
+	for i := range events {
+		events[i] = model.AppointmentEvent{Type: model.AppointmentEventRescheduled, UserID: userID}
+	}
+	return events
+}
+
+func TestUpdateManyMovesWeeklySeriesForwardByOneWeek(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewAppointmentRepository(db)
+	f := newAppointmentFixture(t, db)
+
+	start := time.Date(2031, 1, 6, 9, 0, 0, 0, time.UTC)
+	appointments := bookWeekly(t, repo, f, start, 3)
+
+	// Setiap janji pindah ke slot janji berikutnya yang belum digeser
+	for i := range appointments {
+		appointments[i].StartAt = appointments[i].StartAt.AddDate(0, 0, 7)
+		appointments[i].EndAt = appointments[i].EndAt.AddDate(0, 0, 7)
+	}
+	ok, err := repo.UpdateMany(appointments, model.AppointmentBooked, shiftEvents(appointments, f.therapistID))
+	if err != nil || !ok {
+		t.Fatalf("UpdateMany() = %v, %v; want true, nil", ok, err)
+	}
+
+	moved, err := repo.FindByID(appointments[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := start.AddDate(0, 0, 7); !moved.StartAt.Equal(want) {
+		t.Errorf("first occurrence starts at %s, want %s", moved.StartAt, want)
+	}
+}
+
+func TestUpdateManyStillRejectsOverlapsOnCommit(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewAppointmentRepository(db)
+	f := newAppointmentFixture(t, db)
+
+	start := time.Date(2031, 2, 3, 9, 0, 0, 0, time.UTC)
+	appointments := bookWeekly(t, repo, f, start, 2)
+
+	// Hanya janji pertama yang digeser, sehingga menimpa janji kedua
+	first := appointments[:1]
+	first[0].StartAt = first[0].StartAt.AddDate(0, 0, 7)
+	first[0].EndAt = first[0].EndAt.AddDate(0, 0, 7)
+	_, err := repo.UpdateMany(first, model.AppointmentBooked, shiftEvents(first, f.therapistID))
+	if !errors.Is(err, ErrAppointmentConflict) {
+		t.Fatalf("UpdateMany() error = %v, want %v", err, ErrAppointmentConflict)
+	}
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"sim-clinic-api/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type bookingPolicyRepository struct {
+	db *gorm.DB
+}
+
+func NewBookingPolicyRepository(db *gorm.DB) BookingPolicyRepository {
+	return &bookingPolicyRepository{db: db}
+}
+
+func (r *bookingPolicyRepository) Create(policy *model.BookingPolicy) error {
+	return r.db.Create(policy).Error
+}
+
+func (r *bookingPolicyRepository) FindAll() ([]model.BookingPolicy, error) {
+	var policies []model.BookingPolicy
+	err := r.db.Order("id").Find(&policies).Error
+	return policies, err
+}
+
+func (r *bookingPolicyRepository) FindActive() ([]model.BookingPolicy, error) {
+	var policies []model.BookingPolicy
+	err := r.db.Where("active = ?", true).Order("id").Find(&policies).Error
+	return policies, err
+}
+
+// FindByID returns a booking policy, or nil if there is none.
+func (r *bookingPolicyRepository) FindByID(id uint) (*model.BookingPolicy, error) {
+	var policies []model.BookingPolicy
+	err := r.db.Where("id = ?", id).Find(&policies).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) < 1 {
+		return nil, nil
+	}
+	return &policies[0], nil
+}
+
+func (r *bookingPolicyRepository) Update(policy *model.BookingPolicy) error {
+	return r.db.Save(policy).Error
+}
+
+func (r *bookingPolicyRepository) Delete(id uint) error {
+	return r.db.Delete(&model.BookingPolicy{}, id).Error
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"sim-clinic-api/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type calendarTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewCalendarTokenRepository(db *gorm.DB) CalendarTokenRepository {
+	return &calendarTokenRepository{db: db}
+}
+
+// Save stores the user's token, replacing the one they had before.
+func (r *calendarTokenRepository) Save(token *model.CalendarToken) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"prefix":       token.Prefix,
+			"token_hash":   token.TokenHash,
+			"last_used_at": nil,
+			"created_at":   token.CreatedAt,
+			"updated_at":   token.UpdatedAt,
+		}),
+	}).Create(token).Error
+}
+
+// FindByUserID returns the user's token, or nil if they have none.
+func (r *calendarTokenRepository) FindByUserID(userID uint) (*model.CalendarToken, error) {
+	var tokens []model.CalendarToken
+	err := r.db.Where("user_id = ?", userID).Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) < 1 {
+		return nil, nil
+	}
+	return &tokens[0], nil
+}
+
+// FindByHash returns the token with this hash, or nil if there is none.
+func (r *calendarTokenRepository) FindByHash(tokenHash string) (*model.CalendarToken, error) {
+	var tokens []model.CalendarToken
+	err := r.db.Where("token_hash = ?", tokenHash).Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) < 1 {
+		return nil, nil
+	}
+	return &tokens[0], nil
+}
+
+func (r *calendarTokenRepository) TouchLastUsed(id uint, usedAt time.Time) error {
+	return r.db.Model(&model.CalendarToken{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
+
+func (r *calendarTokenRepository) DeleteByUserID(userID uint) (bool, error) {
+	result := r.db.Where("user_id = ?", userID).Delete(&model.CalendarToken{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"sim-clinic-api/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type clinicalNoteRepository struct {
+	db *gorm.DB
+}
+
+func NewClinicalNoteRepository(db *gorm.DB) ClinicalNoteRepository {
+	return &clinicalNoteRepository{db: db}
+}
+
+func (r *clinicalNoteRepository) Create(note *model.ClinicalNote) error {
+	return r.db.Omit("Author", "Addenda").Create(note).Error
+}
+
+// FindBySession returns the note of a treatment session with its addenda in
+// the order they were written, or nil if the session has no note yet.
+func (r *clinicalNoteRepository) FindBySession(treatmentSessionID uint) (*model.ClinicalNote, error) {
+	var notes []model.ClinicalNote
+	err := r.db.
+		Preload("Author", func(tx *gorm.DB) *gorm.DB { return tx.Unscoped() }).
+		Preload("Addenda", func(tx *gorm.DB) *gorm.DB { return tx.Order("created_at, id") }).
+		Preload("Addenda.Author", func(tx *gorm.DB) *gorm.DB { return tx.Unscoped() }).
+		Where("treatment_session_id = ?", treatmentSessionID).
+		Find(&notes).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(notes) < 1 {
+		return nil, nil
+	}
+	return &notes[0], nil
+}
+
+// Update saves the SOAP fields of a note that is not signed yet. It returns
+// false if the note was signed in the meantime.
+func (r *clinicalNoteRepository) Update(note *model.ClinicalNote) (bool, error) {
+	result := r.db.Model(&model.ClinicalNote{}).
+		Where("id = ? AND signed_at IS NULL", note.ID).
+		Updates(map[string]interface{}{
+			"subjective": note.Subjective,
+			"objective":  note.Objective,
+			"assessment": note.Assessment,
+			"plan":       note.Plan,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Sign marks a note as signed. It returns false if it was already signed.
+func (r *clinicalNoteRepository) Sign(id uint) (bool, error) {
+	result := r.db.Model(&model.ClinicalNote{}).
+		Where("id = ? AND signed_at IS NULL", id).
+		Update("signed_at", gorm.Expr("NOW()"))
+	return result.RowsAffected > 0, result.Error
+}
+
+// Delete removes a note that is not signed yet. It returns false if the note was signed.
+func (r *clinicalNoteRepository) Delete(id uint) (bool, error) {
+	result := r.db.Where("id = ? AND signed_at IS NULL", id).Delete(&model.ClinicalNote{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *clinicalNoteRepository) CreateAddendum(addendum *model.ClinicalNoteAddendum) error {
+	return r.db.Omit("Author").Create(addendum).Error
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+)
+
+type counterRepository struct {
+	db *gorm.DB
+}
+
+func NewCounterRepository(db *gorm.DB) CounterRepository {
+	return &counterRepository{db: db}
+}
+
+// Next increments the counter and returns its new value. The upsert takes a
+// row lock, so parallel callers always receive distinct values.
+func (r *counterRepository) Next(name, period string) (int64, error) {
+	var value int64
+	err := r.db.Raw(`
+		INSERT INTO counters (name, period, value, updated_at)
+		VALUES (?, ?, 1, NOW())
+		ON CONFLICT (name, period)
+		DO UPDATE SET value = counters.value + 1, updated_at = NOW()
+		RETURNING value`, name, period).Scan(&value).Error
+	return value, err
+}
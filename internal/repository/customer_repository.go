@@ -3,14 +3,39 @@ package repository
 import (
 	"errors"
 	"sim-clinic-api/internal/model"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/cast"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var tagCustomerRepository = "internal.repository.customer_repository."
 
+// ErrCustomerMergeConflict is returned when a customer taking part in a merge
+// was deleted or merged elsewhere after the merge was prepared.
+var ErrCustomerMergeConflict = errors.New("customer changed during merge")
+
+// customerReference is a column that points at customers.id.
+type customerReference struct {
+	Table  string
+	Column string
+}
+
+// customerReferences lists every table whose rows belong to a customer.
+// Merging customers moves these rows to the surviving customer, so new
+// tables with a customer_id must be added here.
+var customerReferences = []customerReference{
+	{Table: "customer_medical_histories", Column: "customer_id"},
+	{Table: "treatment_sessions", Column: "customer_id"},
+	{Table: "appointment_series", Column: "customer_id"},
+	{Table: "appointments", Column: "customer_id"},
+	{Table: "queue_entries", Column: "customer_id"},
+	{Table: "outbox_messages", Column: "customer_id"},
+}
+
 type customerRepository struct {
 	db *gorm.DB
 }
@@ -41,7 +66,13 @@ func (r *customerRepository) FindCustomerByPhoneNumber(phoneNumber string) (*[]m
 		customer []model.Customer
 	)
 
-	err := r.db.Where("phone_number = ?", phoneNumber).Find(&customer).Error
+	// Nomor lama dari customer yang sudah digabung ikut dicari
+	err := r.db.
+		Where(
+			"phone_number = ? OR id IN (SELECT customer_id FROM customer_aliases WHERE kind = ? AND value = ?)",
+			phoneNumber, model.CustomerAliasPhone, phoneNumber,
+		).
+		Find(&customer).Error
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"tag":   tag + "01",
@@ -57,34 +88,256 @@ func (r *customerRepository) UpdateCustomer(customer *model.Customer) error {
 	return r.db.Save(customer).Error
 }
 
-func (r *customerRepository) FindCustomers(req model.RequestPagination) (*[]model.Customer, error) {
+func (r *customerRepository) DeleteCustomer(id string) error {
+	return r.db.Where("id = ?", id).Delete(&model.Customer{}).Error
+}
+
+// FindDeletedCustomerByID returns a soft-deleted customer, or nil if there is none with this id.
+func (r *customerRepository) FindDeletedCustomerByID(id string) (*model.Customer, error) {
+	var customer []model.Customer
+	err := r.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Find(&customer).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(customer) < 1 {
+		return nil, nil
+	}
+	return &customer[0], nil
+}
+
+func (r *customerRepository) RestoreCustomer(id string) error {
+	return r.db.Unscoped().Model(&model.Customer{}).Where("id = ?", id).Update("deleted_at", nil).Error
+}
+
+// FindCustomersWithoutCodeRegister returns customers created before registration
+// codes were generated, including soft-deleted ones, oldest first.
+func (r *customerRepository) FindCustomersWithoutCodeRegister() ([]model.Customer, error) {
+	var customers []model.Customer
+	err := r.db.Unscoped().
+		Where("code_register IS NULL OR code_register IN ('', 'Code')").
+		Order("created_at").
+		Find(&customers).Error
+	return customers, err
+}
+
+func (r *customerRepository) SetCodeRegister(id, codeRegister string) error {
+	return r.db.Unscoped().Model(&model.Customer{}).Where("id = ?", id).Update("code_register", codeRegister).Error
+}
+
+func (r *customerRepository) FindCustomersByIDs(ids []string) ([]model.Customer, error) {
+	var customers []model.Customer
+	err := r.db.Where("id IN ?", ids).Find(&customers).Error
+	return customers, err
+}
+
+// FindDuplicatePhoneNumbers returns phone numbers shared by more than one active customer.
+func (r *customerRepository) FindDuplicatePhoneNumbers(limit int) ([]string, error) {
+	var phoneNumbers []string
+	err := r.db.Model(&model.Customer{}).
+		Select("phone_number").
+		Where("phone_number <> ''").
+		Group("phone_number").
+		Having("COUNT(*) > 1").
+		Order("phone_number").
+		Limit(limit).
+		Pluck("phone_number", &phoneNumbers).Error
+	return phoneNumbers, err
+}
+
+func (r *customerRepository) FindCustomersByPhoneNumbers(phoneNumbers []string) ([]model.Customer, error) {
+	var customers []model.Customer
+	err := r.db.Where("phone_number IN ?", phoneNumbers).Order("created_at").Find(&customers).Error
+	return customers, err
+}
+
+// FindSimilarCustomerPairs returns at most limit pairs of active customers in
+// the same city whose names have a trigram similarity of at least
+// minSimilarity and whose phone numbers differ, most similar first.
+func (r *customerRepository) FindSimilarCustomerPairs(minSimilarity float64, limit int) ([]model.CustomerSimilarPair, error) {
+	var pairs []model.CustomerSimilarPair
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Operator % memakai idx_customers_customer_name_trgm, fungsi similarity() tidak
+		err := tx.Exec("SELECT set_config('pg_trgm.similarity_threshold', ?, true)", strconv.FormatFloat(minSimilarity, 'f', -1, 64)).Error
+		if err != nil {
+			return err
+		}
+		return tx.Raw(`
+			SELECT a.id AS first_id, b.id AS second_id, similarity(a.customer_name, b.customer_name) AS similarity
+			FROM customers a
+			JOIN customers b
+				ON b.customer_name % a.customer_name
+				AND a.id < b.id
+				AND LOWER(a.city) = LOWER(b.city)
+				AND a.phone_number <> b.phone_number
+			WHERE a.deleted_at IS NULL AND b.deleted_at IS NULL
+				AND a.city <> ''
+			ORDER BY similarity DESC, a.id, b.id
+			LIMIT ?`, limit).Scan(&pairs).Error
+	})
+	return pairs, err
+}
+
+// FindCustomersInBatches walks every customer, including soft-deleted ones.
+func (r *customerRepository) FindCustomersInBatches(batchSize int, fn func(customers []model.Customer) error) error {
+	var customers []model.Customer
+	return r.db.Unscoped().FindInBatches(&customers, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(customers)
+	}).Error
+}
+
+func (r *customerRepository) UpdatePhoneNumber(id, phoneNumber string) error {
+	return r.db.Unscoped().Model(&model.Customer{}).Where("id = ?", id).Update("phone_number", phoneNumber).Error
+}
+
+// FindCustomers returns one page of customers matching the request and the total number of matches.
+func (r *customerRepository) FindCustomers(req model.CustomerListRequest, page, limit int) ([]model.Customer, int64, error) {
 	var (
-		tag                 = tagCustomerRepository + "FindCustomers."
-		mCustomers          []model.Customer
-		offset, page, limit int
+		tag        = tagCustomerRepository + "FindCustomers."
+		mCustomers = []model.Customer{}
+		total      int64
 	)
 
-	page = cast.ToInt(req.Page)
-	limit = cast.ToInt(req.Limit)
-
-	offset = (page - 1) * limit
+	queryBuilder := r.db.Model(&model.Customer{})
+	if req.Search != "" {
+		searchPattern := "%" + escapeLike(req.Search) + "%"
+		search := r.db.Where(
+			"customer_name ILIKE ? OR phone_number ILIKE ? OR city ILIKE ? OR code_register ILIKE ? "+
+				"OR id IN (SELECT customer_id FROM customer_aliases WHERE value ILIKE ?)",
+			searchPattern, searchPattern, searchPattern, searchPattern, searchPattern,
+		)
+		// Tanpa nomor HP, phone_number = '' akan cocok dengan semua customer tanpa nomor
+		if req.SearchPhone != "" {
+			search = search.Or(
+				"phone_number = ? OR id IN (SELECT customer_id FROM customer_aliases WHERE kind = ? AND value = ?)",
+				req.SearchPhone, model.CustomerAliasPhone, req.SearchPhone,
+			)
+		}
+		queryBuilder = queryBuilder.Where(search)
+	}
+	if req.Gender != "" {
+		queryBuilder = queryBuilder.Where("gender = ?", req.Gender)
+	}
+	if req.City != "" {
+		queryBuilder = queryBuilder.Where("city ILIKE ?", escapeLike(req.City))
+	}
+	if req.CreatedAfter != nil {
+		queryBuilder = queryBuilder.Where("created_at >= ?", *req.CreatedAfter)
+	}
+	if req.CreatedBefore != nil {
+		queryBuilder = queryBuilder.Where("created_at < ?", *req.CreatedBefore)
+	}
 
-	queryBuilder := r.db.Model(&model.Customer{}).Limit(limit).Offset(offset)
-	if err := queryBuilder.Find(&mCustomers).Error; err != nil {
+	if err := queryBuilder.Count(&total).Error; err != nil {
 		logrus.WithFields(logrus.Fields{
 			"tag":   tag + "01",
 			"error": err.Error(),
-		})
-		return nil, err
+		}).Error("count customers failed")
+		return nil, 0, err
+	}
+
+	sortColumn, ok := model.CustomerSortFields[req.SortBy]
+	if !ok {
+		sortColumn = "created_at"
+	}
+	sortOrder := "DESC"
+	if req.SortOrder == "asc" {
+		sortOrder = "ASC"
 	}
 
-	if len(mCustomers) == 0 {
+	err := queryBuilder.
+		Order(sortColumn + " " + sortOrder).
+		Order("id").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&mCustomers).Error
+	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"tag":   tag + "02",
-			"error": "data customer not found",
-		})
-		return nil, errors.New("RESOURCE_NOT_FOUND")
+			"error": err.Error(),
+		}).Error("find customers failed")
+		return nil, 0, err
+	}
+
+	return mCustomers, total, nil
+}
+
+// CountCustomerReferences counts, per table, the rows that belong to the given customers.
+func (r *customerRepository) CountCustomerReferences(ids []string) (map[string]int64, error) {
+	counts := make(map[string]int64, len(customerReferences))
+	for _, ref := range customerReferences {
+		var count int64
+		err := r.db.Table(ref.Table).Where(ref.Column+" IN ?", ids).Count(&count).Error
+		if err != nil {
+			return nil, err
+		}
+		counts[ref.Table] = count
+	}
+	return counts, nil
+}
+
+// MergeCustomers moves every record of the merged customers to the survivor,
+// stores the aliases, retires the merged customers and writes the audit
+// entry, all in one transaction. It returns the number of moved rows per table.
+func (r *customerRepository) MergeCustomers(survivorID string, mergedIDs []string, aliases []model.CustomerAlias, entry *model.CustomerMergeLog) (map[string]int64, error) {
+	moved := make(map[string]int64, len(customerReferences))
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Kunci semua customer yang terlibat agar tidak digabung dua kali bersamaan
+		var locked []model.Customer
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", append([]string{survivorID}, mergedIDs...)).
+			Find(&locked).Error
+		if err != nil {
+			return err
+		}
+		if len(locked) != len(mergedIDs)+1 {
+			return ErrCustomerMergeConflict
+		}
+
+		for _, ref := range customerReferences {
+			result := tx.Table(ref.Table).
+				Where(ref.Column+" IN ?", mergedIDs).
+				Update(ref.Column, survivorID)
+			if result.Error != nil {
+				return result.Error
+			}
+			moved[ref.Table] = result.RowsAffected
+		}
+
+		// Alias lama dari customer yang digabung ikut pindah ke survivor
+		err = tx.Model(&model.CustomerAlias{}).
+			Where("customer_id IN ?", mergedIDs).
+			Update("customer_id", survivorID).Error
+		if err != nil {
+			return err
+		}
+		if len(aliases) > 0 {
+			if err := tx.Create(&aliases).Error; err != nil {
+				return err
+			}
+		}
+
+		err = tx.Model(&model.Customer{}).
+			Where("id IN ?", mergedIDs).
+			Updates(map[string]interface{}{
+				"merged_into_id": survivorID,
+				"deleted_at":     time.Now(),
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		entry.MovedRecords = moved
+		return tx.Create(entry).Error
+	})
+	if err != nil {
+		return nil, err
 	}
+	return moved, nil
+}
 
-	return &mCustomers, nil
+// escapeLike escapes the LIKE wildcards in s so it only matches literally.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
 }
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
@@ -1,28 +1,92 @@
 package repository
 
-import "sim-clinic-api/internal/model"
+import (
+	"sim-clinic-api/internal/model"
+	"time"
+)
 
 type UserRepository interface {
 	Create(user *model.User) error
 	FindByUsername(username string) (*model.User, error)
 	FindByEmail(email string) (*model.User, error)
 	FindByID(id uint) (*model.User, error)
-	FindAll(page, limit, search string) ([]model.User, int64, error)
+	FindAll(page, limit, search string, maxRoleLevel *int) ([]model.User, int64, error)
 	FindByRole(roleName string) ([]model.User, error)
 	FindByRoles(roleNames []string) ([]model.User, error)
 	Update(user *model.User) error
 	Delete(id uint) error
+	IncrementFailedLogin(id uint, windowStart time.Time) (int, error)
+	LockUser(id uint, until time.Time) error
+	ResetFailedLogins(id uint) error
+}
+
+type SessionRepository interface {
+	Create(session *model.UserSession) error
+	FindByID(id string) (*model.UserSession, error)
+	FindActiveByUser(userID uint) ([]model.UserSession, error)
+	Touch(id string, seenAt time.Time, ipAddress string) error
+	Extend(id string, expiresAt time.Time) error
+	Revoke(id, reason string) error
+	RevokeAllByUser(userID uint, reason string) ([]string, error)
+	DeleteExpired() error
+}
+
+type MFARepository interface {
+	SetSecret(userID uint, secret string) error
+	Enable(userID uint) error
+	Disable(userID uint) error
+	MarkStepUsed(userID uint, step int64) error
+	ReplaceRecoveryCodes(userID uint, codeHashes []string) error
+	UseRecoveryCode(userID uint, codeHash string) error
+}
+
+type LoginAttemptRepository interface {
+	Create(attempt *model.LoginAttempt) error
+	CountFailuresByIP(ip string, since time.Time) (int64, *time.Time, error)
+}
+
+type APIKeyRepository interface {
+	Create(key *model.APIKey) error
+	FindAll() ([]model.APIKey, error)
+	FindByID(id uint) (*model.APIKey, error)
+	FindByHash(keyHash string) (*model.APIKey, error)
+	Revoke(id uint) error
+	TouchLastUsed(id uint, usedAt time.Time, ipAddress string) error
 }
 
 type RoleRepository interface {
 	FindByID(id uint) (*model.Role, error)
+	FindByName(name string) (*model.Role, error)
+	FindAll() ([]model.Role, error)
+	Create(role *model.Role) error
+	Update(role *model.Role) error
+	Delete(id uint) error
+	CountUsers(id uint) (int64, error)
+
+	// Permission
+	FindAllPermissions() ([]model.Permission, error)
+	FindPermissionsByNames(names []string) ([]model.Permission, error)
 }
 
 type TokenRepository interface {
 	BlacklistToken(token *model.BlacklistedToken) error
 	IsTokenBlacklisted(token string) (bool, error)
+	IsTokenRevoked(jti string) (bool, error)
 	CleanExpiredTokens() error
-	GetUserActiveTokens(userID uint) ([]model.BlacklistedToken, error)
+	GetUserBlacklistedTokens(userID uint) ([]model.BlacklistedToken, error)
+
+	// Refresh Token
+	CreateRefreshToken(token *model.RefreshToken) error
+	FindRefreshTokenByHash(tokenHash string) (*model.RefreshToken, error)
+	RotateRefreshToken(oldID uint, newToken *model.RefreshToken) error
+	RevokeRefreshTokenFamily(familyID, reason string) error
+	RevokeUserRefreshTokens(userID uint, reason string) error
+
+	// Password Reset Token
+	CreatePasswordResetToken(token *model.PasswordResetToken) error
+	FindPasswordResetTokenByHash(tokenHash string) (*model.PasswordResetToken, error)
+	MarkPasswordResetTokenUsed(id uint) error
+	InvalidateUserPasswordResetTokens(userID uint) error
 }
 
 type MasterDataRepository interface {
@@ -41,6 +105,8 @@ type MasterDataRepository interface {
 	FindRiwayatPenyakitByCode(code string) (*model.RiwayatPenyakit, error)
 	UpdateRiwayatPenyakit(riwayat *model.RiwayatPenyakit) error
 	DeleteRiwayatPenyakit(id uint) error
+	CountRiwayatPenyakitUsage(id uint) (int64, error)
+	ReplaceRiwayatPenyakit(id, replacementID uint) (int64, error)
 
 	// Teknik Terapi
 	CreateTeknikTerapi(teknik *model.TeknikTerapi) error
@@ -49,6 +115,11 @@ type MasterDataRepository interface {
 	FindTeknikTerapiByCode(code string) (*model.TeknikTerapi, error)
 	UpdateTeknikTerapi(teknik *model.TeknikTerapi) error
 	DeleteTeknikTerapi(id uint) error
+	FindTeknikTerapiByIDs(ids []uint) ([]model.TeknikTerapi, error)
+}
+
+type CounterRepository interface {
+	Next(name, period string) (int64, error)
 }
 
 type CustomerRepository interface {
@@ -56,5 +127,134 @@ type CustomerRepository interface {
 	FindCustomerByID(id string) (*model.Customer, error)
 	FindCustomerByPhoneNumber(phoneNumber string) (*[]model.Customer, error)
 	UpdateCustomer(customer *model.Customer) error
-	FindCustomers(reqPagination model.RequestPagination) (*[]model.Customer, error)
+	DeleteCustomer(id string) error
+	FindDeletedCustomerByID(id string) (*model.Customer, error)
+	RestoreCustomer(id string) error
+	FindCustomersWithoutCodeRegister() ([]model.Customer, error)
+	SetCodeRegister(id, codeRegister string) error
+	FindCustomersByIDs(ids []string) ([]model.Customer, error)
+	FindDuplicatePhoneNumbers(limit int) ([]string, error)
+	FindCustomersByPhoneNumbers(phoneNumbers []string) ([]model.Customer, error)
+	FindSimilarCustomerPairs(minSimilarity float64, limit int) ([]model.CustomerSimilarPair, error)
+	FindCustomersInBatches(batchSize int, fn func(customers []model.Customer) error) error
+	UpdatePhoneNumber(id, phoneNumber string) error
+	FindCustomers(req model.CustomerListRequest, page, limit int) ([]model.Customer, int64, error)
+	CountCustomerReferences(ids []string) (map[string]int64, error)
+	MergeCustomers(survivorID string, mergedIDs []string, aliases []model.CustomerAlias, entry *model.CustomerMergeLog) (map[string]int64, error)
+}
+
+type MedicalHistoryRepository interface {
+	Create(history *model.CustomerMedicalHistory) error
+	FindByCustomer(customerID string) ([]model.CustomerMedicalHistory, error)
+	FindByID(customerID string, id uint) (*model.CustomerMedicalHistory, error)
+	Update(history *model.CustomerMedicalHistory) error
+	Delete(id uint) error
+}
+
+type TreatmentSessionRepository interface {
+	Create(session *model.TreatmentSession) error
+	FindByCustomer(customerID string, from, to time.Time) ([]model.TreatmentSession, error)
+	FindByID(customerID string, id uint) (*model.TreatmentSession, error)
+	Update(session *model.TreatmentSession) error
+	Delete(id uint) error
+}
+
+type ClinicalNoteRepository interface {
+	Create(note *model.ClinicalNote) error
+	FindBySession(treatmentSessionID uint) (*model.ClinicalNote, error)
+	Update(note *model.ClinicalNote) (bool, error)
+	Sign(id uint) (bool, error)
+	Delete(id uint) (bool, error)
+	CreateAddendum(addendum *model.ClinicalNoteAddendum) error
+}
+
+type ScheduleRepository interface {
+	CreateWorkingHour(workingHour *model.WorkingHour) error
+	FindWorkingHours(therapistID uint) ([]model.WorkingHour, error)
+	FindWorkingHoursByWeekday(therapistID uint, weekday time.Weekday) ([]model.WorkingHour, error)
+	FindWorkingHourByID(id uint) (*model.WorkingHour, error)
+	UpdateWorkingHour(workingHour *model.WorkingHour) error
+	DeleteWorkingHour(id uint) error
+	CreateException(exception *model.ScheduleException) error
+	FindExceptions(therapistID uint, from, to time.Time) ([]model.ScheduleException, error)
+	FindExceptionByID(id uint) (*model.ScheduleException, error)
+	DeleteException(id uint) error
+}
+
+type AppointmentRepository interface {
+	Create(appointment *model.Appointment, event *model.AppointmentEvent) error
+	FindByID(id uint) (*model.Appointment, error)
+	Find(from, to time.Time, therapistID uint, customerID, status string) ([]model.Appointment, error)
+	FindBusy(therapistID uint, from, to time.Time) ([]model.Appointment, error)
+	FindEvents(appointmentID uint) ([]model.AppointmentEvent, error)
+	Update(appointment *model.Appointment, expectedStatus string, event *model.AppointmentEvent) (bool, error)
+	UpdateMany(appointments []model.Appointment, expectedStatus string, events []model.AppointmentEvent) (bool, error)
+	CreateSeries(series *model.AppointmentSeries, appointments []model.Appointment, events []model.AppointmentEvent) error
+	FindSeriesByID(id uint) (*model.AppointmentSeries, error)
+	FindBookedInSeries(seriesID uint, from time.Time) ([]model.Appointment, error)
+	FindReminderCandidates(from, to time.Time, lead time.Duration) ([]model.Appointment, error)
+	CountAttendance(customerID string, since time.Time, lateWindow time.Duration) (*model.AttendanceCounts, error)
+	FindAttendanceIssues(since time.Time, lateWindow time.Duration) ([]model.CustomerAttendance, error)
+	FindForCalendar(from, to, cancelledSince time.Time, therapistID, layananTerapiID, resourceID uint) ([]model.Appointment, error)
+	FindResourceBookings(resourceIDs []uint, from, to time.Time) ([]model.ResourceBooking, error)
+}
+
+type QueueRepository interface {
+	Create(entry *model.QueueEntry, event *model.QueueEvent) error
+	FindByID(id uint) (*model.QueueEntry, error)
+	Find(date string, layananTerapiID uint, status string) ([]model.QueueEntry, error)
+	FindActiveByCustomer(date, customerID string, layananTerapiID uint) (*model.QueueEntry, error)
+	FindNextWaiting(date string, layananTerapiID uint) (*model.QueueEntry, error)
+	Update(entry *model.QueueEntry, expectedStatus string, event *model.QueueEvent) (bool, error)
+	FindEventsAfter(date string, afterID uint, limit int) ([]model.QueueEvent, error)
+	LastEventID() (uint, error)
+}
+
+type OutboxRepository interface {
+	Enqueue(messages []model.OutboxMessage) error
+	ClaimDue(limit int, lease time.Duration) ([]model.OutboxMessage, error)
+	MarkSent(id uint) error
+	MarkRetry(id uint, lastError string, nextAttemptAt time.Time) error
+	MarkFinished(id uint, status, lastError string) error
+	Requeue(id uint) (bool, error)
+	FindByID(id uint) (*model.OutboxMessage, error)
+	Find(status, customerID string, limit int) ([]model.OutboxMessage, error)
+}
+
+type BookingPolicyRepository interface {
+	Create(policy *model.BookingPolicy) error
+	FindAll() ([]model.BookingPolicy, error)
+	FindActive() ([]model.BookingPolicy, error)
+	FindByID(id uint) (*model.BookingPolicy, error)
+	Update(policy *model.BookingPolicy) error
+	Delete(id uint) error
+}
+
+type CalendarTokenRepository interface {
+	Save(token *model.CalendarToken) error
+	FindByUserID(userID uint) (*model.CalendarToken, error)
+	FindByHash(tokenHash string) (*model.CalendarToken, error)
+	TouchLastUsed(id uint, usedAt time.Time) error
+	DeleteByUserID(userID uint) (bool, error)
+}
+
+type ResourceRepository interface {
+	CreateType(resourceType *model.ResourceType) error
+	FindTypes() ([]model.ResourceType, error)
+	FindTypeByID(id uint) (*model.ResourceType, error)
+	FindTypeByCode(code string) (*model.ResourceType, error)
+	UpdateType(resourceType *model.ResourceType) error
+	DeleteType(id uint) error
+	CountTypeUsage(id uint) (int64, error)
+	Create(resource *model.Resource) error
+	Find(resourceTypeID uint) ([]model.Resource, error)
+	FindActiveByTypes(resourceTypeIDs []uint) ([]model.Resource, error)
+	FindByID(id uint) (*model.Resource, error)
+	Update(resource *model.Resource) error
+	Delete(id uint) error
+	FindUpcomingBookings(id uint, from time.Time) ([]model.ResourceBooking, error)
+	CreateRequirement(requirement *model.ResourceRequirement) error
+	FindRequirements(layananTerapiID, teknikTerapiID uint) ([]model.ResourceRequirement, error)
+	FindRequirementByID(id uint) (*model.ResourceRequirement, error)
+	DeleteRequirement(id uint) error
 }
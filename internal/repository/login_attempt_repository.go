@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"sim-clinic-api/internal/model"
+	"time"
+)
+
+type loginAttemptRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginAttemptRepository(db *gorm.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+func (r *loginAttemptRepository) Create(attempt *model.LoginAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+// CountFailuresByIP returns the number of attempts from ip since the given
+// time that failed on wrong credentials, and when the latest of them happened.
+func (r *loginAttemptRepository) CountFailuresByIP(ip string, since time.Time) (int64, *time.Time, error) {
+	var result struct {
+		Total  int64
+		LastAt *time.Time
+	}
+
+	err := r.db.Model(&model.LoginAttempt{}).
+		Select("COUNT(*) AS total, MAX(created_at) AS last_at").
+		Where("ip_address = ? AND success = ? AND created_at > ?", ip, false, since).
+		Where("reason IN ?", model.LoginCredentialFailures).
+		Scan(&result).Error
+	if err != nil {
+		return 0, nil, err
+	}
+	return result.Total, result.LastAt, nil
+}
@@ -81,6 +81,31 @@ func (r *masterDataRepository) DeleteRiwayatPenyakit(id uint) error {
 	return r.db.Delete(&model.RiwayatPenyakit{}, id).Error
 }
 
+// CountRiwayatPenyakitUsage counts the medical history entries that refer to a riwayat penyakit.
+func (r *masterDataRepository) CountRiwayatPenyakitUsage(id uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.CustomerMedicalHistory{}).Where("riwayat_penyakit_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+// ReplaceRiwayatPenyakit points every medical history entry, including deleted
+// ones, at the replacement and deletes the riwayat penyakit in one transaction.
+// It returns the number of entries that were moved.
+func (r *masterDataRepository) ReplaceRiwayatPenyakit(id, replacementID uint) (int64, error) {
+	var moved int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&model.CustomerMedicalHistory{}).
+			Where("riwayat_penyakit_id = ?", id).
+			Update("riwayat_penyakit_id", replacementID)
+		if result.Error != nil {
+			return result.Error
+		}
+		moved = result.RowsAffected
+		return tx.Delete(&model.RiwayatPenyakit{}, id).Error
+	})
+	return moved, err
+}
+
 // Teknik Terapi implementations (similar structure)
 func (r *masterDataRepository) CreateTeknikTerapi(teknik *model.TeknikTerapi) error {
 	return r.db.Create(teknik).Error
@@ -120,3 +145,10 @@ func (r *masterDataRepository) UpdateTeknikTerapi(teknik *model.TeknikTerapi) er
 func (r *masterDataRepository) DeleteTeknikTerapi(id uint) error {
 	return r.db.Delete(&model.TeknikTerapi{}, id).Error
 }
+
+// FindTeknikTerapiByIDs returns the teknik terapi with the given ids that are not deleted.
+func (r *masterDataRepository) FindTeknikTerapiByIDs(ids []uint) ([]model.TeknikTerapi, error) {
+	var teks []model.TeknikTerapi
+	err := r.db.Where("id IN ?", ids).Find(&teks).Error
+	return teks, err
+}
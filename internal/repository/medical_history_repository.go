@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"sim-clinic-api/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type medicalHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewMedicalHistoryRepository(db *gorm.DB) MedicalHistoryRepository {
+	return &medicalHistoryRepository{db: db}
+}
+
+// preloadRiwayatPenyakit memuat master data termasuk yang sudah dihapus
+func preloadRiwayatPenyakit(db *gorm.DB) *gorm.DB {
+	return db.Preload("RiwayatPenyakit", func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped()
+	})
+}
+
+func (r *medicalHistoryRepository) Create(history *model.CustomerMedicalHistory) error {
+	return r.db.Create(history).Error
+}
+
+// FindByCustomer returns the medical history of a customer, active entries first.
+func (r *medicalHistoryRepository) FindByCustomer(customerID string) ([]model.CustomerMedicalHistory, error) {
+	var histories []model.CustomerMedicalHistory
+	err := preloadRiwayatPenyakit(r.db).
+		Where("customer_id = ?", customerID).
+		Order("status = 'active' DESC, onset_date DESC NULLS LAST, id DESC").
+		Find(&histories).Error
+	return histories, err
+}
+
+// FindByID returns an entry of the given customer, or nil if there is none.
+func (r *medicalHistoryRepository) FindByID(customerID string, id uint) (*model.CustomerMedicalHistory, error) {
+	var histories []model.CustomerMedicalHistory
+	err := preloadRiwayatPenyakit(r.db).
+		Where("customer_id = ? AND id = ?", customerID, id).
+		Find(&histories).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(histories) < 1 {
+		return nil, nil
+	}
+	return &histories[0], nil
+}
+
+func (r *medicalHistoryRepository) Update(history *model.CustomerMedicalHistory) error {
+	return r.db.Omit("RiwayatPenyakit").Save(history).Error
+}
+
+func (r *medicalHistoryRepository) Delete(id uint) error {
+	return r.db.Delete(&model.CustomerMedicalHistory{}, id).Error
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"sim-clinic-api/internal/model"
+	"time"
+)
+
+// ErrMFACodeUsed is returned when a TOTP time step or recovery code was
+// already consumed.
+var ErrMFACodeUsed = errors.New("mfa code already used")
+
+type mfaRepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) MFARepository {
+	return &mfaRepository{db: db}
+}
+
+// SetSecret stores a pending secret. MFA stays disabled until the secret is confirmed.
+func (r *mfaRepository) SetSecret(userID uint, secret string) error {
+	return r.db.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"mfa_secret":         secret,
+		"mfa_enabled":        false,
+		"mfa_last_used_step": 0,
+	}).Error
+}
+
+func (r *mfaRepository) Enable(userID uint) error {
+	return r.db.Model(&model.User{}).Where("id = ?", userID).Update("mfa_enabled", true).Error
+}
+
+func (r *mfaRepository) Disable(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"mfa_secret":         "",
+			"mfa_enabled":        false,
+			"mfa_last_used_step": 0,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&model.MFARecoveryCode{}).Error
+	})
+}
+
+// MarkStepUsed records the TOTP time step of an accepted code, refusing steps
+// that are not newer than the last accepted one.
+func (r *mfaRepository) MarkStepUsed(userID uint, step int64) error {
+	result := r.db.Model(&model.User{}).
+		Where("id = ? AND mfa_last_used_step < ?", userID, step).
+		Update("mfa_last_used_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrMFACodeUsed
+	}
+	return nil
+}
+
+func (r *mfaRepository) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]model.MFARecoveryCode, len(codeHashes))
+		for i, hash := range codeHashes {
+			codes[i] = model.MFARecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *mfaRepository) UseRecoveryCode(userID uint, codeHash string) error {
+	result := r.db.Model(&model.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrMFACodeUsed
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"sim-clinic-api/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// Enqueue stores messages, skipping those whose DedupKey is already queued.
+func (r *outboxRepository) Enqueue(messages []model.OutboxMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	return r.db.
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "dedup_key"}}, DoNothing: true}).
+		Create(&messages).Error
+}
+
+// ClaimDue picks up to limit pending messages that are due and counts an
+// attempt for each. They are hidden from other workers until lease passes, so
+// a worker that dies while sending leaves them to be retried.
+func (r *outboxRepository) ClaimDue(limit int, lease time.Duration) ([]model.OutboxMessage, error) {
+	var messages []model.OutboxMessage
+	now := time.Now()
+	err := r.db.Raw(`
+		UPDATE outbox_messages
+		SET attempts = attempts + 1, next_attempt_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM outbox_messages
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at, id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, now.Add(lease), now, model.OutboxPending, now, limit).
+		Scan(&messages).Error
+	return messages, err
+}
+
+func (r *outboxRepository) MarkSent(id uint) error {
+	now := time.Now()
+	return r.db.Model(&model.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     model.OutboxSent,
+		"sent_at":    now,
+		"last_error": "",
+		"updated_at": now,
+	}).Error
+}
+
+// MarkRetry records a failed attempt and schedules the next one.
+func (r *outboxRepository) MarkRetry(id uint, lastError string, nextAttemptAt time.Time) error {
+	return r.db.Model(&model.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_error":      lastError,
+		"next_attempt_at": nextAttemptAt,
+		"updated_at":      time.Now(),
+	}).Error
+}
+
+// MarkFinished gives up on a message with status failed or skipped.
+func (r *outboxRepository) MarkFinished(id uint, status, lastError string) error {
+	return r.db.Model(&model.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     status,
+		"last_error": lastError,
+		"updated_at": time.Now(),
+	}).Error
+}
+
+// Requeue makes a failed or skipped message pending again, with a fresh set
+// of attempts. It returns false if the message is not in one of those states
+// or has expired; the expiry itself is kept.
+func (r *outboxRepository) Requeue(id uint) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&model.OutboxMessage{}).
+		Where("id = ? AND status IN ?", id, []string{model.OutboxFailed, model.OutboxSkipped}).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Updates(map[string]interface{}{
+			"status":          model.OutboxPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"updated_at":      now,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindByID returns a message, or nil if there is none.
+func (r *outboxRepository) FindByID(id uint) (*model.OutboxMessage, error) {
+	var messages []model.OutboxMessage
+	if err := r.db.Where("id = ?", id).Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	if len(messages) < 1 {
+		return nil, nil
+	}
+	return &messages[0], nil
+}
+
+// Find returns the newest messages matching the filters.
+func (r *outboxRepository) Find(status, customerID string, limit int) ([]model.OutboxMessage, error) {
+	var messages []model.OutboxMessage
+	query := r.db
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if customerID != "" {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&messages).Error
+	return messages, err
+}
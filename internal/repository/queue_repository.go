@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"errors"
+	"sim-clinic-api/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// ErrQueueEntryExists is returned by Create when the customer already waits
+// or is being called for the same layanan terapi that day.
+var ErrQueueEntryExists = errors.New("customer is already in the queue")
+
+// uniqueViolation is the SQLSTATE of a violated unique index.
+const uniqueViolation = "23505"
+
+// queueActiveCustomerIndex allows one waiting or called entry per customer,
+// day and layanan terapi. It is created by database.AutoMigrate.
+const queueActiveCustomerIndex = "idx_queue_entries_active_customer"
+
+// queueEventLock is the advisory lock key ("QUEU") held while a queue event
+// is written.
+const queueEventLock = 0x51554555
+
+// errQueueEntryChanged rolls back Update when the entry changed status.
+var errQueueEntryChanged = errors.New("queue entry changed")
+
+type queueRepository struct {
+	db *gorm.DB
+}
+
+func NewQueueRepository(db *gorm.DB) QueueRepository {
+	return &queueRepository{db: db}
+}
+
+func preloadQueueEntry(db *gorm.DB) *gorm.DB {
+	unscoped := func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped()
+	}
+	return db.
+		Preload("Customer", unscoped).
+		Preload("LayananTerapi", unscoped).
+		Preload("Caller", unscoped)
+}
+
+// Create stores a new queue entry together with its check-in event. It
+// returns ErrQueueEntryExists if the customer is already in that queue.
+func (r *queueRepository) Create(entry *model.QueueEntry, event *model.QueueEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Customer", "LayananTerapi", "Caller").Create(entry).Error; err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == queueActiveCustomerIndex {
+				return ErrQueueEntryExists
+			}
+			return err
+		}
+		event.QueueEntryID = entry.ID
+		return createQueueEvent(tx, event)
+	})
+}
+
+// FindByID returns a queue entry, or nil if there is none.
+func (r *queueRepository) FindByID(id uint) (*model.QueueEntry, error) {
+	var entries []model.QueueEntry
+	err := preloadQueueEntry(r.db).Where("id = ?", id).Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) < 1 {
+		return nil, nil
+	}
+	return &entries[0], nil
+}
+
+// Find returns the entries of a day matching the filters, by queue number.
+func (r *queueRepository) Find(date string, layananTerapiID uint, status string) ([]model.QueueEntry, error) {
+	var entries []model.QueueEntry
+	query := preloadQueueEntry(r.db).Where("queue_date = ?", date)
+	if layananTerapiID != 0 {
+		query = query.Where("layanan_terapi_id = ?", layananTerapiID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("layanan_terapi_id, number").Find(&entries).Error
+	return entries, err
+}
+
+// FindActiveByCustomer returns the waiting or called entry of a customer for
+// a layanan terapi on a day, or nil if there is none.
+func (r *queueRepository) FindActiveByCustomer(date, customerID string, layananTerapiID uint) (*model.QueueEntry, error) {
+	var entries []model.QueueEntry
+	err := r.db.
+		Where("queue_date = ? AND customer_id = ? AND layanan_terapi_id = ?", date, customerID, layananTerapiID).
+		Where("status IN ?", []string{model.QueueWaiting, model.QueueCalled}).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) < 1 {
+		return nil, nil
+	}
+	return &entries[0], nil
+}
+
+// FindNextWaiting returns the waiting entry with the lowest number, or nil if
+// nobody is waiting.
+func (r *queueRepository) FindNextWaiting(date string, layananTerapiID uint) (*model.QueueEntry, error) {
+	var entries []model.QueueEntry
+	err := r.db.
+		Where("queue_date = ? AND layanan_terapi_id = ? AND status = ?", date, layananTerapiID, model.QueueWaiting).
+		Order("number").
+		Limit(1).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) < 1 {
+		return nil, nil
+	}
+	return &entries[0], nil
+}
+
+// Update saves the status and call details of an entry and records the
+// event, provided the entry still has expectedStatus. It returns false if the
+// status changed in the meantime.
+func (r *queueRepository) Update(entry *model.QueueEntry, expectedStatus string, event *model.QueueEvent) (bool, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.QueueEntry{}).
+			Where("id = ? AND status = ?", entry.ID, expectedStatus).
+			Updates(map[string]interface{}{
+				"status":      entry.Status,
+				"room":        entry.Room,
+				"call_count":  entry.CallCount,
+				"called_by":   entry.CalledBy,
+				"called_at":   entry.CalledAt,
+				"finished_at": entry.FinishedAt,
+				"updated_at":  time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errQueueEntryChanged
+		}
+
+		event.QueueEntryID = entry.ID
+		return createQueueEvent(tx, event)
+	})
+	if errors.Is(err, errQueueEntryChanged) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// createQueueEvent records an event so that event ids follow commit order.
+// Displays resume from the highest id they saw, so an event must never become
+// visible after one with a higher id. The lock is held until the transaction
+// ends, which makes writers take their ids one after another.
+func createQueueEvent(tx *gorm.DB, event *model.QueueEvent) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", queueEventLock).Error; err != nil {
+		return err
+	}
+	return tx.Create(event).Error
+}
+
+// FindEventsAfter returns the events of a day with an id above afterID, oldest
+// first, at most limit of them.
+func (r *queueRepository) FindEventsAfter(date string, afterID uint, limit int) ([]model.QueueEvent, error) {
+	var events []model.QueueEvent
+	err := r.db.
+		Where("queue_date = ? AND id > ?", date, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// LastEventID returns the id of the newest queue event, or 0 if there is none.
+func (r *queueRepository) LastEventID() (uint, error) {
+	var id uint
+	err := r.db.Model(&model.QueueEvent{}).Select("COALESCE(MAX(id), 0)").Scan(&id).Error
+	return id, err
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sim-clinic-api/internal/model"
+	"testing"
+)
+
+func TestQueueCreateRejectsSecondActiveEntry(t *testing.T) {
+	db := openTestDB(t)
+	f := newAppointmentFixture(t, db)
+	repo := NewQueueRepository(db)
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM queue_events WHERE layanan_terapi_id = ?", f.layananTerapiID)
+		db.Exec("DELETE FROM queue_entries WHERE layanan_terapi_id = ?", f.layananTerapiID)
+	})
+
+	checkIn := func(number int64) error {
+		entry := &model.QueueEntry{
+			QueueDate:       "2026-01-05",
+			LayananTerapiID: f.layananTerapiID,
+			Number:          number,
+			Ticket:          fmt.Sprintf("T-%03d", number),
+			CustomerID:      f.customerID,
+			Status:          model.QueueWaiting,
+			CreatedBy:       f.therapistID,
+		}
+		event := &model.QueueEvent{
+			QueueDate:       entry.QueueDate,
+			LayananTerapiID: f.layananTerapiID,
+			Type:            model.QueueActionCheckIn,
+			Ticket:          entry.Ticket,
+			Status:          model.QueueWaiting,
+			UserID:          f.therapistID,
+		}
+		return repo.Create(entry, event)
+	}
+
+	if err := checkIn(1); err != nil {
+		t.Fatalf("first check-in: %v", err)
+	}
+	if err := checkIn(2); !errors.Is(err, ErrQueueEntryExists) {
+		t.Fatalf("second check-in: got %v, want ErrQueueEntryExists", err)
+	}
+}
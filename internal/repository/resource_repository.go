@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"sim-clinic-api/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type resourceRepository struct {
+	db *gorm.DB
+}
+
+func NewResourceRepository(db *gorm.DB) ResourceRepository {
+	return &resourceRepository{db: db}
+}
+
+// ============ RESOURCE TYPE ============
+func (r *resourceRepository) CreateType(resourceType *model.ResourceType) error {
+	return r.db.Create(resourceType).Error
+}
+
+func (r *resourceRepository) FindTypes() ([]model.ResourceType, error) {
+	var types []model.ResourceType
+	err := r.db.Order("kind, name").Find(&types).Error
+	return types, err
+}
+
+// FindTypeByID returns a resource type, or nil if there is none.
+func (r *resourceRepository) FindTypeByID(id uint) (*model.ResourceType, error) {
+	var types []model.ResourceType
+	err := r.db.Where("id = ?", id).Find(&types).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(types) < 1 {
+		return nil, nil
+	}
+	return &types[0], nil
+}
+
+// FindTypeByCode returns a resource type, or nil if there is none.
+func (r *resourceRepository) FindTypeByCode(code string) (*model.ResourceType, error) {
+	var types []model.ResourceType
+	err := r.db.Where("code = ?", code).Find(&types).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(types) < 1 {
+		return nil, nil
+	}
+	return &types[0], nil
+}
+
+func (r *resourceRepository) UpdateType(resourceType *model.ResourceType) error {
+	return r.db.Save(resourceType).Error
+}
+
+func (r *resourceRepository) DeleteType(id uint) error {
+	return r.db.Delete(&model.ResourceType{}, id).Error
+}
+
+// CountTypeUsage counts the resources and requirements of a resource type.
+func (r *resourceRepository) CountTypeUsage(id uint) (int64, error) {
+	var resources, requirements int64
+	if err := r.db.Model(&model.Resource{}).Where("resource_type_id = ?", id).Count(&resources).Error; err != nil {
+		return 0, err
+	}
+	if err := r.db.Model(&model.ResourceRequirement{}).Where("resource_type_id = ?", id).Count(&requirements).Error; err != nil {
+		return 0, err
+	}
+	return resources + requirements, nil
+}
+
+// ============ RESOURCE ============
+func (r *resourceRepository) Create(resource *model.Resource) error {
+	return r.db.Omit("ResourceType").Create(resource).Error
+}
+
+// Find returns the resources of a type, or all resources when
+// resourceTypeID is zero.
+func (r *resourceRepository) Find(resourceTypeID uint) ([]model.Resource, error) {
+	var resources []model.Resource
+	query := r.db.Preload("ResourceType")
+	if resourceTypeID != 0 {
+		query = query.Where("resource_type_id = ?", resourceTypeID)
+	}
+	err := query.Order("resource_type_id, name").Find(&resources).Error
+	return resources, err
+}
+
+// FindActiveByTypes returns the active resources of the given types.
+func (r *resourceRepository) FindActiveByTypes(resourceTypeIDs []uint) ([]model.Resource, error) {
+	var resources []model.Resource
+	if len(resourceTypeIDs) == 0 {
+		return resources, nil
+	}
+	err := r.db.
+		Where("resource_type_id IN ? AND active = ?", resourceTypeIDs, true).
+		Order("id").
+		Find(&resources).Error
+	return resources, err
+}
+
+// FindByID returns a resource, or nil if there is none.
+func (r *resourceRepository) FindByID(id uint) (*model.Resource, error) {
+	var resources []model.Resource
+	err := r.db.Preload("ResourceType").Where("id = ?", id).Find(&resources).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(resources) < 1 {
+		return nil, nil
+	}
+	return &resources[0], nil
+}
+
+func (r *resourceRepository) Update(resource *model.Resource) error {
+	return r.db.Omit("ResourceType").Save(resource).Error
+}
+
+func (r *resourceRepository) Delete(id uint) error {
+	return r.db.Delete(&model.Resource{}, id).Error
+}
+
+// FindUpcomingBookings returns the reservations of a resource by appointments
+// that still take place and end after from, in chronological order.
+func (r *resourceRepository) FindUpcomingBookings(id uint, from time.Time) ([]model.ResourceBooking, error) {
+	var bookings []model.ResourceBooking
+	err := r.db.Table("appointment_resources").
+		Select("appointment_resources.resource_id, appointments.id AS appointment_id, appointments.start_at, appointments.end_at").
+		Joins("JOIN appointments ON appointments.id = appointment_resources.appointment_id").
+		Where("appointment_resources.resource_id = ? AND appointments.end_at > ?", id, from).
+		Where("appointments.status NOT IN ?", []string{model.AppointmentCancelled, model.AppointmentNoShow, model.AppointmentCompleted}).
+		Order("appointments.start_at, appointments.id").
+		Scan(&bookings).Error
+	return bookings, err
+}
+
+// ============ RESOURCE REQUIREMENT ============
+func (r *resourceRepository) CreateRequirement(requirement *model.ResourceRequirement) error {
+	return r.db.Omit("ResourceType", "LayananTerapi", "TeknikTerapi").Create(requirement).Error
+}
+
+// FindRequirements returns the requirements of a layanan terapi and of a
+// teknik terapi; a zero id is skipped. With both ids zero it returns all.
+func (r *resourceRepository) FindRequirements(layananTerapiID, teknikTerapiID uint) ([]model.ResourceRequirement, error) {
+	var requirements []model.ResourceRequirement
+	query := r.db.Preload("ResourceType").Preload("LayananTerapi").Preload("TeknikTerapi")
+	switch {
+	case layananTerapiID != 0 && teknikTerapiID != 0:
+		query = query.Where("layanan_terapi_id = ? OR teknik_terapi_id = ?", layananTerapiID, teknikTerapiID)
+	case layananTerapiID != 0:
+		query = query.Where("layanan_terapi_id = ?", layananTerapiID)
+	case teknikTerapiID != 0:
+		query = query.Where("teknik_terapi_id = ?", teknikTerapiID)
+	}
+	err := query.Order("id").Find(&requirements).Error
+	return requirements, err
+}
+
+// FindRequirementByID returns a requirement, or nil if there is none.
+func (r *resourceRepository) FindRequirementByID(id uint) (*model.ResourceRequirement, error) {
+	var requirements []model.ResourceRequirement
+	err := r.db.
+		Preload("ResourceType").Preload("LayananTerapi").Preload("TeknikTerapi").
+		Where("id = ?", id).
+		Find(&requirements).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(requirements) < 1 {
+		return nil, nil
+	}
+	return &requirements[0], nil
+}
+
+func (r *resourceRepository) DeleteRequirement(id uint) error {
+	return r.db.Delete(&model.ResourceRequirement{}, id).Error
+}
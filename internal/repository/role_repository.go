@@ -15,9 +15,68 @@ func NewRoleRepository(db *gorm.DB) RoleRepository {
 
 func (r *roleRepository) FindByID(id uint) (*model.Role, error) {
 	var role model.Role
-	err := r.db.First(&role, id).Error
+	err := r.db.Preload("Permissions").First(&role, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &role, nil
 }
+
+func (r *roleRepository) FindByName(name string) (*model.Role, error) {
+	var role model.Role
+	err := r.db.Preload("Permissions").Where("name = ?", name).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *roleRepository) FindAll() ([]model.Role, error) {
+	var roles []model.Role
+	err := r.db.Preload("Permissions").Order("level DESC, name").Find(&roles).Error
+	return roles, err
+}
+
+func (r *roleRepository) Create(role *model.Role) error {
+	return r.db.Create(role).Error
+}
+
+// Update saves the role and replaces its permissions with role.Permissions.
+func (r *roleRepository) Update(role *model.Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Permissions").Save(role).Error; err != nil {
+			return err
+		}
+		return tx.Model(role).Association("Permissions").Replace(role.Permissions)
+	})
+}
+
+func (r *roleRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM role_permissions WHERE role_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Role{}, id).Error
+	})
+}
+
+func (r *roleRepository) CountUsers(id uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.User{}).Where("role_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+func (r *roleRepository) FindAllPermissions() ([]model.Permission, error) {
+	var permissions []model.Permission
+	err := r.db.Order("name").Find(&permissions).Error
+	return permissions, err
+}
+
+func (r *roleRepository) FindPermissionsByNames(names []string) ([]model.Permission, error) {
+	var permissions []model.Permission
+	if len(names) == 0 {
+		return permissions, nil
+	}
+	err := r.db.Where("name IN ?", names).Find(&permissions).Error
+	return permissions, err
+}
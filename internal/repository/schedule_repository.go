@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"sim-clinic-api/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type scheduleRepository struct {
+	db *gorm.DB
+}
+
+func NewScheduleRepository(db *gorm.DB) ScheduleRepository {
+	return &scheduleRepository{db: db}
+}
+
+func (r *scheduleRepository) CreateWorkingHour(workingHour *model.WorkingHour) error {
+	return r.db.Omit("Therapist").Create(workingHour).Error
+}
+
+// FindWorkingHours returns the working hours of a therapist, or of every
+// therapist when therapistID is zero.
+func (r *scheduleRepository) FindWorkingHours(therapistID uint) ([]model.WorkingHour, error) {
+	var workingHours []model.WorkingHour
+	query := r.db.Preload("Therapist")
+	if therapistID != 0 {
+		query = query.Where("therapist_id = ?", therapistID)
+	}
+	err := query.Order("therapist_id, weekday, start_time").Find(&workingHours).Error
+	return workingHours, err
+}
+
+func (r *scheduleRepository) FindWorkingHoursByWeekday(therapistID uint, weekday time.Weekday) ([]model.WorkingHour, error) {
+	var workingHours []model.WorkingHour
+	err := r.db.
+		Where("therapist_id = ? AND weekday = ?", therapistID, int(weekday)).
+		Order("start_time").
+		Find(&workingHours).Error
+	return workingHours, err
+}
+
+// FindWorkingHourByID returns a working hour block, or nil if there is none.
+func (r *scheduleRepository) FindWorkingHourByID(id uint) (*model.WorkingHour, error) {
+	var workingHours []model.WorkingHour
+	err := r.db.Preload("Therapist").Where("id = ?", id).Find(&workingHours).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(workingHours) < 1 {
+		return nil, nil
+	}
+	return &workingHours[0], nil
+}
+
+func (r *scheduleRepository) UpdateWorkingHour(workingHour *model.WorkingHour) error {
+	return r.db.Omit("Therapist").Save(workingHour).Error
+}
+
+func (r *scheduleRepository) DeleteWorkingHour(id uint) error {
+	return r.db.Delete(&model.WorkingHour{}, id).Error
+}
+
+func (r *scheduleRepository) CreateException(exception *model.ScheduleException) error {
+	return r.db.Omit("Therapist").Create(exception).Error
+}
+
+// FindExceptions returns the exceptions overlapping [from, to). With a
+// therapistID it returns that therapist's leave plus clinic holidays. A zero
+// from or to leaves that side open.
+func (r *scheduleRepository) FindExceptions(therapistID uint, from, to time.Time) ([]model.ScheduleException, error) {
+	var exceptions []model.ScheduleException
+	query := r.db.Preload("Therapist")
+	if therapistID != 0 {
+		query = query.Where("therapist_id = ? OR therapist_id IS NULL", therapistID)
+	}
+	if !from.IsZero() {
+		query = query.Where("end_at > ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("start_at < ?", to)
+	}
+	err := query.Order("start_at, id").Find(&exceptions).Error
+	return exceptions, err
+}
+
+// FindExceptionByID returns an exception, or nil if there is none.
+func (r *scheduleRepository) FindExceptionByID(id uint) (*model.ScheduleException, error) {
+	var exceptions []model.ScheduleException
+	err := r.db.Where("id = ?", id).Find(&exceptions).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(exceptions) < 1 {
+		return nil, nil
+	}
+	return &exceptions[0], nil
+}
+
+func (r *scheduleRepository) DeleteException(id uint) error {
+	return r.db.Delete(&model.ScheduleException{}, id).Error
+}
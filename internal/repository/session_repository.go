@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"sim-clinic-api/internal/model"
+	"time"
+)
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(session *model.UserSession) error {
+	return r.db.Create(session).Error
+}
+
+func (r *sessionRepository) FindByID(id string) (*model.UserSession, error) {
+	var session model.UserSession
+	err := r.db.Where("id = ?", id).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) FindActiveByUser(userID uint) ([]model.UserSession, error) {
+	var sessions []model.UserSession
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *sessionRepository) Touch(id string, seenAt time.Time, ipAddress string) error {
+	updates := map[string]interface{}{"last_seen_at": seenAt}
+	if ipAddress != "" {
+		updates["ip_address"] = ipAddress
+	}
+	return r.db.Model(&model.UserSession{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *sessionRepository) Extend(id string, expiresAt time.Time) error {
+	return r.db.Model(&model.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"expires_at":   expiresAt,
+			"last_seen_at": time.Now(),
+		}).Error
+}
+
+// Revoke ends a session together with its refresh tokens.
+func (r *sessionRepository) Revoke(id, reason string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return revokeSessions(tx, []string{id}, reason)
+	})
+}
+
+// RevokeAllByUser ends every active session of a user and returns the IDs of
+// the sessions that were revoked.
+func (r *sessionRepository) RevokeAllByUser(userID uint, reason string) ([]string, error) {
+	var ids []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.UserSession{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		return revokeSessions(tx, ids, reason)
+	})
+	return ids, err
+}
+
+func revokeSessions(tx *gorm.DB, ids []string, reason string) error {
+	now := time.Now()
+	err := tx.Model(&model.UserSession{}).
+		Where("id IN ? AND revoked_at IS NULL", ids).
+		Updates(map[string]interface{}{
+			"revoked_at":    now,
+			"revoke_reason": reason,
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&model.RefreshToken{}).
+		Where("family_id IN ? AND revoked_at IS NULL", ids).
+		Updates(map[string]interface{}{
+			"revoked_at":    now,
+			"revoke_reason": reason,
+		}).Error
+}
+
+func (r *sessionRepository) DeleteExpired() error {
+	return r.db.Where("expires_at <= ?", time.Now()).Delete(&model.UserSession{}).Error
+}
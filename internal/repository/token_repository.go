@@ -1,11 +1,20 @@
 package repository
 
 import (
+	"errors"
 	"gorm.io/gorm"
 	"sim-clinic-api/internal/model"
 	"time"
 )
 
+// ErrRefreshTokenReused is returned when a refresh token that was already
+// rotated or revoked is presented again.
+var ErrRefreshTokenReused = errors.New("refresh token already used")
+
+// ErrPasswordResetTokenUsed is returned when a password reset token has
+// already been consumed.
+var ErrPasswordResetTokenUsed = errors.New("password reset token already used")
+
 type tokenRepository struct {
 	db *gorm.DB
 }
@@ -31,12 +40,120 @@ func (r *tokenRepository) IsTokenBlacklisted(token string) (bool, error) {
 	return true, nil
 }
 
+func (r *tokenRepository) IsTokenRevoked(jti string) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.BlacklistedToken{}).
+		Where("jti = ? AND expires_at > ?", jti, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CleanExpiredTokens permanently removes blacklist entries, refresh tokens and
+// password reset tokens that can no longer be used.
 func (r *tokenRepository) CleanExpiredTokens() error {
-	return r.db.Where("expires_at <= ?", time.Now()).Delete(&model.BlacklistedToken{}).Error
+	now := time.Now()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("expires_at <= ?", now).Delete(&model.BlacklistedToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("expires_at <= ?", now).Delete(&model.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("expires_at <= ?", now).Delete(&model.PasswordResetToken{}).Error
+	})
 }
 
-func (r *tokenRepository) GetUserActiveTokens(userID uint) ([]model.BlacklistedToken, error) {
+func (r *tokenRepository) GetUserBlacklistedTokens(userID uint) ([]model.BlacklistedToken, error) {
 	var tokens []model.BlacklistedToken
 	err := r.db.Where("user_id = ? AND expires_at > ?", userID, time.Now()).Find(&tokens).Error
 	return tokens, err
 }
+
+func (r *tokenRepository) CreateRefreshToken(token *model.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *tokenRepository) FindRefreshTokenByHash(tokenHash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken revokes oldID and stores newToken in one transaction.
+// The revoke only succeeds while oldID is still active, so two concurrent
+// rotations of the same token cannot both win.
+func (r *tokenRepository) RotateRefreshToken(oldID uint, newToken *model.RefreshToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(newToken).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&model.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", oldID).
+			Updates(map[string]interface{}{
+				"revoked_at":     time.Now(),
+				"revoke_reason":  "rotated",
+				"replaced_by_id": newToken.ID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+		return nil
+	})
+}
+
+func (r *tokenRepository) RevokeRefreshTokenFamily(familyID, reason string) error {
+	return r.db.Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Updates(map[string]interface{}{
+			"revoked_at":    time.Now(),
+			"revoke_reason": reason,
+		}).Error
+}
+
+func (r *tokenRepository) RevokeUserRefreshTokens(userID uint, reason string) error {
+	return r.db.Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"revoked_at":    time.Now(),
+			"revoke_reason": reason,
+		}).Error
+}
+
+func (r *tokenRepository) CreatePasswordResetToken(token *model.PasswordResetToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *tokenRepository) FindPasswordResetTokenByHash(tokenHash string) (*model.PasswordResetToken, error) {
+	var token model.PasswordResetToken
+	err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *tokenRepository) MarkPasswordResetTokenUsed(id uint) error {
+	result := r.db.Model(&model.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPasswordResetTokenUsed
+	}
+	return nil
+}
+
+func (r *tokenRepository) InvalidateUserPasswordResetTokens(userID uint) error {
+	return r.db.Model(&model.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
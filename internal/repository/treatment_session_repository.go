@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"sim-clinic-api/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type treatmentSessionRepository struct {
+	db *gorm.DB
+}
+
+func NewTreatmentSessionRepository(db *gorm.DB) TreatmentSessionRepository {
+	return &treatmentSessionRepository{db: db}
+}
+
+// preloadTreatmentSession memuat master data termasuk yang sudah dihapus,
+// agar sesi lama tetap tampil lengkap
+func preloadTreatmentSession(db *gorm.DB) *gorm.DB {
+	unscoped := func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped()
+	}
+	return db.
+		Preload("Therapist", unscoped).
+		Preload("LayananTerapi", unscoped).
+		Preload("TeknikTerapi", unscoped)
+}
+
+func (r *treatmentSessionRepository) Create(session *model.TreatmentSession) error {
+	return r.db.Omit("Therapist", "LayananTerapi", "TeknikTerapi.*").Create(session).Error
+}
+
+// FindByCustomer returns the sessions of a customer started within [from, to),
+// newest first. A zero from or to leaves that side open.
+func (r *treatmentSessionRepository) FindByCustomer(customerID string, from, to time.Time) ([]model.TreatmentSession, error) {
+	var sessions []model.TreatmentSession
+	query := preloadTreatmentSession(r.db).Where("customer_id = ?", customerID)
+	if !from.IsZero() {
+		query = query.Where("started_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("started_at < ?", to)
+	}
+	err := query.Order("started_at DESC, id DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// FindByID returns a session of the given customer, or nil if there is none.
+func (r *treatmentSessionRepository) FindByID(customerID string, id uint) (*model.TreatmentSession, error) {
+	var sessions []model.TreatmentSession
+	err := preloadTreatmentSession(r.db).
+		Where("customer_id = ? AND id = ?", customerID, id).
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) < 1 {
+		return nil, nil
+	}
+	return &sessions[0], nil
+}
+
+// Update saves the session and replaces its teknik terapi.
+func (r *treatmentSessionRepository) Update(session *model.TreatmentSession) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Therapist", "LayananTerapi", "TeknikTerapi").Save(session).Error; err != nil {
+			return err
+		}
+		return tx.Model(session).Association("TeknikTerapi").Replace(session.TeknikTerapi)
+	})
+}
+
+func (r *treatmentSessionRepository) Delete(id uint) error {
+	return r.db.Delete(&model.TreatmentSession{}, id).Error
+}
@@ -5,6 +5,7 @@ import (
 	"gorm.io/gorm"
 	"sim-clinic-api/internal/model"
 	"strconv"
+	"time"
 )
 
 var tagRepoUser = "internal.repository.user_repository."
@@ -48,7 +49,8 @@ func (r *userRepository) FindByID(id uint) (*model.User, error) {
 	return &user, nil
 }
 
-func (r *userRepository) FindAll(page, limit, search string) ([]model.User, int64, error) {
+// FindAll lists users page by page. A non-nil maxRoleLevel hides users whose role level is above it.
+func (r *userRepository) FindAll(page, limit, search string, maxRoleLevel *int) ([]model.User, int64, error) {
 	var (
 		users               []model.User
 		currPage, currLimit int
@@ -76,6 +78,10 @@ func (r *userRepository) FindAll(page, limit, search string) ([]model.User, int6
 		query = query.Where("username LIKE ?", searchPattern)
 	}
 
+	if maxRoleLevel != nil {
+		query = query.Where("role_id IN (?)", r.db.Model(&model.Role{}).Select("id").Where("level <= ?", *maxRoleLevel))
+	}
+
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
@@ -122,3 +128,32 @@ func (r *userRepository) Update(user *model.User) error {
 func (r *userRepository) Delete(id uint) error {
 	return r.db.Delete(&model.User{}, id).Error
 }
+
+// IncrementFailedLogin bumps the failed login counter and returns its new
+// value. Failures older than windowStart no longer count.
+func (r *userRepository) IncrementFailedLogin(id uint, windowStart time.Time) (int, error) {
+	var count int
+	err := r.db.Raw(`
+		UPDATE users SET
+			failed_login_count = CASE
+				WHEN last_failed_login_at IS NULL OR last_failed_login_at < ? THEN 1
+				ELSE failed_login_count + 1
+			END,
+			last_failed_login_at = ?
+		WHERE id = ?
+		RETURNING failed_login_count`, windowStart, time.Now(), id).
+		Scan(&count).Error
+	return count, err
+}
+
+func (r *userRepository) LockUser(id uint, until time.Time) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Update("locked_until", until).Error
+}
+
+func (r *userRepository) ResetFailedLogins(id uint) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"failed_login_count":   0,
+		"last_failed_login_at": nil,
+		"locked_until":         nil,
+	}).Error
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/repository"
+	"sim-clinic-api/internal/utils"
+	"time"
+)
+
+const (
+	// apiKeyPrefix marks API keys so they are easy to spot in logs and secret scanners.
+	apiKeyPrefix = "sck_"
+	// apiKeyDisplayLength is how much of the key is kept in plaintext to tell keys apart.
+	apiKeyDisplayLength = 12
+)
+
+type apiKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+	authCache  *AuthCache
+	authorizer *Authorizer
+}
+
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository, authCache *AuthCache, authorizer *Authorizer) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		authCache:  authCache,
+		authorizer: authorizer,
+	}
+}
+
+func (s *apiKeyService) CreateAPIKey(request model.CreateAPIKeyRequest, currentUserRole string, currentUserID uint) (*model.APIKeyCreatedResponse, error) {
+	if err := s.authorizer.Require(currentUserRole, model.PermAPIKeyManage); err != nil {
+		return nil, err
+	}
+
+	secret, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+	plainKey := apiKeyPrefix + secret
+
+	key := &model.APIKey{
+		Name:       request.Name,
+		Prefix:     plainKey[:apiKeyDisplayLength],
+		KeyHash:    utils.HashToken(plainKey),
+		Scopes:     request.Scopes,
+		AllowedIPs: request.AllowedIPs,
+		ExpiresAt:  request.ExpiresAt,
+		CreatedBy:  currentUserID,
+	}
+
+	if err := s.apiKeyRepo.Create(key); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("User %d created API key %d (%s) with scopes %v", currentUserID, key.ID, key.Name, key.Scopes)
+	return &model.APIKeyCreatedResponse{APIKey: *key, Key: plainKey}, nil
+}
+
+func (s *apiKeyService) GetAPIKeys(currentUserRole string) ([]model.APIKey, error) {
+	if err := s.authorizer.Require(currentUserRole, model.PermAPIKeyManage); err != nil {
+		return nil, err
+	}
+
+	return s.apiKeyRepo.FindAll()
+}
+
+func (s *apiKeyService) RevokeAPIKey(id uint, currentUserRole string, currentUserID uint) error {
+	if err := s.authorizer.Require(currentUserRole, model.PermAPIKeyManage); err != nil {
+		return err
+	}
+
+	key, err := s.apiKeyRepo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &ServiceError{Message: "api key not found", Code: 404}
+		}
+		return err
+	}
+
+	if key.RevokedAt != nil {
+		return &ServiceError{Message: "api key already revoked", Code: 400}
+	}
+
+	if err := s.apiKeyRepo.Revoke(id); err != nil {
+		return err
+	}
+	s.authCache.InvalidateAPIKey(key.KeyHash)
+
+	logrus.Infof("User %d revoked API key %d (%s)", currentUserID, id, key.Name)
+	return nil
+}
+
+func (s *apiKeyService) ValidateAPIKey(plainKey, ipAddress string) (*model.APIKey, error) {
+	keyHash := utils.HashToken(plainKey)
+
+	key, ok := s.authCache.apiKeys.Get(keyHash)
+	if !ok {
+		found, err := s.apiKeyRepo.FindByHash(keyHash)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, &ServiceError{Message: "invalid api key", Code: 401}
+			}
+			return nil, err
+		}
+		key = *found
+	}
+
+	now := time.Now()
+	if !key.IsActive(now) {
+		return nil, &ServiceError{Message: "api key has been revoked or expired", Code: 401}
+	}
+
+	if !key.AllowsIP(ipAddress) {
+		logrus.Warnf("API key %d used from disallowed address %s", key.ID, ipAddress)
+		return nil, &ServiceError{Message: "api key not allowed from this address", Code: 403}
+	}
+
+	// Cukup catat pemakaian terakhir sekali per interval
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > sessionTouchInterval || key.LastUsedIP != ipAddress {
+		if err := s.apiKeyRepo.TouchLastUsed(key.ID, now, ipAddress); err != nil {
+			logrus.Warnf("Failed to update API key %d: %v", key.ID, err)
+		}
+		key.LastUsedAt = &now
+		key.LastUsedIP = ipAddress
+	}
+
+	s.authCache.apiKeys.Set(keyHash, key, s.authCache.userTTL)
+	return &key, nil
+}
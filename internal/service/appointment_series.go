@@ -0,0 +1,319 @@
+package service
+
+import (
+	"fmt"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/notification"
+	"sim-clinic-api/internal/utils"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PreviewSeries lists the occurrences a series would book and which of them
+// conflict with working hours, leave or other appointments. Nothing is saved.
+func (s *appointmentService) PreviewSeries(request model.CreateAppointmentSeriesRequest) (*model.AppointmentSeriesPreview, error) {
+	plan, err := s.planSeries(request)
+	if err != nil {
+		return nil, err
+	}
+	return plan.preview, nil
+}
+
+// CreateSeries books every occurrence of a recurring therapy program. If some
+// occurrences conflict the whole series is refused, unless the request asks
+// to skip them.
+func (s *appointmentService) CreateSeries(request model.CreateAppointmentSeriesRequest, currentUserID uint, currentUserRole string) (*model.AppointmentSeries, error) {
+	plan, err := s.planSeries(request)
+	if err != nil {
+		return nil, err
+	}
+	booking, err := s.bookingContext(currentUserRole, request.DepositReference)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attendance.CheckBooking(request.CustomerID, booking); err != nil {
+		return nil, err
+	}
+
+	preview := plan.preview
+	if preview.Conflicts > 0 && !request.SkipConflicts {
+		return nil, &ServiceError{
+			Message: fmt.Sprintf("%d of %d occurrences conflict with the schedule, preview the series or set skipConflicts", preview.Conflicts, len(preview.Occurrences)),
+			Code:    409,
+		}
+	}
+
+	appointments := make([]model.Appointment, 0, len(preview.Occurrences)-preview.Conflicts)
+	for _, occurrence := range preview.Occurrences {
+		if occurrence.Conflict != "" {
+			continue
+		}
+		appointments = append(appointments, model.Appointment{
+			CustomerID:       request.CustomerID,
+			TherapistID:      request.TherapistID,
+			LayananTerapiID:  request.LayananTerapiID,
+			TeknikTerapiID:   request.TeknikTerapiID,
+			StartAt:          occurrence.StartAt,
+			EndAt:            occurrence.EndAt,
+			Status:           model.AppointmentBooked,
+			Notes:            request.Notes,
+			Source:           booking.Source,
+			DepositReference: booking.DepositReference,
+			CreatedBy:        currentUserID,
+			Resources:        occurrence.Resources,
+		})
+	}
+	if len(appointments) == 0 {
+		return nil, &ServiceError{Message: "none of the occurrences can be booked", Code: 409}
+	}
+
+	events := make([]model.AppointmentEvent, len(appointments))
+	for i := range events {
+		events[i] = model.AppointmentEvent{
+			Type:     model.AppointmentEventBooked,
+			ToStatus: model.AppointmentBooked,
+			UserID:   currentUserID,
+		}
+	}
+	// Konfirmasi dikirim sekali untuk kunjungan pertama; sisanya diingatkan satu per satu
+	appointments[0].Customer = plan.customer
+	appointments[0].LayananTerapi = plan.layanan
+	events[0].Notifications = s.notifications.appointmentMessages(notification.TemplateAppointmentBooked, &appointments[0])
+
+	series := &model.AppointmentSeries{
+		CustomerID:      request.CustomerID,
+		TherapistID:     request.TherapistID,
+		LayananTerapiID: request.LayananTerapiID,
+		TeknikTerapiID:  request.TeknikTerapiID,
+		RRule:           request.RRule,
+		StartAt:         request.StartAt,
+		Notes:           request.Notes,
+		CreatedBy:       currentUserID,
+	}
+	if err := s.appointmentRepo.CreateSeries(series, appointments, events); err != nil {
+		return nil, mapAppointmentConflict(err)
+	}
+
+	logrus.Infof("Appointment series %d booked for customer %s with %d appointments (%d skipped)", series.ID, request.CustomerID, len(appointments), preview.Conflicts)
+	return s.appointmentRepo.FindSeriesByID(series.ID)
+}
+
+func (s *appointmentService) GetSeries(id uint) (*model.AppointmentSeries, error) {
+	series, err := s.appointmentRepo.FindSeriesByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if series == nil {
+		return nil, &ServiceError{Message: "appointment series not found", Code: 404}
+	}
+	return series, nil
+}
+
+// seriesPlan is a checked series request, ready to be booked.
+type seriesPlan struct {
+	preview  *model.AppointmentSeriesPreview
+	customer *model.Customer
+	layanan  *model.LayananTerapi
+}
+
+// planSeries expands the recurrence rule and checks every occurrence the same
+// way a single booking is checked.
+func (s *appointmentService) planSeries(request model.CreateAppointmentSeriesRequest) (*seriesPlan, error) {
+	customer, err := findCustomer(s.customerRepo, request.CustomerID, 400)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTherapist(s.userRepo, s.authorizer, request.TherapistID); err != nil {
+		return nil, err
+	}
+	layanan, err := s.findLayananTerapi(request.LayananTerapiID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTeknikTerapi(request.TeknikTerapiID); err != nil {
+		return nil, err
+	}
+
+	rule, err := utils.ParseRecurrenceRule(request.RRule, s.policy.Location)
+	if err != nil {
+		return nil, &ServiceError{Message: err.Error(), Code: 400}
+	}
+	starts, err := rule.Occurrences(request.StartAt.In(s.policy.Location))
+	if err != nil {
+		return nil, &ServiceError{Message: err.Error(), Code: 400}
+	}
+
+	length := time.Duration(layanan.DurationMinutes) * time.Minute
+	var planner *resourcePlanner
+	if len(starts) > 0 {
+		period := timeRange{Start: starts[0], End: starts[len(starts)-1].Add(length)}
+		if planner, err = s.planResources(layanan.ID, request.TeknikTerapiID, period, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	preview := &model.AppointmentSeriesPreview{Occurrences: make([]model.SeriesOccurrence, 0, len(starts))}
+	for _, start := range starts {
+		occurrence := model.SeriesOccurrence{StartAt: start, EndAt: start.Add(length)}
+		slot := timeRange{Start: occurrence.StartAt, End: occurrence.EndAt}
+		err := s.checkAvailable(request.TherapistID, slot, nil)
+		if err == nil {
+			occurrence.Resources, err = planner.allocate(slot)
+		}
+		if serviceErr, ok := err.(*ServiceError); ok {
+			occurrence.Conflict = serviceErr.Message
+			preview.Conflicts++
+		} else if err != nil {
+			return nil, err
+		} else {
+			planner.reserve(slot, occurrence.Resources)
+		}
+		preview.Occurrences = append(preview.Occurrences, occurrence)
+	}
+	return &seriesPlan{preview: preview, customer: customer, layanan: layanan}, nil
+}
+
+// rescheduleSeries shifts the booked occurrences in scope by the same amount
+// the given appointment moves. Either every occurrence fits or none moves.
+func (s *appointmentService) rescheduleSeries(appointment *model.Appointment, request model.RescheduleAppointmentRequest, currentUserID uint) (*model.Appointment, error) {
+	if appointment.Status != model.AppointmentBooked {
+		return nil, &ServiceError{Message: "only booked appointments can be rescheduled", Code: 409}
+	}
+	appointments, err := s.seriesInScope(appointment, request.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	therapistID := appointment.TherapistID
+	if request.TherapistID != 0 && request.TherapistID != therapistID {
+		if err := checkTherapist(s.userRepo, s.authorizer, request.TherapistID); err != nil {
+			return nil, err
+		}
+		therapistID = request.TherapistID
+	}
+
+	// Janji yang ikut dipindah tidak dihitung sebagai bentrok satu sama lain
+	moving := make(map[uint]bool, len(appointments))
+	for _, a := range appointments {
+		moving[a.ID] = true
+	}
+
+	shift := request.StartAt.Sub(appointment.StartAt)
+	period := timeRange{Start: appointments[0].StartAt.Add(shift), End: appointments[len(appointments)-1].EndAt.Add(shift)}
+	planner, err := s.planResources(appointment.LayananTerapiID, appointment.TeknikTerapiID, period, moving)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]model.AppointmentEvent, 0, len(appointments))
+	for i := range appointments {
+		a := &appointments[i]
+		slot := timeRange{Start: a.StartAt.Add(shift), End: a.EndAt.Add(shift)}
+		err := s.checkAvailable(therapistID, slot, moving)
+		if err == nil {
+			a.Resources, err = planner.allocate(slot)
+		}
+		if err != nil {
+			if serviceErr, ok := err.(*ServiceError); ok {
+				serviceErr.Message = "occurrence on " + a.StartAt.In(s.policy.Location).Format(model.DateLayout) + ": " + serviceErr.Message
+			}
+			return nil, err
+		}
+		planner.reserve(slot, a.Resources)
+
+		previousStartAt := a.StartAt
+		previousTherapistID := a.TherapistID
+		events = append(events, model.AppointmentEvent{
+			Type:                model.AppointmentEventRescheduled,
+			PreviousStartAt:     &previousStartAt,
+			PreviousTherapistID: &previousTherapistID,
+			Reason:              request.Reason,
+			UserID:              currentUserID,
+		})
+		a.TherapistID = therapistID
+		a.StartAt = slot.Start
+		a.EndAt = slot.End
+	}
+
+	if err := s.updateMany(appointments, model.AppointmentBooked, events); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Rescheduled %d appointments of series %d by %s (scope %s, user %d)", len(appointments), *appointment.SeriesID, shift, request.Scope, currentUserID)
+	return s.appointmentRepo.FindByID(appointment.ID)
+}
+
+// cancelSeries cancels the booked occurrences in scope. Occurrences that
+// already took place or were cancelled are left alone.
+func (s *appointmentService) cancelSeries(appointment *model.Appointment, request model.CancelAppointmentRequest, currentUserID uint) (*model.Appointment, error) {
+	appointments, err := s.seriesInScope(appointment, request.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	events := make([]model.AppointmentEvent, 0, len(appointments))
+	for i := range appointments {
+		appointments[i].Status = model.AppointmentCancelled
+		appointments[i].CancelReason = request.Reason
+		appointments[i].CancelledBy = cancelledBy(request)
+		appointments[i].CancelledAt = &now
+		events = append(events, model.AppointmentEvent{
+			Type:       model.AppointmentEventStatus,
+			FromStatus: model.AppointmentBooked,
+			ToStatus:   model.AppointmentCancelled,
+			Reason:     request.Reason,
+			UserID:     currentUserID,
+		})
+	}
+
+	// Pasien cukup menerima satu pesan untuk kunjungan terdekat yang dibatalkan
+	appointments[0].Customer = appointment.Customer
+	appointments[0].LayananTerapi = appointment.LayananTerapi
+	events[0].Notifications = s.notifications.appointmentMessages(notification.TemplateAppointmentCancelled, &appointments[0])
+
+	if err := s.updateMany(appointments, model.AppointmentBooked, events); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Cancelled %d appointments of series %d (scope %s, user %d): %s", len(appointments), *appointment.SeriesID, request.Scope, currentUserID, request.Reason)
+	return s.appointmentRepo.FindByID(appointment.ID)
+}
+
+// seriesInScope returns the booked occurrences affected by a change to
+// appointment: from this one onwards for "following", and every upcoming one
+// for "all".
+func (s *appointmentService) seriesInScope(appointment *model.Appointment, scope string) ([]model.Appointment, error) {
+	if appointment.SeriesID == nil {
+		return nil, &ServiceError{Message: "appointment is not part of a series", Code: 400}
+	}
+
+	from := appointment.StartAt
+	if scope == model.SeriesScopeAll {
+		from = time.Now()
+	}
+	appointments, err := s.appointmentRepo.FindBookedInSeries(*appointment.SeriesID, from)
+	if err != nil {
+		return nil, err
+	}
+	if len(appointments) == 0 {
+		return nil, &ServiceError{Message: "no booked appointments left in this series", Code: 409}
+	}
+	return appointments, nil
+}
+
+func (s *appointmentService) updateMany(appointments []model.Appointment, expectedStatus string, events []model.AppointmentEvent) error {
+	updated, err := s.appointmentRepo.UpdateMany(appointments, expectedStatus, events)
+	if err != nil {
+		return mapAppointmentConflict(err)
+	}
+	if !updated {
+		return &ServiceError{Message: "an appointment in this series was changed by someone else, please reload", Code: 409}
+	}
+	return nil
+}
+
+func isSeriesScope(scope string) bool {
+	return scope == model.SeriesScopeFollowing || scope == model.SeriesScopeAll
+}
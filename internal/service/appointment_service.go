@@ -0,0 +1,474 @@
+package service
+
+import (
+	"errors"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/notification"
+	"sim-clinic-api/internal/repository"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type appointmentService struct {
+	appointmentRepo repository.AppointmentRepository
+	scheduleRepo    repository.ScheduleRepository
+	customerRepo    repository.CustomerRepository
+	masterRepo      repository.MasterDataRepository
+	userRepo        repository.UserRepository
+	resourceRepo    repository.ResourceRepository
+	authorizer      *Authorizer
+	policy          AppointmentPolicy
+	notifications   NotificationPolicy
+	attendance      AttendanceService
+}
+
+func NewAppointmentService(
+	appointmentRepo repository.AppointmentRepository,
+	scheduleRepo repository.ScheduleRepository,
+	customerRepo repository.CustomerRepository,
+	masterRepo repository.MasterDataRepository,
+	userRepo repository.UserRepository,
+	resourceRepo repository.ResourceRepository,
+	authorizer *Authorizer,
+	policy AppointmentPolicy,
+	notifications NotificationPolicy,
+	attendance AttendanceService,
+) AppointmentService {
+	if policy.Location == nil {
+		policy.Location = time.Local
+	}
+	if policy.SlotStep <= 0 {
+		policy.SlotStep = 15 * time.Minute
+	}
+
+	return &appointmentService{
+		appointmentRepo: appointmentRepo,
+		scheduleRepo:    scheduleRepo,
+		customerRepo:    customerRepo,
+		masterRepo:      masterRepo,
+		userRepo:        userRepo,
+		resourceRepo:    resourceRepo,
+		authorizer:      authorizer,
+		policy:          policy,
+		notifications:   notifications.withDefaults(),
+		attendance:      attendance,
+	}
+}
+
+// GetSlots lists the slots on a day in which both the therapist and the
+// resources the layanan terapi, and teknik terapi if given, need are free.
+func (s *appointmentService) GetSlots(request model.AppointmentSlotRequest) ([]model.AppointmentSlot, error) {
+	if err := checkTherapist(s.userRepo, s.authorizer, request.TherapistID); err != nil {
+		return nil, err
+	}
+	layanan, err := s.findLayananTerapi(request.LayananTerapiID)
+	if err != nil {
+		return nil, err
+	}
+	var teknikTerapiID *uint
+	if request.TeknikTerapiID != 0 {
+		teknikTerapiID = &request.TeknikTerapiID
+		if err := s.checkTeknikTerapi(teknikTerapiID); err != nil {
+			return nil, err
+		}
+	}
+
+	day, _ := time.ParseInLocation(model.DateLayout, request.Date, s.policy.Location)
+	free, err := s.freeRanges(request.TherapistID, day)
+	if err != nil {
+		return nil, err
+	}
+
+	busy, err := s.busyRanges(request.TherapistID, timeRange{Start: day, End: day.AddDate(0, 0, 1)}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	length := time.Duration(layanan.DurationMinutes) * time.Minute
+	slots := freeSlots(free, busy, length, s.policy.SlotStep, time.Now())
+
+	planner, err := s.planResources(layanan.ID, teknikTerapiID, timeRange{Start: day, End: day.AddDate(0, 0, 1)}, nil)
+	if err != nil {
+		return nil, err
+	}
+	available := slots[:0]
+	for _, slot := range slots {
+		if _, err := planner.allocate(timeRange{Start: slot.StartAt, End: slot.EndAt}); err == nil {
+			available = append(available, slot)
+		}
+	}
+	return available, nil
+}
+
+func (s *appointmentService) GetAppointments(request model.AppointmentListRequest) ([]model.Appointment, error) {
+	var from, to time.Time
+	if request.From != "" {
+		from, _ = time.ParseInLocation(model.DateLayout, request.From, s.policy.Location)
+	}
+	if request.To != "" {
+		to, _ = time.ParseInLocation(model.DateLayout, request.To, s.policy.Location)
+		to = to.AddDate(0, 0, 1)
+	}
+	return s.appointmentRepo.Find(from, to, request.TherapistID, request.CustomerID, request.Status)
+}
+
+func (s *appointmentService) GetAppointment(id uint) (*model.AppointmentDetail, error) {
+	appointment, err := s.findAppointment(id)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.appointmentRepo.FindEvents(id)
+	if err != nil {
+		return nil, err
+	}
+	return &model.AppointmentDetail{Appointment: *appointment, Events: events}, nil
+}
+
+func (s *appointmentService) CreateAppointment(request model.CreateAppointmentRequest, currentUserID uint, currentUserRole string) (*model.Appointment, error) {
+	customer, err := findCustomer(s.customerRepo, request.CustomerID, 400)
+	if err != nil {
+		return nil, err
+	}
+	booking, err := s.bookingContext(currentUserRole, request.DepositReference)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attendance.CheckBooking(request.CustomerID, booking); err != nil {
+		return nil, err
+	}
+	if err := checkTherapist(s.userRepo, s.authorizer, request.TherapistID); err != nil {
+		return nil, err
+	}
+	layanan, err := s.findLayananTerapi(request.LayananTerapiID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTeknikTerapi(request.TeknikTerapiID); err != nil {
+		return nil, err
+	}
+
+	slot := timeRange{
+		Start: request.StartAt,
+		End:   request.StartAt.Add(time.Duration(layanan.DurationMinutes) * time.Minute),
+	}
+	if err := s.checkAvailable(request.TherapistID, slot, nil); err != nil {
+		return nil, err
+	}
+	planner, err := s.planResources(layanan.ID, request.TeknikTerapiID, slot, nil)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := planner.allocate(slot)
+	if err != nil {
+		return nil, err
+	}
+
+	appointment := &model.Appointment{
+		CustomerID:       request.CustomerID,
+		TherapistID:      request.TherapistID,
+		LayananTerapiID:  request.LayananTerapiID,
+		TeknikTerapiID:   request.TeknikTerapiID,
+		StartAt:          slot.Start,
+		EndAt:            slot.End,
+		Status:           model.AppointmentBooked,
+		Notes:            request.Notes,
+		Source:           booking.Source,
+		DepositReference: booking.DepositReference,
+		CreatedBy:        currentUserID,
+		Resources:        resources,
+	}
+	appointment.Customer = customer
+	appointment.LayananTerapi = layanan
+	event := &model.AppointmentEvent{
+		Type:          model.AppointmentEventBooked,
+		ToStatus:      model.AppointmentBooked,
+		UserID:        currentUserID,
+		Notifications: s.notifications.appointmentMessages(notification.TemplateAppointmentBooked, appointment),
+	}
+	if err := s.appointmentRepo.Create(appointment, event); err != nil {
+		return nil, mapAppointmentConflict(err)
+	}
+
+	logrus.Infof("Appointment %d booked for customer %s with therapist %d at %s", appointment.ID, request.CustomerID, request.TherapistID, slot.Start)
+	return s.appointmentRepo.FindByID(appointment.ID)
+}
+
+// bookingContext works out how a booking is made from who makes it. Front
+// desk staff book in person and vouch for the deposits they record; anyone
+// else books online and cannot claim a deposit was paid.
+func (s *appointmentService) bookingContext(currentUserRole, depositReference string) (model.BookingContext, error) {
+	if s.authorizer.Can(currentUserRole, model.PermAppointmentDesk) {
+		return model.BookingContext{Source: model.BookingSourceFrontDesk, DepositReference: depositReference}, nil
+	}
+	if depositReference != "" {
+		return model.BookingContext{}, &ServiceError{Message: "only front desk staff can record a deposit", Code: 403}
+	}
+	return model.BookingContext{Source: model.BookingSourceOnline}, nil
+}
+
+// RescheduleAppointment moves a booked appointment to another time, and
+// optionally to another therapist. For an appointment in a series the scope
+// decides whether later or all occurrences move along with it.
+func (s *appointmentService) RescheduleAppointment(id uint, request model.RescheduleAppointmentRequest, currentUserID uint) (*model.Appointment, error) {
+	appointment, err := s.findAppointment(id)
+	if err != nil {
+		return nil, err
+	}
+	if isSeriesScope(request.Scope) {
+		return s.rescheduleSeries(appointment, request, currentUserID)
+	}
+	if appointment.Status != model.AppointmentBooked {
+		return nil, &ServiceError{Message: "only booked appointments can be rescheduled", Code: 409}
+	}
+
+	therapistID := appointment.TherapistID
+	if request.TherapistID != 0 && request.TherapistID != therapistID {
+		if err := checkTherapist(s.userRepo, s.authorizer, request.TherapistID); err != nil {
+			return nil, err
+		}
+		therapistID = request.TherapistID
+	}
+
+	slot := timeRange{
+		Start: request.StartAt,
+		End:   request.StartAt.Add(appointment.EndAt.Sub(appointment.StartAt)),
+	}
+	moving := map[uint]bool{appointment.ID: true}
+	if err := s.checkAvailable(therapistID, slot, moving); err != nil {
+		return nil, err
+	}
+	planner, err := s.planResources(appointment.LayananTerapiID, appointment.TeknikTerapiID, slot, moving)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := planner.allocate(slot)
+	if err != nil {
+		return nil, err
+	}
+
+	previousStartAt := appointment.StartAt
+	previousTherapistID := appointment.TherapistID
+	event := &model.AppointmentEvent{
+		Type:                model.AppointmentEventRescheduled,
+		PreviousStartAt:     &previousStartAt,
+		PreviousTherapistID: &previousTherapistID,
+		Reason:              request.Reason,
+		UserID:              currentUserID,
+	}
+
+	appointment.TherapistID = therapistID
+	appointment.StartAt = slot.Start
+	appointment.EndAt = slot.End
+	appointment.Resources = resources
+	if err := s.update(appointment, model.AppointmentBooked, event); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Appointment %d rescheduled from %s to %s by user %d", id, previousStartAt, slot.Start, currentUserID)
+	return s.appointmentRepo.FindByID(id)
+}
+
+func (s *appointmentService) CancelAppointment(id uint, request model.CancelAppointmentRequest, currentUserID uint) (*model.Appointment, error) {
+	appointment, err := s.findAppointment(id)
+	if err != nil {
+		return nil, err
+	}
+	if isSeriesScope(request.Scope) {
+		return s.cancelSeries(appointment, request, currentUserID)
+	}
+
+	now := time.Now()
+	appointment.CancelReason = request.Reason
+	appointment.CancelledBy = cancelledBy(request)
+	appointment.CancelledAt = &now
+	if err := s.transition(appointment, model.AppointmentCancelled, request.Reason, currentUserID); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Appointment %d cancelled by user %d: %s", id, currentUserID, request.Reason)
+	return s.appointmentRepo.FindByID(id)
+}
+
+func (s *appointmentService) UpdateAppointmentStatus(id uint, request model.AppointmentStatusRequest, currentUserID uint) (*model.Appointment, error) {
+	appointment, err := s.findAppointment(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pasien baru bisa dianggap tidak datang setelah jam janjinya lewat
+	if request.Status == model.AppointmentNoShow && time.Now().Before(appointment.StartAt) {
+		return nil, &ServiceError{Message: "an appointment can only be marked as no-show after it started", Code: 409}
+	}
+
+	if err := s.transition(appointment, request.Status, request.Reason, currentUserID); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Appointment %d is now %s (user %d)", id, request.Status, currentUserID)
+	return s.appointmentRepo.FindByID(id)
+}
+
+// transition moves an appointment to a new status if the state machine allows it.
+func (s *appointmentService) transition(appointment *model.Appointment, status, reason string, currentUserID uint) error {
+	from := appointment.Status
+	if !model.CanTransitionAppointment(from, status) {
+		return &ServiceError{Message: "appointment cannot change from " + from + " to " + status, Code: 409}
+	}
+
+	event := &model.AppointmentEvent{
+		Type:       model.AppointmentEventStatus,
+		FromStatus: from,
+		ToStatus:   status,
+		Reason:     reason,
+		UserID:     currentUserID,
+	}
+	if status == model.AppointmentCancelled {
+		event.Notifications = s.notifications.appointmentMessages(notification.TemplateAppointmentCancelled, appointment)
+	}
+	appointment.Status = status
+	// Reservasi ruang dan alat tetap; yang batal atau tidak datang otomatis melepasnya
+	appointment.Resources = nil
+	return s.update(appointment, from, event)
+}
+
+func (s *appointmentService) update(appointment *model.Appointment, expectedStatus string, event *model.AppointmentEvent) error {
+	updated, err := s.appointmentRepo.Update(appointment, expectedStatus, event)
+	if err != nil {
+		return mapAppointmentConflict(err)
+	}
+	if !updated {
+		return &ServiceError{Message: "appointment was changed by someone else, please reload", Code: 409}
+	}
+	return nil
+}
+
+// checkAvailable verifies that the slot lies within the therapist's working
+// hours, outside leave and holidays, and does not overlap another appointment.
+// Appointments in exclude are ignored, as they are the ones being moved. The
+// database constraint still guards against concurrent bookings.
+func (s *appointmentService) checkAvailable(therapistID uint, slot timeRange, exclude map[uint]bool) error {
+	if slot.Start.Before(time.Now()) {
+		return &ServiceError{Message: "appointments cannot be booked in the past", Code: 400}
+	}
+
+	local := slot.Start.In(s.policy.Location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.policy.Location)
+	free, err := s.freeRanges(therapistID, day)
+	if err != nil {
+		return err
+	}
+
+	working := false
+	for _, window := range free {
+		if window.contains(slot) {
+			working = true
+			break
+		}
+	}
+	if !working {
+		return &ServiceError{Message: "therapist is not available at this time", Code: 409}
+	}
+
+	busy, err := s.busyRanges(therapistID, slot, exclude)
+	if err != nil {
+		return err
+	}
+	if len(busy) > 0 {
+		return &ServiceError{Message: "therapist already has an appointment at this time", Code: 409}
+	}
+	return nil
+}
+
+// freeRanges returns the working hours of a therapist on a day minus leave and holidays.
+func (s *appointmentService) freeRanges(therapistID uint, day time.Time) ([]timeRange, error) {
+	workingHours, err := s.scheduleRepo.FindWorkingHoursByWeekday(therapistID, day.Weekday())
+	if err != nil {
+		return nil, err
+	}
+
+	end := day.AddDate(0, 0, 1)
+	exceptions, err := s.scheduleRepo.FindExceptions(therapistID, day, end)
+	if err != nil {
+		return nil, err
+	}
+
+	cuts := make([]timeRange, 0, len(exceptions))
+	for _, exception := range exceptions {
+		cuts = append(cuts, timeRange{Start: exception.StartAt, End: exception.EndAt})
+	}
+	return subtractRanges(workingRanges(day, workingHours), cuts), nil
+}
+
+func (s *appointmentService) busyRanges(therapistID uint, period timeRange, exclude map[uint]bool) ([]timeRange, error) {
+	appointments, err := s.appointmentRepo.FindBusy(therapistID, period.Start, period.End)
+	if err != nil {
+		return nil, err
+	}
+
+	busy := make([]timeRange, 0, len(appointments))
+	for _, appointment := range appointments {
+		if exclude[appointment.ID] {
+			continue
+		}
+		busy = append(busy, timeRange{Start: appointment.StartAt, End: appointment.EndAt})
+	}
+	return busy, nil
+}
+
+func (s *appointmentService) findAppointment(id uint) (*model.Appointment, error) {
+	appointment, err := s.appointmentRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if appointment == nil {
+		return nil, &ServiceError{Message: "appointment not found", Code: 404}
+	}
+	return appointment, nil
+}
+
+// checkTeknikTerapi verifies that the teknik terapi exists, if one is given.
+func (s *appointmentService) checkTeknikTerapi(id *uint) error {
+	if id == nil {
+		return nil
+	}
+	if _, err := s.masterRepo.FindTeknikTerapiByID(*id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &ServiceError{Message: "teknik terapi not found", Code: 400}
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *appointmentService) findLayananTerapi(id uint) (*model.LayananTerapi, error) {
+	layanan, err := s.masterRepo.FindLayananTerapiByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, &ServiceError{Message: "layanan terapi not found", Code: 400}
+		}
+		return nil, err
+	}
+	return layanan, nil
+}
+
+// cancelledBy returns who cancelled; unless the clinic says otherwise it was
+// the customer, which counts toward late cancellations.
+func cancelledBy(request model.CancelAppointmentRequest) string {
+	if request.CancelledBy == "" {
+		return model.CancelledByCustomer
+	}
+	return request.CancelledBy
+}
+
+func mapAppointmentConflict(err error) error {
+	if errors.Is(err, repository.ErrAppointmentConflict) {
+		return &ServiceError{Message: "therapist already has an appointment at this time", Code: 409}
+	}
+	if errors.Is(err, repository.ErrResourceConflict) {
+		return &ServiceError{Message: "a room or device this appointment needs was just booked by someone else, please pick another time", Code: 409}
+	}
+	return err
+}
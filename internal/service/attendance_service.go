@@ -0,0 +1,263 @@
+package service
+
+import (
+	"fmt"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/repository"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type attendanceService struct {
+	appointmentRepo  repository.AppointmentRepository
+	policyRepo       repository.BookingPolicyRepository
+	customerRepo     repository.CustomerRepository
+	lateCancelWindow time.Duration
+}
+
+func NewAttendanceService(
+	appointmentRepo repository.AppointmentRepository,
+	policyRepo repository.BookingPolicyRepository,
+	customerRepo repository.CustomerRepository,
+	lateCancelWindow time.Duration,
+) AttendanceService {
+	if lateCancelWindow <= 0 {
+		lateCancelWindow = 24 * time.Hour
+	}
+	return &attendanceService{
+		appointmentRepo:  appointmentRepo,
+		policyRepo:       policyRepo,
+		customerRepo:     customerRepo,
+		lateCancelWindow: lateCancelWindow,
+	}
+}
+
+func (s *attendanceService) GetPolicies() ([]model.BookingPolicy, error) {
+	return s.policyRepo.FindAll()
+}
+
+func (s *attendanceService) CreatePolicy(request model.BookingPolicyRequest) (*model.BookingPolicy, error) {
+	policy := &model.BookingPolicy{Active: true}
+	applyBookingPolicyRequest(policy, request)
+	if err := s.policyRepo.Create(policy); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Booking policy %d (%s) created", policy.ID, policy.Name)
+	return policy, nil
+}
+
+func (s *attendanceService) UpdatePolicy(id uint, request model.BookingPolicyRequest) (*model.BookingPolicy, error) {
+	policy, err := s.findPolicy(id)
+	if err != nil {
+		return nil, err
+	}
+
+	applyBookingPolicyRequest(policy, request)
+	if err := s.policyRepo.Update(policy); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Booking policy %d updated", id)
+	return policy, nil
+}
+
+func (s *attendanceService) DeletePolicy(id uint) error {
+	if _, err := s.findPolicy(id); err != nil {
+		return err
+	}
+
+	if err := s.policyRepo.Delete(id); err != nil {
+		return err
+	}
+
+	logrus.Infof("Booking policy %d deleted", id)
+	return nil
+}
+
+// GetAttendance returns the attendance of a customer over all their
+// appointments and the booking policies they currently fall under.
+func (s *attendanceService) GetAttendance(customerID string) (*model.AttendanceRecord, error) {
+	if _, err := findCustomer(s.customerRepo, customerID, 404); err != nil {
+		return nil, err
+	}
+
+	allTime, err := s.appointmentRepo.CountAttendance(customerID, time.Time{}, s.lateCancelWindow)
+	if err != nil {
+		return nil, err
+	}
+	restrictions, err := s.restrictions(customerID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.AttendanceRecord{CustomerID: customerID, AllTime: *allTime, Restrictions: restrictions}, nil
+}
+
+// GetFlaggedCustomers lists every customer under at least one active booking
+// policy, most restricted first.
+func (s *attendanceService) GetFlaggedCustomers() ([]model.FlaggedCustomer, error) {
+	policies, err := s.policyRepo.FindActive()
+	if err != nil {
+		return nil, err
+	}
+
+	// Satu query per jendela waktu, bukan per kebijakan
+	issues := make(map[int][]model.CustomerAttendance)
+	restrictions := make(map[string][]model.BookingRestriction)
+	for _, policy := range policies {
+		attendance, ok := issues[policy.WindowDays]
+		if !ok {
+			attendance, err = s.appointmentRepo.FindAttendanceIssues(windowStart(policy.WindowDays), s.lateCancelWindow)
+			if err != nil {
+				return nil, err
+			}
+			issues[policy.WindowDays] = attendance
+		}
+		for _, a := range attendance {
+			if restriction, ok := checkPolicy(policy, a.AttendanceCounts); ok {
+				restrictions[a.CustomerID] = append(restrictions[a.CustomerID], restriction)
+			}
+		}
+	}
+
+	flagged := make([]model.FlaggedCustomer, 0, len(restrictions))
+	if len(restrictions) == 0 {
+		return flagged, nil
+	}
+
+	ids := make([]string, 0, len(restrictions))
+	for id := range restrictions {
+		ids = append(ids, id)
+	}
+	customers, err := s.customerRepo.FindCustomersByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, customer := range customers {
+		flagged = append(flagged, model.FlaggedCustomer{Customer: customer, Restrictions: restrictions[customer.Id]})
+	}
+
+	sort.SliceStable(flagged, func(i, j int) bool {
+		if len(flagged[i].Restrictions) != len(flagged[j].Restrictions) {
+			return len(flagged[i].Restrictions) > len(flagged[j].Restrictions)
+		}
+		return flagged[i].Customer.CustomerName < flagged[j].Customer.CustomerName
+	})
+	return flagged, nil
+}
+
+// CheckBooking refuses a booking the active policies do not allow for this
+// customer, explaining which policy and which attendance caused it.
+func (s *attendanceService) CheckBooking(customerID string, booking model.BookingContext) error {
+	restrictions, err := s.restrictions(customerID)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range restrictions {
+		switch r.Action {
+		case model.PolicyActionBlockBooking:
+			return &ServiceError{
+				Message: "booking refused: " + describeRestriction(r) + ", new appointments are blocked",
+				Code:    403,
+			}
+		case model.PolicyActionBlockOnline:
+			if booking.Source == model.BookingSourceOnline {
+				return &ServiceError{
+					Message: "online booking refused: " + describeRestriction(r) + ", please book at the front desk",
+					Code:    403,
+				}
+			}
+		case model.PolicyActionRequireDeposit:
+			if booking.DepositReference == "" {
+				return &ServiceError{
+					Message: "deposit required: " + describeRestriction(r) + ", the front desk sets depositReference once the deposit is paid",
+					Code:    402,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// restrictions evaluates every active policy against the customer's recent
+// attendance. It never returns nil, so the JSON shows an empty list.
+func (s *attendanceService) restrictions(customerID string) ([]model.BookingRestriction, error) {
+	policies, err := s.policyRepo.FindActive()
+	if err != nil {
+		return nil, err
+	}
+
+	restrictions := []model.BookingRestriction{}
+	counts := make(map[int]*model.AttendanceCounts)
+	for _, policy := range policies {
+		c, ok := counts[policy.WindowDays]
+		if !ok {
+			c, err = s.appointmentRepo.CountAttendance(customerID, windowStart(policy.WindowDays), s.lateCancelWindow)
+			if err != nil {
+				return nil, err
+			}
+			counts[policy.WindowDays] = c
+		}
+		if restriction, ok := checkPolicy(policy, *c); ok {
+			restrictions = append(restrictions, restriction)
+		}
+	}
+	return restrictions, nil
+}
+
+func (s *attendanceService) findPolicy(id uint) (*model.BookingPolicy, error) {
+	policy, err := s.policyRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, &ServiceError{Message: "booking policy not found", Code: 404}
+	}
+	return policy, nil
+}
+
+func applyBookingPolicyRequest(policy *model.BookingPolicy, request model.BookingPolicyRequest) {
+	policy.Name = request.Name
+	policy.Metric = request.Metric
+	policy.Threshold = request.Threshold
+	policy.WindowDays = request.WindowDays
+	policy.Action = request.Action
+	if request.Active != nil {
+		policy.Active = *request.Active
+	}
+}
+
+// checkPolicy reports whether counts reach the policy's threshold.
+func checkPolicy(policy model.BookingPolicy, counts model.AttendanceCounts) (model.BookingRestriction, bool) {
+	count := counts.NoShow
+	if policy.Metric == model.PolicyMetricLateCancellation {
+		count = counts.LateCancelled
+	}
+	if count < int64(policy.Threshold) {
+		return model.BookingRestriction{}, false
+	}
+	return model.BookingRestriction{
+		PolicyID:   policy.ID,
+		PolicyName: policy.Name,
+		Metric:     policy.Metric,
+		Action:     policy.Action,
+		Count:      count,
+		Threshold:  policy.Threshold,
+		WindowDays: policy.WindowDays,
+	}, true
+}
+
+func describeRestriction(r model.BookingRestriction) string {
+	metric := "no-shows"
+	if r.Metric == model.PolicyMetricLateCancellation {
+		metric = "late cancellations"
+	}
+	return fmt.Sprintf("customer has %d %s in the last %d days (policy %q allows fewer than %d)", r.Count, metric, r.WindowDays, r.PolicyName, r.Threshold)
+}
+
+func windowStart(days int) time.Time {
+	return time.Now().AddDate(0, 0, -days)
+}
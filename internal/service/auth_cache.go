@@ -0,0 +1,119 @@
+package service
+
+import (
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/pkg/cache"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// AuthInvalidationChannel is the Postgres NOTIFY channel replicas use to tell
+// each other that cached auth state changed.
+const AuthInvalidationChannel = "auth_invalidation"
+
+// AuthCache keeps the state checked on every authenticated request in memory:
+// whether a token (by jti) is revoked, the session it belongs to, the
+// user with their role, roles with their permissions by name, and API keys
+// by hash. Every change is published so other replicas drop
+// their copy as well.
+type AuthCache struct {
+	revoked  *cache.Cache[string, bool]
+	sessions *cache.Cache[string, model.UserSession]
+	users    *cache.Cache[uint, model.User]
+	apiKeys  *cache.Cache[string, model.APIKey]
+	roles    *cache.Cache[string, model.Role]
+	userTTL  time.Duration
+	publish  func(payload string) error
+}
+
+// NewAuthCache creates a cache holding at most size entries per kind. publish
+// may be nil when running a single replica.
+func NewAuthCache(size int, userTTL time.Duration, publish func(payload string) error) *AuthCache {
+	return &AuthCache{
+		revoked:  cache.New[string, bool](size),
+		sessions: cache.New[string, model.UserSession](size),
+		users:    cache.New[uint, model.User](size),
+		apiKeys:  cache.New[string, model.APIKey](size),
+		roles:    cache.New[string, model.Role](size),
+		userTTL:  userTTL,
+		publish:  publish,
+	}
+}
+
+func (c *AuthCache) InvalidateToken(jti string) {
+	c.revoked.Delete(jti)
+	c.broadcast("token:" + jti)
+}
+
+func (c *AuthCache) InvalidateSession(id string) {
+	c.sessions.Delete(id)
+	c.broadcast("session:" + id)
+}
+
+func (c *AuthCache) InvalidateSessions(ids []string) {
+	for _, id := range ids {
+		c.InvalidateSession(id)
+	}
+}
+
+func (c *AuthCache) InvalidateUser(id uint) {
+	c.users.Delete(id)
+	c.broadcast("user:" + strconv.FormatUint(uint64(id), 10))
+}
+
+func (c *AuthCache) InvalidateAPIKey(keyHash string) {
+	c.apiKeys.Delete(keyHash)
+	c.broadcast("apikey:" + keyHash)
+}
+
+// InvalidateRole drops a role and every cached user, since users carry their role.
+func (c *AuthCache) InvalidateRole(name string) {
+	c.roles.Delete(name)
+	c.users.Purge()
+	c.broadcast("role:" + name)
+}
+
+// HandleInvalidation applies a payload received from another replica.
+func (c *AuthCache) HandleInvalidation(payload string) {
+	kind, key, ok := strings.Cut(payload, ":")
+	if !ok {
+		return
+	}
+
+	switch kind {
+	case "token":
+		c.revoked.Delete(key)
+	case "session":
+		c.sessions.Delete(key)
+	case "user":
+		if id, err := strconv.ParseUint(key, 10, 32); err == nil {
+			c.users.Delete(uint(id))
+		}
+	case "apikey":
+		c.apiKeys.Delete(key)
+	case "role":
+		c.roles.Delete(key)
+		c.users.Purge()
+	}
+}
+
+// Reset drops everything, used when invalidations may have been missed.
+func (c *AuthCache) Reset() {
+	c.revoked.Purge()
+	c.sessions.Purge()
+	c.users.Purge()
+	c.apiKeys.Purge()
+	c.roles.Purge()
+}
+
+func (c *AuthCache) broadcast(payload string) {
+	if c.publish == nil {
+		return
+	}
+	if err := c.publish(payload); err != nil {
+		logrus.Errorf("Failed to publish auth invalidation %s: %v", payload, err)
+	}
+}
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"errors"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/repository"
+	"sim-clinic-api/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// MFAPolicy controls TOTP two-factor authentication.
+type MFAPolicy struct {
+	Issuer            string
+	RequiredRoles     []string
+	ChallengeExpire   time.Duration
+	RecoveryCodeCount int
+	// ChallengeKeys menandatangani challenge token; kuncinya tidak dipublikasikan di JWKS
+	ChallengeKeys *utils.KeySet
+}
+
+func (p MFAPolicy) requiredFor(role string) bool {
+	for _, r := range p.RequiredRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *authService) VerifyMFA(request model.MFAVerifyRequest) (*model.LoginResponse, error) {
+	if request.Code == "" && request.RecoveryCode == "" {
+		return nil, &ServiceError{Message: "code or recovery_code is required", Code: 400}
+	}
+
+	user, err := s.userFromMFAToken(request.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MFASecret == "" {
+		return nil, &ServiceError{Message: "mfa enrollment has not been started", Code: 400}
+	}
+
+	now := time.Now()
+	windowStart := now.Add(-s.loginPolicy.AttemptWindow)
+
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		return nil, &ServiceError{Message: "account is locked", Code: 423}
+	}
+
+	if request.RecoveryCode != "" {
+		// Recovery code hanya berlaku setelah MFA aktif
+		if !user.MFAEnabled {
+			return nil, &ServiceError{Message: "invalid mfa code", Code: 401}
+		}
+		err = s.mfaRepo.UseRecoveryCode(user.ID, utils.HashToken(normalizeRecoveryCode(request.RecoveryCode)))
+	} else {
+		err = s.checkTOTP(user, request.Code, now)
+	}
+	if err != nil {
+		if _, ok := err.(*ServiceError); ok || errors.Is(err, repository.ErrMFACodeUsed) {
+			if err := s.registerFailedLogin(user, windowStart, now); err != nil {
+				return nil, err
+			}
+			return nil, &ServiceError{Message: "invalid mfa code", Code: 401}
+		}
+		return nil, err
+	}
+
+	// Verifikasi pertama sekaligus menyelesaikan pendaftaran MFA
+	var recoveryCodes []string
+	if !user.MFAEnabled {
+		if err := s.mfaRepo.Enable(user.ID); err != nil {
+			return nil, err
+		}
+		s.authCache.InvalidateUser(user.ID)
+		recoveryCodes, err = s.generateRecoveryCodes(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		logrus.Infof("User %s enabled MFA during login", user.Username)
+	}
+
+	if user.FailedLoginCount > 0 {
+		if err := s.userRepo.ResetFailedLogins(user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	response, err := s.issueTokens(user, request.IPAddress, request.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	response.RecoveryCodes = recoveryCodes
+
+	logrus.Infof("User logged in successfully with MFA: %s", user.Username)
+	return response, nil
+}
+
+func (s *authService) BeginMFAEnrollment(userID uint) (*model.MFAEnrollmentResponse, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, &ServiceError{Message: "user not found", Code: 404}
+		}
+		return nil, err
+	}
+	return s.beginMFAEnrollment(user)
+}
+
+func (s *authService) BeginMFAEnrollmentWithChallenge(mfaToken string) (*model.MFAEnrollmentResponse, error) {
+	user, err := s.userFromMFAToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.beginMFAEnrollment(user)
+}
+
+func (s *authService) ConfirmMFAEnrollment(userID uint, code string) (*model.MFARecoveryCodesResponse, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, &ServiceError{Message: "user not found", Code: 404}
+		}
+		return nil, err
+	}
+
+	if user.MFAEnabled {
+		return nil, &ServiceError{Message: "mfa is already enabled", Code: 400}
+	}
+	if user.MFASecret == "" {
+		return nil, &ServiceError{Message: "mfa enrollment has not been started", Code: 400}
+	}
+
+	if err := s.checkTOTP(user, code, time.Now()); err != nil {
+		return nil, mfaCodeError(err)
+	}
+
+	if err := s.mfaRepo.Enable(user.ID); err != nil {
+		return nil, err
+	}
+	s.authCache.InvalidateUser(user.ID)
+
+	codes, err := s.generateRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("User %s enabled MFA", user.Username)
+	return &model.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (s *authService) RegenerateRecoveryCodes(userID uint, code string) (*model.MFARecoveryCodesResponse, error) {
+	user, err := s.enabledMFAUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkTOTP(user, code, time.Now()); err != nil {
+		return nil, mfaCodeError(err)
+	}
+
+	codes, err := s.generateRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("User %s regenerated MFA recovery codes", user.Username)
+	return &model.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (s *authService) DisableMFA(userID uint, code string) error {
+	user, err := s.enabledMFAUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if s.mfaPolicy.requiredFor(user.Role.Name) {
+		return &ServiceError{Message: "mfa is required for your role", Code: 403}
+	}
+
+	if err := s.checkTOTP(user, code, time.Now()); err != nil {
+		return mfaCodeError(err)
+	}
+
+	if err := s.mfaRepo.Disable(user.ID); err != nil {
+		return err
+	}
+	s.authCache.InvalidateUser(user.ID)
+
+	logrus.Infof("User %s disabled MFA", user.Username)
+	return nil
+}
+
+func (s *authService) mfaChallengeResponse(user *model.User) (*model.LoginResponse, error) {
+	mfaToken, err := utils.GenerateMFAToken(user, s.mfaPolicy.ChallengeKeys, s.mfaPolicy.ChallengeExpire)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.LoginResponse{
+		Role:                  user.Role.Name,
+		UserID:                user.ID,
+		Username:              user.Username,
+		Email:                 user.Email,
+		MFARequired:           true,
+		MFAEnrollmentRequired: !user.MFAEnabled,
+		MFAToken:              mfaToken,
+	}, nil
+}
+
+func (s *authService) beginMFAEnrollment(user *model.User) (*model.MFAEnrollmentResponse, error) {
+	// Secret yang aktif tidak boleh ditimpa tanpa menonaktifkan MFA dulu
+	if user.MFAEnabled {
+		return nil, &ServiceError{Message: "mfa is already enabled", Code: 400}
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaRepo.SetSecret(user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	return &model.MFAEnrollmentResponse{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(s.mfaPolicy.Issuer, user.Username, secret),
+	}, nil
+}
+
+func (s *authService) userFromMFAToken(mfaToken string) (*model.User, error) {
+	claims, err := utils.ParseMFAToken(mfaToken, s.mfaPolicy.ChallengeKeys)
+	if err != nil {
+		return nil, &ServiceError{Message: "invalid or expired mfa token", Code: 401}
+	}
+
+	user, err := s.userRepo.FindByID(claims.UserID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, &ServiceError{Message: "invalid or expired mfa token", Code: 401}
+		}
+		return nil, err
+	}
+	// Password diganti setelah challenge dibuat
+	if claims.TokenVersion != user.TokenVersion {
+		return nil, &ServiceError{Message: "invalid or expired mfa token", Code: 401}
+	}
+	return user, nil
+}
+
+func (s *authService) enabledMFAUser(userID uint) (*model.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, &ServiceError{Message: "user not found", Code: 404}
+		}
+		return nil, err
+	}
+
+	if !user.MFAEnabled {
+		return nil, &ServiceError{Message: "mfa is not enabled", Code: 400}
+	}
+	return user, nil
+}
+
+// checkTOTP validates code for user and consumes its time step so it cannot be replayed.
+func (s *authService) checkTOTP(user *model.User, code string, now time.Time) error {
+	step, ok := utils.ValidateTOTP(user.MFASecret, code, now)
+	if !ok {
+		return &ServiceError{Message: "invalid mfa code", Code: 401}
+	}
+	return s.mfaRepo.MarkStepUsed(user.ID, step)
+}
+
+func (s *authService) generateRecoveryCodes(userID uint) ([]string, error) {
+	codes := make([]string, s.mfaPolicy.RecoveryCodeCount)
+	hashes := make([]string, s.mfaPolicy.RecoveryCodeCount)
+	for i := range codes {
+		code, err := utils.GenerateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = utils.HashToken(code)
+	}
+
+	if err := s.mfaRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
+
+func mfaCodeError(err error) error {
+	if errors.Is(err, repository.ErrMFACodeUsed) {
+		return &ServiceError{Message: "invalid mfa code", Code: 401}
+	}
+	return err
+}
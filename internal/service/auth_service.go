@@ -1,41 +1,76 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"sim-clinic-api/internal/model"
 	"sim-clinic-api/internal/repository"
 	"sim-clinic-api/internal/utils"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 type authService struct {
-	userRepo  repository.UserRepository
-	roleRepo  repository.RoleRepository
-	tokenRepo repository.TokenRepository
-	jwtSecret string
-	jwtExpire time.Duration
+	userRepo         repository.UserRepository
+	roleRepo         repository.RoleRepository
+	tokenRepo        repository.TokenRepository
+	loginAttemptRepo repository.LoginAttemptRepository
+	jwtKeys          *utils.KeySet
+	jwtExpire        time.Duration
+
+	refreshExpire time.Duration
+	loginPolicy   LoginPolicy
+
+	mfaRepo   repository.MFARepository
+	mfaPolicy MFAPolicy
+
+	sessionRepo repository.SessionRepository
+	authCache   *AuthCache
+	authorizer  *Authorizer
 }
 
+// sessionTouchInterval limits how often the last-seen time of a session is written.
+const sessionTouchInterval = time.Minute
+
 func NewAuthService(
 	userRepo repository.UserRepository,
 	roleRepo repository.RoleRepository,
 	tokenRepo repository.TokenRepository,
-	jwtSecret string,
+	loginAttemptRepo repository.LoginAttemptRepository,
+	jwtKeys *utils.KeySet,
 	jwtExpire time.Duration,
+	refreshExpire time.Duration,
+	loginPolicy LoginPolicy,
+	mfaRepo repository.MFARepository,
+	mfaPolicy MFAPolicy,
+	sessionRepo repository.SessionRepository,
+	authCache *AuthCache,
+	authorizer *Authorizer,
 ) AuthService {
 	return &authService{
-		userRepo:  userRepo,
-		roleRepo:  roleRepo,
-		tokenRepo: tokenRepo,
-		jwtSecret: jwtSecret,
-		jwtExpire: jwtExpire,
+		userRepo:         userRepo,
+		roleRepo:         roleRepo,
+		tokenRepo:        tokenRepo,
+		loginAttemptRepo: loginAttemptRepo,
+		jwtKeys:          jwtKeys,
+		jwtExpire:        jwtExpire,
+		refreshExpire:    refreshExpire,
+		loginPolicy:      loginPolicy,
+		mfaRepo:          mfaRepo,
+		mfaPolicy:        mfaPolicy,
+		sessionRepo:      sessionRepo,
+		authCache:        authCache,
+		authorizer:       authorizer,
 	}
 }
 
-func (s *authService) Register(request model.RegisterRequest) (*model.User, error) {
+// Register creates a user on behalf of currentUserRole, which must be allowed
+// to assign the requested role.
+func (s *authService) Register(request model.RegisterRequest, currentUserRole string) (*model.User, error) {
 	// Validate role exists
 	role, err := s.roleRepo.FindByID(request.RoleID)
 	if err != nil {
@@ -45,6 +80,13 @@ func (s *authService) Register(request model.RegisterRequest) (*model.User, erro
 		return nil, err
 	}
 
+	if !s.authorizer.CanAssignRole(currentUserRole, role) {
+		return nil, &ServiceError{
+			Message: "access denied: insufficient permissions",
+			Code:    403,
+		}
+	}
+
 	// Check if username exists
 	existingUser, _ := s.userRepo.FindByUsername(request.Username)
 	if existingUser != nil {
@@ -87,56 +129,161 @@ func (s *authService) Register(request model.RegisterRequest) (*model.User, erro
 }
 
 func (s *authService) Login(request model.LoginRequest) (*model.LoginResponse, error) {
+	now := time.Now()
+	windowStart := now.Add(-s.loginPolicy.AttemptWindow)
+
+	// Batasi percobaan gagal per alamat IP
+	if request.IPAddress != "" {
+		ipFailures, lastFailure, err := s.loginAttemptRepo.CountFailuresByIP(request.IPAddress, windowStart)
+		if err != nil {
+			return nil, err
+		}
+		if s.loginPolicy.IPMaxAttempts > 0 && ipFailures >= int64(s.loginPolicy.IPMaxAttempts) {
+			s.recordLoginAttempt(request, nil, false, model.LoginFailureIPBlocked)
+			return nil, &ServiceError{Message: "too many failed login attempts from this address, try again later", Code: 429}
+		}
+		if wait := s.loginPolicy.retryAfter(ipFailures, lastFailure, now); wait > 0 {
+			s.recordLoginAttempt(request, nil, false, model.LoginFailureThrottled)
+			return nil, tooManyAttemptsError(wait)
+		}
+	}
+
 	// Find user by username
 	user, err := s.userRepo.FindByUsername(request.Username)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
+			s.recordLoginAttempt(request, nil, false, model.LoginFailureUnknownUser)
 			return nil, &ServiceError{Message: "invalid credentials", Code: 401}
 		}
 		return nil, err
 	}
 
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		s.recordLoginAttempt(request, &user.ID, false, model.LoginFailureLocked)
+		return nil, &ServiceError{
+			Message: fmt.Sprintf("account is locked until %s", user.LockedUntil.Format(time.RFC3339)),
+			Code:    423,
+		}
+	}
+
+	// Percobaan gagal di luar window tidak dihitung lagi
+	if user.LastFailedLoginAt != nil && user.LastFailedLoginAt.After(windowStart) {
+		if wait := s.loginPolicy.retryAfter(int64(user.FailedLoginCount), user.LastFailedLoginAt, now); wait > 0 {
+			s.recordLoginAttempt(request, &user.ID, false, model.LoginFailureThrottled)
+			return nil, tooManyAttemptsError(wait)
+		}
+	}
+
 	// Check password
 	if !utils.CheckPasswordHash(request.Password, user.Password) {
+		s.recordLoginAttempt(request, &user.ID, false, model.LoginFailureInvalidPassword)
+		if err := s.registerFailedLogin(user, windowStart, now); err != nil {
+			return nil, err
+		}
 		return nil, &ServiceError{Message: "invalid credentials", Code: 401}
 	}
 
-	// Generate JWT token
-	token, err := utils.GenerateJWT(user, s.jwtSecret, s.jwtExpire)
-	if err != nil {
-		return nil, err
+	if user.FailedLoginCount > 0 || user.LockedUntil != nil {
+		if err := s.userRepo.ResetFailedLogins(user.ID); err != nil {
+			return nil, err
+		}
+	}
+	s.recordLoginAttempt(request, &user.ID, true, "")
+
+	// Akun dengan MFA harus menukar challenge token lewat /auth/mfa/verify
+	if user.MFAEnabled || s.mfaPolicy.requiredFor(user.Role.Name) {
+		return s.mfaChallengeResponse(user)
 	}
 
-	response := &model.LoginResponse{
-		AccessToken: token,
-		Role:        user.Role.Name,
-		UserID:      user.ID,
-		Username:    user.Username,
-		Email:       user.Email,
+	response, err := s.issueTokens(user, request.IPAddress, request.UserAgent)
+	if err != nil {
+		return nil, err
 	}
 
 	logrus.Infof("User logged in successfully: %s", user.Username)
 	return response, nil
 }
 
-func (s *authService) Logout(tokenString string, userID uint) error {
-	// Parse token untuk mendapatkan expiry time
-	token, err := jwt.ParseWithClaims(tokenString, &utils.Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(s.jwtSecret), nil
-	})
+func (s *authService) RefreshToken(request model.RefreshTokenRequest) (*model.LoginResponse, error) {
+	stored, err := s.tokenRepo.FindRefreshTokenByHash(utils.HashToken(request.RefreshToken))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, &ServiceError{Message: "invalid refresh token", Code: 401}
+		}
+		return nil, err
+	}
+
+	// Token yang sudah di-rotate dipakai lagi: anggap bocor, cabut seluruh family
+	if stored.RevokedAt != nil {
+		return nil, s.handleRefreshTokenReuse(stored)
+	}
 
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, &ServiceError{Message: "refresh token expired", Code: 401}
+	}
+
+	// Family refresh token adalah ID sesi
+	session, err := s.sessionRepo.FindByID(stored.FamilyID)
 	if err != nil {
-		return &ServiceError{Message: "invalid token", Code: 401}
+		if err == gorm.ErrRecordNotFound {
+			return nil, &ServiceError{Message: "invalid refresh token", Code: 401}
+		}
+		return nil, err
+	}
+	if !session.IsActive(time.Now()) {
+		return nil, &ServiceError{Message: "session has been revoked", Code: 401}
+	}
+
+	user, err := s.userRepo.FindByID(stored.UserID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, &ServiceError{Message: "invalid refresh token", Code: 401}
+		}
+		return nil, err
+	}
+
+	refreshToken, next, err := s.newRefreshToken(user.ID, stored.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokenRepo.RotateRefreshToken(stored.ID, next); err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenReused) {
+			return nil, s.handleRefreshTokenReuse(stored)
+		}
+		return nil, err
+	}
+
+	if err := s.sessionRepo.Extend(session.ID, next.ExpiresAt); err != nil {
+		return nil, err
+	}
+	// Sesi di cache masih membawa masa berlaku yang lama
+	s.authCache.InvalidateSession(session.ID)
+	if request.IPAddress != "" && request.IPAddress != session.IPAddress {
+		if err := s.sessionRepo.Touch(session.ID, time.Now(), request.IPAddress); err != nil {
+			return nil, err
+		}
 	}
 
-	claims, ok := token.Claims.(*utils.Claims)
-	if !ok || !token.Valid {
+	response, err := s.buildLoginResponse(user, session.ID, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Refresh token rotated for user %d", user.ID)
+	return response, nil
+}
+
+func (s *authService) Logout(tokenString, refreshToken string, userID uint) error {
+	// Parse token untuk mendapatkan expiry time
+	claims, err := utils.ParseToken(tokenString, s.jwtKeys)
+	if err != nil {
 		return &ServiceError{Message: "invalid token", Code: 401}
 	}
 
 	// Simpan token ke blacklist
 	blacklistedToken := &model.BlacklistedToken{
 		Token:     tokenString,
+		JTI:       claims.ID,
 		ExpiresAt: claims.ExpiresAt.Time,
 		UserID:    userID,
 		Reason:    "logout",
@@ -145,37 +292,140 @@ func (s *authService) Logout(tokenString string, userID uint) error {
 	if err := s.tokenRepo.BlacklistToken(blacklistedToken); err != nil {
 		return err
 	}
+	if claims.ID != "" {
+		s.authCache.InvalidateToken(claims.ID)
+	}
+
+	// Akhiri sesi token ini beserta refresh token-nya
+	if claims.SessionID != "" {
+		if err := s.sessionRepo.Revoke(claims.SessionID, "logout"); err != nil {
+			return err
+		}
+		s.authCache.InvalidateSession(claims.SessionID)
+	}
+
+	// Cabut juga refresh token yang dikirim jika berasal dari sesi lain
+	if refreshToken != "" {
+		stored, err := s.tokenRepo.FindRefreshTokenByHash(utils.HashToken(refreshToken))
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		if stored != nil && stored.UserID == userID {
+			if err := s.tokenRepo.RevokeRefreshTokenFamily(stored.FamilyID, "logout"); err != nil {
+				return err
+			}
+		}
+	}
 
 	logrus.Infof("User %d logged out successfully", userID)
 	return nil
 }
 
-func (s *authService) ValidateToken(tokenString string) (*model.User, error) {
-	// Cek jika token di blacklist
-	isBlacklisted, err := s.tokenRepo.IsTokenBlacklisted(tokenString)
+func (s *authService) ValidateToken(tokenString string) (*model.User, *utils.Claims, error) {
+	// Validate token
+	claims, err := utils.ParseToken(tokenString, s.jwtKeys)
 	if err != nil {
-		return nil, err
+		return nil, nil, &ServiceError{Message: "invalid token", Code: 401}
 	}
-	if isBlacklisted {
-		return nil, &ServiceError{Message: "token has been revoked", Code: 401}
+
+	// Challenge token MFA bukan access token
+	if claims.Purpose != "" {
+		return nil, nil, &ServiceError{Message: "invalid token", Code: 401}
 	}
 
-	// Validate token
-	token, err := jwt.ParseWithClaims(tokenString, &utils.Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(s.jwtSecret), nil
-	})
+	// Cek jika token di blacklist
+	if err := s.checkRevoked(tokenString, claims); err != nil {
+		return nil, nil, err
+	}
+
+	// Token tanpa sesi hanya berasal dari versi sebelumnya dan segera kedaluwarsa
+	if claims.SessionID != "" {
+		if err := s.checkSession(claims.SessionID, claims.ExpiresAt.Time); err != nil {
+			return nil, nil, err
+		}
+	}
 
+	user, err := s.cachedUser(claims.UserID)
 	if err != nil {
-		return nil, &ServiceError{Message: "invalid token", Code: 401}
+		return nil, nil, err
+	}
+
+	// Token yang terbit sebelum password diganti sudah dicabut. Dibandingkan
+	// lewat versi karena iat hanya presisi detik
+	if claims.TokenVersion != user.TokenVersion {
+		return nil, nil, &ServiceError{Message: "token has been revoked", Code: 401}
+	}
+
+	return user, claims, nil
+}
+
+// checkRevoked looks the token up in the blacklist, caching the answer until the token expires.
+func (s *authService) checkRevoked(tokenString string, claims *utils.Claims) error {
+	// Token lama tanpa jti dicek berdasarkan token utuh
+	if claims.ID == "" {
+		isBlacklisted, err := s.tokenRepo.IsTokenBlacklisted(tokenString)
+		if err != nil {
+			return err
+		}
+		if isBlacklisted {
+			return &ServiceError{Message: "token has been revoked", Code: 401}
+		}
+		return nil
+	}
+
+	revoked, ok := s.authCache.revoked.Get(claims.ID)
+	if !ok {
+		var err error
+		revoked, err = s.tokenRepo.IsTokenRevoked(claims.ID)
+		if err != nil {
+			return err
+		}
+		s.authCache.revoked.Set(claims.ID, revoked, time.Until(claims.ExpiresAt.Time))
+	}
+
+	if revoked {
+		return &ServiceError{Message: "token has been revoked", Code: 401}
+	}
+	return nil
+}
+
+// checkSession rejects tokens of revoked or expired sessions and records activity.
+func (s *authService) checkSession(sessionID string, tokenExpiresAt time.Time) error {
+	session, ok := s.authCache.sessions.Get(sessionID)
+	if !ok {
+		found, err := s.sessionRepo.FindByID(sessionID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return &ServiceError{Message: "session has been revoked", Code: 401}
+			}
+			return err
+		}
+		session = *found
+	}
+
+	now := time.Now()
+	if !session.IsActive(now) {
+		return &ServiceError{Message: "session has been revoked", Code: 401}
+	}
+
+	if now.Sub(session.LastSeenAt) > sessionTouchInterval {
+		if err := s.sessionRepo.Touch(session.ID, now, ""); err != nil {
+			logrus.Warnf("Failed to update session %s: %v", session.ID, err)
+		}
+		session.LastSeenAt = now
 	}
 
-	claims, ok := token.Claims.(*utils.Claims)
-	if !ok || !token.Valid {
-		return nil, &ServiceError{Message: "invalid token", Code: 401}
+	s.authCache.sessions.Set(sessionID, session, time.Until(tokenExpiresAt))
+	return nil
+}
+
+// cachedUser returns the user with their role, reading the database at most once per user cache TTL.
+func (s *authService) cachedUser(id uint) (*model.User, error) {
+	if user, ok := s.authCache.users.Get(id); ok {
+		return &user, nil
 	}
 
-	// Get user from database
-	user, err := s.userRepo.FindByID(claims.UserID)
+	user, err := s.userRepo.FindByID(id)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, &ServiceError{Message: "user not found", Code: 404}
@@ -183,9 +433,123 @@ func (s *authService) ValidateToken(tokenString string) (*model.User, error) {
 		return nil, err
 	}
 
+	s.authCache.users.Set(id, *user, s.authCache.userTTL)
 	return user, nil
 }
 
+// registerFailedLogin counts a failed password and locks the account once the
+// policy limit is reached.
+func (s *authService) registerFailedLogin(user *model.User, windowStart, now time.Time) error {
+	failures, err := s.userRepo.IncrementFailedLogin(user.ID, windowStart)
+	if err != nil {
+		return err
+	}
+
+	if s.loginPolicy.MaxAttempts > 0 && failures >= s.loginPolicy.MaxAttempts {
+		if err := s.userRepo.LockUser(user.ID, now.Add(s.loginPolicy.LockoutDuration)); err != nil {
+			return err
+		}
+		logrus.Warnf("User %s locked after %d failed login attempts", user.Username, failures)
+	}
+	return nil
+}
+
+// recordLoginAttempt stores the outcome of a login attempt for later investigation.
+// Failing to record must not block the login itself.
+func (s *authService) recordLoginAttempt(request model.LoginRequest, userID *uint, success bool, reason string) {
+	attempt := &model.LoginAttempt{
+		Username:  request.Username,
+		UserID:    userID,
+		IPAddress: request.IPAddress,
+		UserAgent: request.UserAgent,
+		Success:   success,
+		Reason:    reason,
+	}
+	if err := s.loginAttemptRepo.Create(attempt); err != nil {
+		logrus.Errorf("Failed to record login attempt for %s: %v", request.Username, err)
+	}
+}
+
+func tooManyAttemptsError(wait time.Duration) error {
+	return &ServiceError{
+		Message: fmt.Sprintf("too many failed login attempts, retry in %d seconds", int(math.Ceil(wait.Seconds()))),
+		Code:    429,
+	}
+}
+
+// issueTokens starts a new session with its own refresh token family and
+// returns the full login response.
+func (s *authService) issueTokens(user *model.User, ipAddress, userAgent string) (*model.LoginResponse, error) {
+	now := time.Now()
+	session := &model.UserSession{
+		ID:         uuid.New().String(),
+		UserID:     user.ID,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.refreshExpire),
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	refreshToken, stored, err := s.newRefreshToken(user.ID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokenRepo.CreateRefreshToken(stored); err != nil {
+		return nil, err
+	}
+
+	return s.buildLoginResponse(user, session.ID, refreshToken)
+}
+
+// newRefreshToken creates a refresh token in the given family and returns the
+// raw token for the client together with the record to persist.
+func (s *authService) newRefreshToken(userID uint, familyID string) (string, *model.RefreshToken, error) {
+	raw, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return raw, &model.RefreshToken{
+		TokenHash: utils.HashToken(raw),
+		FamilyID:  familyID,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(s.refreshExpire),
+	}, nil
+}
+
+func (s *authService) buildLoginResponse(user *model.User, sessionID, refreshToken string) (*model.LoginResponse, error) {
+	accessToken, err := utils.GenerateJWT(user, sessionID, s.jwtKeys, s.jwtExpire)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.LoginResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.jwtExpire.Seconds()),
+		Role:         user.Role.Name,
+		UserID:       user.ID,
+		Username:     user.Username,
+		Email:        user.Email,
+	}, nil
+}
+
+func (s *authService) handleRefreshTokenReuse(stored *model.RefreshToken) error {
+	logrus.Warnf("Refresh token reuse detected for user %d, revoking session %s", stored.UserID, stored.FamilyID)
+	if err := s.sessionRepo.Revoke(stored.FamilyID, "reuse_detected"); err != nil {
+		return err
+	}
+	s.authCache.InvalidateSession(stored.FamilyID)
+	return &ServiceError{Message: "refresh token reuse detected", Code: 401}
+}
+
+func (s *authService) JWKS() utils.JWKS {
+	return s.jwtKeys.JWKS()
+}
+
 type ServiceError struct {
 	Message string
 	Code    int
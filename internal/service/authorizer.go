@@ -0,0 +1,93 @@
+package service
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/repository"
+)
+
+// Authorizer answers every "may this role do that" question from the roles
+// and permissions stored in the database, so services never compare role
+// names themselves.
+type Authorizer struct {
+	roleRepo  repository.RoleRepository
+	authCache *AuthCache
+}
+
+func NewAuthorizer(roleRepo repository.RoleRepository, authCache *AuthCache) *Authorizer {
+	return &Authorizer{
+		roleRepo:  roleRepo,
+		authCache: authCache,
+	}
+}
+
+// Can reports whether roleName holds permission. Unknown roles hold nothing.
+func (a *Authorizer) Can(roleName, permission string) bool {
+	role, err := a.role(roleName)
+	if err != nil {
+		return false
+	}
+	return role.HasPermission(permission)
+}
+
+// Require returns a 403 ServiceError unless roleName holds permission.
+func (a *Authorizer) Require(roleName, permission string) error {
+	if !a.Can(roleName, permission) {
+		return &ServiceError{Message: "access denied: insufficient permissions", Code: 403}
+	}
+	return nil
+}
+
+// Level returns the level of roleName, or 0 for unknown roles.
+func (a *Authorizer) Level(roleName string) int {
+	role, err := a.role(roleName)
+	if err != nil {
+		return 0
+	}
+	return role.Level
+}
+
+// CanManageUser reports whether roleName may use permission on target.
+// Without user:manage_all the target's role must be below the actor's.
+func (a *Authorizer) CanManageUser(roleName, permission string, target *model.User) bool {
+	role, err := a.role(roleName)
+	if err != nil || !role.HasPermission(permission) {
+		return false
+	}
+	if role.HasPermission(model.PermUserManageAll) {
+		return true
+	}
+	return target.Role.Level < role.Level
+}
+
+// CanAssignRole reports whether roleName may give target role to a user or
+// define it: the role must be below the actor's own unless they hold
+// user:manage_all.
+func (a *Authorizer) CanAssignRole(roleName string, target *model.Role) bool {
+	role, err := a.role(roleName)
+	if err != nil {
+		return false
+	}
+	if role.HasPermission(model.PermUserManageAll) {
+		return true
+	}
+	return target.Level < role.Level
+}
+
+func (a *Authorizer) role(name string) (*model.Role, error) {
+	if role, ok := a.authCache.roles.Get(name); ok {
+		return &role, nil
+	}
+
+	role, err := a.roleRepo.FindByName(name)
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			logrus.Errorf("Failed to load role %s: %v", name, err)
+		}
+		return nil, err
+	}
+
+	a.authCache.roles.Set(name, *role, a.authCache.userTTL)
+	return role, nil
+}
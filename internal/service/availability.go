@@ -0,0 +1,108 @@
+package service
+
+import (
+	"sim-clinic-api/internal/model"
+	"sort"
+	"time"
+)
+
+// AppointmentPolicy controls how appointment slots are computed.
+type AppointmentPolicy struct {
+	// Location is the clinic's time zone; working hours are read in it.
+	Location *time.Location
+	// SlotStep is the distance between the start times of offered slots.
+	SlotStep time.Duration
+}
+
+// timeRange is the half-open period [Start, End).
+type timeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (r timeRange) overlaps(other timeRange) bool {
+	return r.Start.Before(other.End) && other.Start.Before(r.End)
+}
+
+func (r timeRange) contains(other timeRange) bool {
+	return !other.Start.Before(r.Start) && !other.End.After(r.End)
+}
+
+// subtractRanges removes every cut from the base ranges and returns what is
+// left, in order.
+func subtractRanges(base, cuts []timeRange) []timeRange {
+	result := append([]timeRange(nil), base...)
+	for _, cut := range cuts {
+		var next []timeRange
+		for _, r := range result {
+			if !r.overlaps(cut) {
+				next = append(next, r)
+				continue
+			}
+			if r.Start.Before(cut.Start) {
+				next = append(next, timeRange{Start: r.Start, End: cut.Start})
+			}
+			if cut.End.Before(r.End) {
+				next = append(next, timeRange{Start: cut.End, End: r.End})
+			}
+		}
+		result = next
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Start.Before(result[j].Start)
+	})
+	return result
+}
+
+// workingRanges places the working hours on a day. day must be midnight in
+// the clinic's time zone.
+func workingRanges(day time.Time, workingHours []model.WorkingHour) []timeRange {
+	ranges := make([]timeRange, 0, len(workingHours))
+	for _, wh := range workingHours {
+		if r, ok := clockRange(day, wh.StartTime, wh.EndTime); ok {
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
+
+// clockRange returns the period between two "HH:MM" times on day.
+func clockRange(day time.Time, startTime, endTime string) (timeRange, bool) {
+	start, errStart := time.Parse(model.ClockLayout, startTime)
+	end, errEnd := time.Parse(model.ClockLayout, endTime)
+	if errStart != nil || errEnd != nil {
+		return timeRange{}, false
+	}
+	return timeRange{
+		Start: time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, day.Location()),
+		End:   time.Date(day.Year(), day.Month(), day.Day(), end.Hour(), end.Minute(), 0, 0, day.Location()),
+	}, true
+}
+
+// freeSlots lists the periods of the given length that fit into the free
+// ranges without touching a busy range, starting every step and not before
+// notBefore.
+func freeSlots(free, busy []timeRange, length, step time.Duration, notBefore time.Time) []model.AppointmentSlot {
+	slots := []model.AppointmentSlot{}
+	for _, window := range free {
+		for start := window.Start; !start.Add(length).After(window.End); start = start.Add(step) {
+			slot := timeRange{Start: start, End: start.Add(length)}
+			if slot.Start.Before(notBefore) {
+				continue
+			}
+
+			taken := false
+			for _, b := range busy {
+				if slot.overlaps(b) {
+					taken = true
+					break
+				}
+			}
+			if !taken {
+				slots = append(slots, model.AppointmentSlot{StartAt: slot.Start, EndAt: slot.End})
+			}
+		}
+	}
+	return slots
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/repository"
+	"sim-clinic-api/internal/utils"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	calendarTokenPrefix        = "cal_"
+	calendarTokenDisplayLength = 10
+
+	// Feed memuat janji sejak kemarin sampai 90 hari ke depan
+	calendarFeedPast    = 24 * time.Hour
+	calendarFeedAhead   = 90 * 24 * time.Hour
+	calendarFeedRefresh = 30 * time.Minute
+	// Janji yang batal tetap dimuat sekian lama agar aplikasi kalender menghapusnya
+	calendarFeedCancelled = 14 * 24 * time.Hour
+)
+
+type calendarService struct {
+	tokenRepo       repository.CalendarTokenRepository
+	appointmentRepo repository.AppointmentRepository
+	userRepo        repository.UserRepository
+	masterRepo      repository.MasterDataRepository
+	resourceRepo    repository.ResourceRepository
+	authorizer      *Authorizer
+	clinicName      string
+}
+
+func NewCalendarService(
+	tokenRepo repository.CalendarTokenRepository,
+	appointmentRepo repository.AppointmentRepository,
+	userRepo repository.UserRepository,
+	masterRepo repository.MasterDataRepository,
+	resourceRepo repository.ResourceRepository,
+	authorizer *Authorizer,
+	clinicName string,
+) CalendarService {
+	return &calendarService{
+		tokenRepo:       tokenRepo,
+		appointmentRepo: appointmentRepo,
+		userRepo:        userRepo,
+		masterRepo:      masterRepo,
+		resourceRepo:    resourceRepo,
+		authorizer:      authorizer,
+		clinicName:      clinicName,
+	}
+}
+
+func (s *calendarService) GetToken(userID uint) (*model.CalendarToken, error) {
+	token, err := s.tokenRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, &ServiceError{Message: "no calendar token yet, generate one first", Code: 404}
+	}
+	return token, nil
+}
+
+// RegenerateToken issues a new calendar token for the user. Feed URLs built
+// with the previous token stop working. baseURL is where the API is reachable
+// from the user's calendar app.
+func (s *calendarService) RegenerateToken(userID uint, currentUserRole, baseURL string) (*model.CalendarTokenCreatedResponse, error) {
+	secret, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+	plainToken := calendarTokenPrefix + secret
+
+	now := time.Now()
+	token := &model.CalendarToken{
+		UserID:    userID,
+		Prefix:    plainToken[:calendarTokenDisplayLength],
+		TokenHash: utils.HashToken(plainToken),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.tokenRepo.Save(token); err != nil {
+		return nil, err
+	}
+
+	response := &model.CalendarTokenCreatedResponse{
+		CalendarToken: *token,
+		Token:         plainToken,
+		FeedURL:       fmt.Sprintf("%s/calendar/%s/appointments.ics", baseURL, plainToken),
+	}
+	if s.authorizer.Can(currentUserRole, model.PermCalendarAll) {
+		response.LayananTerapiFeedURL = fmt.Sprintf("%s/calendar/%s/layanan-terapi/{layananTerapiId}/appointments.ics", baseURL, plainToken)
+		response.ResourceFeedURL = fmt.Sprintf("%s/calendar/%s/resources/{resourceId}/appointments.ics", baseURL, plainToken)
+	}
+
+	logrus.Infof("User %d generated a new calendar token", userID)
+	return response, nil
+}
+
+func (s *calendarService) RevokeToken(userID uint) error {
+	deleted, err := s.tokenRepo.DeleteByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return &ServiceError{Message: "no calendar token to revoke", Code: 404}
+	}
+
+	logrus.Infof("User %d revoked their calendar token", userID)
+	return nil
+}
+
+// TherapistFeed renders the upcoming appointments of the token's owner.
+func (s *calendarService) TherapistFeed(plainToken string) ([]byte, error) {
+	user, err := s.resolveToken(plainToken, model.PermAppointmentRead)
+	if err != nil {
+		return nil, err
+	}
+
+	appointments, err := s.findAppointments(user.ID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]utils.ICalEvent, 0, len(appointments))
+	for i := range appointments {
+		a := &appointments[i]
+		event := calendarEvent(a)
+		event.Summary = layananName(a) + " - " + customerInitials(a)
+		events = append(events, event)
+	}
+
+	name := s.clinicName + " - " + displayName(user.Fullname, user.Username)
+	return utils.WriteICalendar(s.prodID(), name, calendarFeedRefresh, events), nil
+}
+
+// LayananTerapiFeed renders the upcoming appointments of one layanan terapi
+// across all therapists. The token's owner must be allowed to see them all.
+func (s *calendarService) LayananTerapiFeed(plainToken string, layananTerapiID uint) ([]byte, error) {
+	if _, err := s.resolveToken(plainToken, model.PermCalendarAll); err != nil {
+		return nil, err
+	}
+
+	layanan, err := s.masterRepo.FindLayananTerapiByID(layananTerapiID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &ServiceError{Message: "layanan terapi not found", Code: 404}
+		}
+		return nil, err
+	}
+
+	appointments, err := s.findAppointments(0, layananTerapiID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]utils.ICalEvent, 0, len(appointments))
+	for i := range appointments {
+		a := &appointments[i]
+		event := calendarEvent(a)
+		event.Summary = customerInitials(a)
+		if a.Therapist != nil {
+			event.Summary += " - " + displayName(a.Therapist.Fullname, a.Therapist.Username)
+		}
+		events = append(events, event)
+	}
+
+	return utils.WriteICalendar(s.prodID(), s.clinicName+" - "+layanan.Name, calendarFeedRefresh, events), nil
+}
+
+// ResourceFeed renders the upcoming appointments that reserved a room or
+// device. The token's owner must be allowed to see them all.
+func (s *calendarService) ResourceFeed(plainToken string, resourceID uint) ([]byte, error) {
+	if _, err := s.resolveToken(plainToken, model.PermCalendarAll); err != nil {
+		return nil, err
+	}
+
+	resource, err := s.resourceRepo.FindByID(resourceID)
+	if err != nil {
+		return nil, err
+	}
+	if resource == nil {
+		return nil, &ServiceError{Message: "resource not found", Code: 404}
+	}
+
+	appointments, err := s.findAppointments(0, 0, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]utils.ICalEvent, 0, len(appointments))
+	for i := range appointments {
+		a := &appointments[i]
+		event := calendarEvent(a)
+		event.Summary = layananName(a) + " - " + customerInitials(a)
+		if a.Therapist != nil {
+			event.Summary += " - " + displayName(a.Therapist.Fullname, a.Therapist.Username)
+		}
+		events = append(events, event)
+	}
+
+	return utils.WriteICalendar(s.prodID(), s.clinicName+" - "+resource.Name, calendarFeedRefresh, events), nil
+}
+
+// resolveToken returns the owner of a calendar token if they still exist and
+// hold permission. Unknown tokens get a 404 so they cannot be told apart from
+// wrong URLs.
+func (s *calendarService) resolveToken(plainToken, permission string) (*model.User, error) {
+	token, err := s.tokenRepo.FindByHash(utils.HashToken(plainToken))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, &ServiceError{Message: "calendar feed not found", Code: 404}
+	}
+
+	user, err := s.userRepo.FindByID(token.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &ServiceError{Message: "calendar feed not found", Code: 404}
+		}
+		return nil, err
+	}
+	if err := s.authorizer.Require(user.Role.Name, permission); err != nil {
+		return nil, err
+	}
+
+	// Cukup catat pemakaian terakhir sekali per interval; aplikasi kalender sering memuat ulang
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > calendarFeedRefresh {
+		if err := s.tokenRepo.TouchLastUsed(token.ID, now); err != nil {
+			logrus.Warnf("Failed to update calendar token %d: %v", token.ID, err)
+		}
+	}
+	return user, nil
+}
+
+func (s *calendarService) findAppointments(therapistID, layananTerapiID, resourceID uint) ([]model.Appointment, error) {
+	now := time.Now()
+	return s.appointmentRepo.FindForCalendar(now.Add(-calendarFeedPast), now.Add(calendarFeedAhead), now.Add(-calendarFeedCancelled), therapistID, layananTerapiID, resourceID)
+}
+
+func (s *calendarService) prodID() string {
+	return "-//" + s.clinicName + "//Appointments//EN"
+}
+
+// calendarEvent fills in everything but the summary. Feeds leave the clinic,
+// so they carry no notes, names or other patient details.
+func calendarEvent(a *model.Appointment) utils.ICalEvent {
+	return utils.ICalEvent{
+		UID:         fmt.Sprintf("appointment-%d@sim-clinic", a.ID),
+		Description: fmt.Sprintf("Appointment #%d (%s)", a.ID, a.Status),
+		Start:       a.StartAt,
+		End:         a.EndAt,
+		Modified:    a.UpdatedAt,
+		Cancelled:   a.Status == model.AppointmentCancelled,
+	}
+}
+
+// customerInitials returns the initials of the patient, e.g. "B.S." for Budi
+// Santoso, using at most three words of the name.
+func customerInitials(a *model.Appointment) string {
+	if a.Customer == nil {
+		return "?"
+	}
+
+	var initials strings.Builder
+	words := strings.Fields(a.Customer.CustomerName)
+	for i := 0; i < len(words) && i < 3; i++ {
+		r, _ := utf8.DecodeRuneInString(words[i])
+		if unicode.IsLetter(r) {
+			initials.WriteRune(unicode.ToUpper(r))
+			initials.WriteRune('.')
+		}
+	}
+	if initials.Len() == 0 {
+		return "?"
+	}
+	return initials.String()
+}
+
+func layananName(a *model.Appointment) string {
+	if a.LayananTerapi == nil {
+		return "Appointment"
+	}
+	return a.LayananTerapi.Name
+}
+
+func displayName(fullname, username string) string {
+	if fullname != "" {
+		return fullname
+	}
+	return username
+}
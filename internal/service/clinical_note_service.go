@@ -0,0 +1,200 @@
+package service
+
+import (
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+type clinicalNoteService struct {
+	clinicalNoteRepo     repository.ClinicalNoteRepository
+	treatmentSessionRepo repository.TreatmentSessionRepository
+	customerRepo         repository.CustomerRepository
+	authorizer           *Authorizer
+}
+
+func NewClinicalNoteService(
+	clinicalNoteRepo repository.ClinicalNoteRepository,
+	treatmentSessionRepo repository.TreatmentSessionRepository,
+	customerRepo repository.CustomerRepository,
+	authorizer *Authorizer,
+) ClinicalNoteService {
+	return &clinicalNoteService{
+		clinicalNoteRepo:     clinicalNoteRepo,
+		treatmentSessionRepo: treatmentSessionRepo,
+		customerRepo:         customerRepo,
+		authorizer:           authorizer,
+	}
+}
+
+func (s *clinicalNoteService) GetNote(customerID string, sessionID uint) (*model.ClinicalNote, error) {
+	if err := s.checkSession(customerID, sessionID); err != nil {
+		return nil, err
+	}
+	return s.findNote(sessionID)
+}
+
+func (s *clinicalNoteService) CreateNote(customerID string, sessionID uint, request model.ClinicalNoteRequest, currentUserID uint) (*model.ClinicalNote, error) {
+	if err := s.checkSession(customerID, sessionID); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.clinicalNoteRepo.FindBySession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, &ServiceError{Message: "treatment session already has a clinical note", Code: 409}
+	}
+
+	note := &model.ClinicalNote{
+		TreatmentSessionID: sessionID,
+		AuthorID:           currentUserID,
+		Subjective:         request.Subjective,
+		Objective:          request.Objective,
+		Assessment:         request.Assessment,
+		Plan:               request.Plan,
+	}
+	if err := s.clinicalNoteRepo.Create(note); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Clinical note %d written for treatment session %d by user %d", note.ID, sessionID, currentUserID)
+	return s.clinicalNoteRepo.FindBySession(sessionID)
+}
+
+func (s *clinicalNoteService) UpdateNote(customerID string, sessionID uint, request model.ClinicalNoteRequest, currentUserID uint) (*model.ClinicalNote, error) {
+	note, err := s.findEditableNote(customerID, sessionID, currentUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	note.Subjective = request.Subjective
+	note.Objective = request.Objective
+	note.Assessment = request.Assessment
+	note.Plan = request.Plan
+
+	updated, err := s.clinicalNoteRepo.Update(note)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, errNoteSigned()
+	}
+
+	logrus.Infof("Clinical note %d updated by user %d", note.ID, currentUserID)
+	return s.clinicalNoteRepo.FindBySession(sessionID)
+}
+
+func (s *clinicalNoteService) SignNote(customerID string, sessionID uint, currentUserID uint) (*model.ClinicalNote, error) {
+	note, err := s.findEditableNote(customerID, sessionID, currentUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	signed, err := s.clinicalNoteRepo.Sign(note.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !signed {
+		return nil, errNoteSigned()
+	}
+
+	logrus.Infof("Clinical note %d signed by user %d", note.ID, currentUserID)
+	return s.clinicalNoteRepo.FindBySession(sessionID)
+}
+
+func (s *clinicalNoteService) DeleteNote(customerID string, sessionID uint, currentUserID uint) error {
+	note, err := s.findEditableNote(customerID, sessionID, currentUserID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.clinicalNoteRepo.Delete(note.ID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errNoteSigned()
+	}
+
+	logrus.Infof("Clinical note %d deleted by user %d", note.ID, currentUserID)
+	return nil
+}
+
+// AddAddendum appends a correction to a signed note. The author may always
+// amend their own note; anyone else needs PermMedicalAmend.
+func (s *clinicalNoteService) AddAddendum(customerID string, sessionID uint, request model.ClinicalNoteAddendumRequest, currentUserID uint, currentUserRole string) (*model.ClinicalNote, error) {
+	if err := s.checkSession(customerID, sessionID); err != nil {
+		return nil, err
+	}
+
+	note, err := s.findNote(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !note.IsSigned() {
+		return nil, &ServiceError{Message: "clinical note is not signed yet, edit it instead", Code: 409}
+	}
+	if note.AuthorID != currentUserID {
+		if err := s.authorizer.Require(currentUserRole, model.PermMedicalAmend); err != nil {
+			return nil, err
+		}
+	}
+
+	addendum := &model.ClinicalNoteAddendum{
+		ClinicalNoteID: note.ID,
+		AuthorID:       currentUserID,
+		Content:        request.Content,
+	}
+	if err := s.clinicalNoteRepo.CreateAddendum(addendum); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Addendum %d added to clinical note %d by user %d", addendum.ID, note.ID, currentUserID)
+	return s.clinicalNoteRepo.FindBySession(sessionID)
+}
+
+// findEditableNote returns the note of a session if the current user wrote it
+// and it is not signed yet.
+func (s *clinicalNoteService) findEditableNote(customerID string, sessionID uint, currentUserID uint) (*model.ClinicalNote, error) {
+	if err := s.checkSession(customerID, sessionID); err != nil {
+		return nil, err
+	}
+
+	note, err := s.findNote(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if note.AuthorID != currentUserID {
+		return nil, &ServiceError{Message: "only the author can change a clinical note", Code: 403}
+	}
+	if note.IsSigned() {
+		return nil, errNoteSigned()
+	}
+	return note, nil
+}
+
+func (s *clinicalNoteService) findNote(sessionID uint) (*model.ClinicalNote, error) {
+	note, err := s.clinicalNoteRepo.FindBySession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if note == nil {
+		return nil, &ServiceError{Message: "clinical note not found", Code: 404}
+	}
+	return note, nil
+}
+
+func (s *clinicalNoteService) checkSession(customerID string, sessionID uint) error {
+	if _, err := findCustomer(s.customerRepo, customerID, 404); err != nil {
+		return err
+	}
+	_, err := findTreatmentSession(s.treatmentSessionRepo, customerID, sessionID)
+	return err
+}
+
+func errNoteSigned() error {
+	return &ServiceError{Message: "clinical note is signed and can only be amended with an addendum", Code: 409}
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"fmt"
+	"time"
+)
+
+// codeRegisterCounter is the counter name used for patient registration codes.
+const codeRegisterCounter = "code_register"
+
+// CodeRegisterFormat describes patient registration codes such as
+// RM-2026-10-000123.
+type CodeRegisterFormat struct {
+	// Prefix starts every code, e.g. "RM".
+	Prefix string
+	// Reset is "month" or "year": the numbering restarts at 1 every period.
+	// config.LoadConfig rejects any other value.
+	Reset string
+	// Digits is the minimum width of the zero-padded sequence number.
+	Digits int
+}
+
+// period returns the part of the code identifying the counter period of t.
+func (f CodeRegisterFormat) period(t time.Time) string {
+	if f.Reset == "year" {
+		return t.Format("2006")
+	}
+	return t.Format("2006-01")
+}
+
+func (f CodeRegisterFormat) format(period string, seq int64) string {
+	return fmt.Sprintf("%s-%s-%0*d", f.Prefix, period, f.Digits, seq)
+}
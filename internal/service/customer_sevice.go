@@ -1,25 +1,52 @@
 package service
 
 import (
+	"errors"
 	"sim-clinic-api/internal/model"
 	"sim-clinic-api/internal/repository"
+	"sim-clinic-api/internal/utils"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/cast"
+)
+
+const (
+	// maxCustomerPageSize caps the page size of the customer list.
+	maxCustomerPageSize = 100
+	// defaultDuplicateNameSimilarity is the trigram similarity above which two names in one city are reported.
+	defaultDuplicateNameSimilarity = 0.6
 )
 
 type customerService struct {
 	customerRepo repository.CustomerRepository
+	counterRepo  repository.CounterRepository
+	codeFormat   CodeRegisterFormat
+	location     *time.Location
 }
 
-func NewCustomerService(customerRepo repository.CustomerRepository) CustomerService {
+func NewCustomerService(
+	customerRepo repository.CustomerRepository,
+	counterRepo repository.CounterRepository,
+	codeFormat CodeRegisterFormat,
+	location *time.Location,
+) CustomerService {
 	return &customerService{
 		customerRepo: customerRepo,
+		counterRepo:  counterRepo,
+		codeFormat:   codeFormat,
+		location:     location,
 	}
 }
 
 func (s *customerService) CheckCustomer(phoneNumber string) (*[]model.Customer, error) {
-	cust, err := s.customerRepo.FindCustomerByPhoneNumber(phoneNumber)
+	normalized, err := normalizePhoneNumber(phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	cust, err := s.customerRepo.FindCustomerByPhoneNumber(normalized)
 	if err != nil {
 		return nil, err
 	}
@@ -27,36 +54,438 @@ func (s *customerService) CheckCustomer(phoneNumber string) (*[]model.Customer,
 }
 
 func (s *customerService) CreateCustomer(request model.Customer) (*model.Customer, error) {
+	phoneNumber, err := normalizePhoneNumber(request.PhoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	request.PhoneNumber = phoneNumber
+
 	// check if customer already exists by phone number
-	existing, _ := s.customerRepo.FindCustomerByPhoneNumber(request.PhoneNumber)
-	if existing != nil {
-		return nil, &ServiceError{
-			Message: "customer with this phone number already exists",
-			Code:    400,
-		}
+	if err := s.checkPhoneNumberAvailable(request.PhoneNumber, ""); err != nil {
+		return nil, err
+	}
+
+	codeRegister, err := s.nextCodeRegister(time.Now())
+	if err != nil {
+		return nil, err
 	}
 
 	request.Id = uuid.New().String()
+	request.CodeRegister = codeRegister
 	if err := s.customerRepo.CreateCustomer(&request); err != nil {
 		return nil, err
 	}
-	logrus.Infof("Customer saved: %s", request.CustomerName)
+	logrus.Infof("Customer saved: %s (%s)", request.CustomerName, request.CodeRegister)
 	return &request, nil
 }
 
-func (s *customerService) GetCustomer(request model.RequestPagination) (*[]model.Customer, error) {
-	if request.Page == "" {
-		request.Page = "1"
+func (s *customerService) GetCustomer(request model.CustomerListRequest) (*model.ResponsePagination, error) {
+	page := cast.ToInt(request.Page)
+	if page < 1 {
+		page = 1
+	}
+
+	limit := cast.ToInt(request.Limit)
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > maxCustomerPageSize {
+		limit = maxCustomerPageSize
+	}
+
+	// Pencarian "0812..." juga menemukan nomor yang tersimpan sebagai "+62812..."
+	if phoneNumber, err := utils.NormalizePhoneNumber(request.Search); err == nil {
+		request.SearchPhone = phoneNumber
+	}
+	// Tanggal dibaca sebagai hari di klinik; tanggal akhir ikut dihitung sampai akhir hari
+	if from, err := time.ParseInLocation(model.DateLayout, request.CreatedFrom, s.location); err == nil {
+		request.CreatedAfter = &from
+	}
+	if to, err := time.ParseInLocation(model.DateLayout, request.CreatedTo, s.location); err == nil {
+		before := to.AddDate(0, 0, 1)
+		request.CreatedBefore = &before
+	}
+
+	customers, total, err := s.customerRepo.FindCustomers(request, page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ResponsePagination{
+		Data:  customers,
+		Total: total,
+		Page:  page,
+		Limit: limit,
+	}, nil
+}
+
+func (s *customerService) GetCustomerByID(id string) (*model.Customer, error) {
+	customer, err := s.customerRepo.FindCustomerByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		return nil, &ServiceError{Message: "customer not found", Code: 404}
+	}
+	return customer, nil
+}
+
+func (s *customerService) UpdateCustomer(id string, request model.UpdateCustomerRequest) (*model.Customer, error) {
+	customer, err := s.GetCustomerByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	phoneNumber, err := normalizePhoneNumber(request.PhoneNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	if phoneNumber != customer.PhoneNumber {
+		if err := s.checkPhoneNumberAvailable(phoneNumber, id); err != nil {
+			return nil, err
+		}
+	}
+
+	customer.CustomerName = request.CustomerName
+	customer.PhoneNumber = phoneNumber
+	customer.CustomerAddress = request.CustomerAddress
+	customer.Gender = request.Gender
+	customer.InformedConsent = request.InformedConsent
+	customer.SourceTerapistInfo = request.SourceTerapistInfo
+	customer.City = request.City
+
+	if err := s.customerRepo.UpdateCustomer(customer); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Customer updated: %s (%s)", customer.CustomerName, customer.Id)
+	return customer, nil
+}
+
+// UpdateNotificationPreferences sets whether a customer receives reminders
+// and other messages, and in which language.
+func (s *customerService) UpdateNotificationPreferences(id string, request model.NotificationPreferencesRequest) (*model.Customer, error) {
+	customer, err := s.GetCustomerByID(id)
+	if err != nil {
+		return nil, err
 	}
 
-	if request.Limit == "" {
-		request.Limit = "10"
+	customer.NotificationOptOut = request.OptOut
+	customer.PreferredLanguage = request.Language
+	if err := s.customerRepo.UpdateCustomer(customer); err != nil {
+		return nil, err
 	}
 
-	customers, err := s.customerRepo.FindCustomers(request)
+	logrus.Infof("Customer %s notification preferences updated (opt-out: %t)", id, request.OptOut)
+	return customer, nil
+}
+
+func (s *customerService) DeleteCustomer(id string) error {
+	if _, err := s.GetCustomerByID(id); err != nil {
+		return err
+	}
+
+	if err := s.customerRepo.DeleteCustomer(id); err != nil {
+		return err
+	}
+
+	logrus.Infof("Customer deleted: %s", id)
+	return nil
+}
+
+func (s *customerService) RestoreCustomer(id string) (*model.Customer, error) {
+	customer, err := s.customerRepo.FindDeletedCustomerByID(id)
 	if err != nil {
 		return nil, err
 	}
+	if customer == nil {
+		return nil, &ServiceError{Message: "deleted customer not found", Code: 404}
+	}
+
+	if customer.MergedIntoID != nil {
+		return nil, &ServiceError{Message: "customer was merged into " + *customer.MergedIntoID + " and cannot be restored", Code: 409}
+	}
+
+	// Nomor HP bisa saja sudah dipakai customer baru selama data ini terhapus
+	if err := s.checkPhoneNumberAvailable(customer.PhoneNumber, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.customerRepo.RestoreCustomer(id); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Customer restored: %s (%s)", customer.CustomerName, id)
+	return s.GetCustomerByID(id)
+}
 
-	return customers, nil
+// MergeCustomers folds the given customers into the survivor. With DryRun set
+// it only reports what would be moved.
+func (s *customerService) MergeCustomers(request model.MergeCustomerRequest, performedBy uint) (*model.CustomerMergeResult, error) {
+	survivor, err := s.GetCustomerByID(request.SurvivorID)
+	if err != nil {
+		return nil, err
+	}
+
+	merged, err := s.customerRepo.FindCustomersByIDs(request.MergedIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(merged) != len(request.MergedIDs) {
+		found := make(map[string]bool, len(merged))
+		for _, customer := range merged {
+			found[customer.Id] = true
+		}
+		for _, id := range request.MergedIDs {
+			if !found[id] {
+				return nil, &ServiceError{Message: "customer not found: " + id, Code: 404}
+			}
+		}
+	}
+
+	// Kode register dan nomor HP lama disimpan sebagai alias survivor
+	aliases := []model.CustomerAlias{}
+	seen := map[string]bool{
+		model.CustomerAliasCodeRegister + ":" + survivor.CodeRegister: true,
+		model.CustomerAliasPhone + ":" + survivor.PhoneNumber:         true,
+	}
+	addAlias := func(kind, value, mergedFromID string) {
+		if value == "" || seen[kind+":"+value] {
+			return
+		}
+		seen[kind+":"+value] = true
+		aliases = append(aliases, model.CustomerAlias{
+			Id:           uuid.New().String(),
+			CustomerID:   survivor.Id,
+			Kind:         kind,
+			Value:        value,
+			MergedFromID: mergedFromID,
+		})
+	}
+	for _, customer := range merged {
+		addAlias(model.CustomerAliasCodeRegister, customer.CodeRegister, customer.Id)
+		addAlias(model.CustomerAliasPhone, customer.PhoneNumber, customer.Id)
+	}
+
+	result := &model.CustomerMergeResult{
+		DryRun:   request.DryRun,
+		Survivor: *survivor,
+		Merged:   merged,
+		Aliases:  aliases,
+	}
+
+	if request.DryRun {
+		result.MovedRecords, err = s.customerRepo.CountCustomerReferences(request.MergedIDs)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	entry := &model.CustomerMergeLog{
+		Id:          uuid.New().String(),
+		SurvivorID:  survivor.Id,
+		MergedIDs:   request.MergedIDs,
+		Snapshot:    merged,
+		PerformedBy: performedBy,
+	}
+	result.MovedRecords, err = s.customerRepo.MergeCustomers(survivor.Id, request.MergedIDs, aliases, entry)
+	if err != nil {
+		if errors.Is(err, repository.ErrCustomerMergeConflict) {
+			return nil, &ServiceError{Message: "customers changed during merge, please review and try again", Code: 409}
+		}
+		return nil, err
+	}
+	result.LogID = entry.Id
+
+	logrus.WithFields(logrus.Fields{
+		"survivor":     survivor.Id,
+		"merged":       request.MergedIDs,
+		"performed_by": performedBy,
+		"moved":        result.MovedRecords,
+	}).Info("Customers merged")
+	return result, nil
+}
+
+func (s *customerService) FindDuplicates(request model.CustomerDuplicatesRequest) ([]model.CustomerDuplicateGroup, error) {
+	if request.MinSimilarity == 0 {
+		request.MinSimilarity = defaultDuplicateNameSimilarity
+	}
+	if request.Limit == 0 {
+		request.Limit = 50
+	}
+
+	groups := []model.CustomerDuplicateGroup{}
+
+	// Nomor HP yang sama setelah normalisasi
+	phoneNumbers, err := s.customerRepo.FindDuplicatePhoneNumbers(request.Limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(phoneNumbers) > 0 {
+		customers, err := s.customerRepo.FindCustomersByPhoneNumbers(phoneNumbers)
+		if err != nil {
+			return nil, err
+		}
+
+		byPhone := make(map[string][]model.Customer, len(phoneNumbers))
+		for _, customer := range customers {
+			byPhone[customer.PhoneNumber] = append(byPhone[customer.PhoneNumber], customer)
+		}
+		for _, phoneNumber := range phoneNumbers {
+			groups = append(groups, model.CustomerDuplicateGroup{
+				Reason:     model.DuplicateReasonPhone,
+				Similarity: 1,
+				Customers:  byPhone[phoneNumber],
+			})
+		}
+	}
+
+	// Nama mirip di kota yang sama
+	remaining := request.Limit - len(groups)
+	if remaining <= 0 {
+		return groups, nil
+	}
+
+	pairs, err := s.customerRepo.FindSimilarCustomerPairs(request.MinSimilarity, remaining)
+	if err != nil {
+		return nil, err
+	}
+	if len(pairs) == 0 {
+		return groups, nil
+	}
+
+	ids := make([]string, 0, len(pairs)*2)
+	for _, pair := range pairs {
+		ids = append(ids, pair.FirstID, pair.SecondID)
+	}
+	customers, err := s.customerRepo.FindCustomersByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]model.Customer, len(customers))
+	for _, customer := range customers {
+		byID[customer.Id] = customer
+	}
+	for _, pair := range pairs {
+		groups = append(groups, model.CustomerDuplicateGroup{
+			Reason:     model.DuplicateReasonNameCity,
+			Similarity: pair.Similarity,
+			Customers:  []model.Customer{byID[pair.FirstID], byID[pair.SecondID]},
+		})
+	}
+
+	return groups, nil
+}
+
+// NormalizePhoneNumbers rewrites every stored phone number to E.164. Numbers
+// that cannot be parsed are left untouched and reported, as are numbers that
+// turn out to be shared by several customers.
+func (s *customerService) NormalizePhoneNumbers(dryRun bool) (*model.PhoneNormalizationResult, error) {
+	result := &model.PhoneNormalizationResult{
+		Invalid:   map[string]string{},
+		Duplicate: map[string]int{},
+	}
+	seen := map[string]int{}
+
+	err := s.customerRepo.FindCustomersInBatches(500, func(customers []model.Customer) error {
+		for _, customer := range customers {
+			result.Checked++
+
+			phoneNumber, err := utils.NormalizePhoneNumber(customer.PhoneNumber)
+			if err != nil {
+				result.Invalid[customer.Id] = customer.PhoneNumber
+				continue
+			}
+			if !customer.DeletedAt.Valid {
+				seen[phoneNumber]++
+			}
+			if phoneNumber == customer.PhoneNumber {
+				continue
+			}
+
+			result.Updated++
+			if dryRun {
+				continue
+			}
+			if err := s.customerRepo.UpdatePhoneNumber(customer.Id, phoneNumber); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for phoneNumber, count := range seen {
+		if count > 1 {
+			result.Duplicate[phoneNumber] = count
+		}
+	}
+	return result, nil
+}
+
+// BackfillCodeRegisters gives a registration code to customers created before
+// codes were generated, numbered by creation date.
+func (s *customerService) BackfillCodeRegisters() error {
+	customers, err := s.customerRepo.FindCustomersWithoutCodeRegister()
+	if err != nil {
+		return err
+	}
+
+	for _, customer := range customers {
+		codeRegister, err := s.nextCodeRegister(customer.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if err := s.customerRepo.SetCodeRegister(customer.Id, codeRegister); err != nil {
+			return err
+		}
+	}
+
+	if len(customers) > 0 {
+		logrus.Infof("Assigned registration codes to %d existing customers", len(customers))
+	}
+	return nil
+}
+
+// nextCodeRegister draws the next number of the period containing t. A number
+// drawn for an insert that then fails is skipped, never reused.
+func (s *customerService) nextCodeRegister(t time.Time) (string, error) {
+	period := s.codeFormat.period(t)
+	seq, err := s.counterRepo.Next(codeRegisterCounter, period)
+	if err != nil {
+		return "", err
+	}
+	return s.codeFormat.format(period, seq), nil
+}
+
+// normalizePhoneNumber wraps utils.NormalizePhoneNumber with a client error.
+func normalizePhoneNumber(phoneNumber string) (string, error) {
+	normalized, err := utils.NormalizePhoneNumber(phoneNumber)
+	if err != nil {
+		return "", &ServiceError{Message: "invalid phone number: " + phoneNumber, Code: 400}
+	}
+	return normalized, nil
+}
+
+// checkPhoneNumberAvailable fails if an active customer other than excludeID uses phoneNumber.
+func (s *customerService) checkPhoneNumberAvailable(phoneNumber, excludeID string) error {
+	existing, err := s.customerRepo.FindCustomerByPhoneNumber(phoneNumber)
+	if err != nil {
+		return err
+	}
+
+	for _, customer := range *existing {
+		if customer.Id != excludeID {
+			return &ServiceError{
+				Message: "customer with this phone number already exists",
+				Code:    400,
+			}
+		}
+	}
+	return nil
 }
@@ -1,19 +1,58 @@
 package service
 
-import "sim-clinic-api/internal/model"
+import (
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/utils"
+)
 
 type AuthService interface {
-	Register(request model.RegisterRequest) (*model.User, error)
+	Register(request model.RegisterRequest, currentUserRole string) (*model.User, error)
 	Login(request model.LoginRequest) (*model.LoginResponse, error)
-	Logout(tokenString string, userID uint) error
-	ValidateToken(tokenString string) (*model.User, error)
+	RefreshToken(request model.RefreshTokenRequest) (*model.LoginResponse, error)
+	Logout(tokenString, refreshToken string, userID uint) error
+	ValidateToken(tokenString string) (*model.User, *utils.Claims, error)
+	JWKS() utils.JWKS
+
+	// MFA
+	VerifyMFA(request model.MFAVerifyRequest) (*model.LoginResponse, error)
+	BeginMFAEnrollment(userID uint) (*model.MFAEnrollmentResponse, error)
+	BeginMFAEnrollmentWithChallenge(mfaToken string) (*model.MFAEnrollmentResponse, error)
+	ConfirmMFAEnrollment(userID uint, code string) (*model.MFARecoveryCodesResponse, error)
+	RegenerateRecoveryCodes(userID uint, code string) (*model.MFARecoveryCodesResponse, error)
+	DisableMFA(userID uint, code string) error
 }
 
 type UserService interface {
 	GetAllUsers(currentUserRole, page, limit, search string) ([]model.User, int64, error)
-	GetUserByID(id uint, currentUserRole string) (*model.User, error)
+	GetUserByID(id uint, currentUserRole string, currentUserID uint) (*model.User, error)
 	UpdateUser(id uint, request model.UpdateUserRequest, currentUserRole string, currentUserID uint) (*model.User, error)
 	DeleteUser(id uint, currentUserRole string, currentUserID uint) error
+	ChangePassword(userID uint, request model.ChangePasswordRequest) error
+	RequestPasswordReset(id uint, currentUserRole string, currentUserID uint) error
+	ResetPassword(request model.ResetPasswordRequest) error
+	UnlockUser(id uint, currentUserRole string, currentUserID uint) error
+
+	// Sessions
+	GetMySessions(userID uint, currentSessionID string) ([]model.UserSession, error)
+	RevokeMySession(userID uint, sessionID string) error
+	RevokeAllMySessions(userID uint) error
+	RevokeUserSessions(id uint, currentUserRole string, currentUserID uint) error
+}
+
+type RoleService interface {
+	CreateRole(request model.RoleRequest, currentUserRole string) (*model.Role, error)
+	GetRoles(currentUserRole string) ([]model.Role, error)
+	GetRoleByID(id uint, currentUserRole string) (*model.Role, error)
+	UpdateRole(id uint, request model.RoleRequest, currentUserRole string) (*model.Role, error)
+	DeleteRole(id uint, currentUserRole string) error
+	GetPermissions(currentUserRole string) ([]model.Permission, error)
+}
+
+type APIKeyService interface {
+	CreateAPIKey(request model.CreateAPIKeyRequest, currentUserRole string, currentUserID uint) (*model.APIKeyCreatedResponse, error)
+	GetAPIKeys(currentUserRole string) ([]model.APIKey, error)
+	RevokeAPIKey(id uint, currentUserRole string, currentUserID uint) error
+	ValidateAPIKey(key, ipAddress string) (*model.APIKey, error)
 }
 
 type MasterDataService interface {
@@ -27,7 +66,7 @@ type MasterDataService interface {
 	GetAllRiwayatPenyakit() ([]model.RiwayatPenyakit, error)
 	GetRiwayatPenyakitByID(id uint) (*model.RiwayatPenyakit, error)
 	UpdateRiwayatPenyakit(id uint, request model.RiwayatPenyakitRequest) (*model.RiwayatPenyakit, error)
-	DeleteRiwayatPenyakit(id uint) error
+	DeleteRiwayatPenyakit(id uint, replacementID uint) error
 
 	CreateTeknikTerapi(request model.TeknikTerapiRequest) (*model.TeknikTerapi, error)
 	GetAllTeknikTerapi() ([]model.TeknikTerapi, error)
@@ -38,6 +77,111 @@ type MasterDataService interface {
 
 type CustomerService interface {
 	CreateCustomer(request model.Customer) (*model.Customer, error)
-	GetCustomer(requst model.RequestPagination) (*[]model.Customer, error)
+	GetCustomer(request model.CustomerListRequest) (*model.ResponsePagination, error)
 	CheckCustomer(phoneNumber string) (*[]model.Customer, error)
+	GetCustomerByID(id string) (*model.Customer, error)
+	UpdateCustomer(id string, request model.UpdateCustomerRequest) (*model.Customer, error)
+	UpdateNotificationPreferences(id string, request model.NotificationPreferencesRequest) (*model.Customer, error)
+	DeleteCustomer(id string) error
+	RestoreCustomer(id string) (*model.Customer, error)
+	BackfillCodeRegisters() error
+	FindDuplicates(request model.CustomerDuplicatesRequest) ([]model.CustomerDuplicateGroup, error)
+	NormalizePhoneNumbers(dryRun bool) (*model.PhoneNormalizationResult, error)
+	MergeCustomers(request model.MergeCustomerRequest, performedBy uint) (*model.CustomerMergeResult, error)
+}
+
+type MedicalHistoryService interface {
+	GetMedicalHistory(customerID string) ([]model.CustomerMedicalHistory, error)
+	CreateMedicalHistory(customerID string, request model.MedicalHistoryRequest, recordedBy uint) (*model.CustomerMedicalHistory, error)
+	UpdateMedicalHistory(customerID string, id uint, request model.MedicalHistoryRequest, recordedBy uint) (*model.CustomerMedicalHistory, error)
+	DeleteMedicalHistory(customerID string, id uint) error
+}
+
+type TreatmentSessionService interface {
+	GetTreatmentSessions(customerID string, request model.TimelineRequest) ([]model.TreatmentSession, error)
+	GetTreatmentSession(customerID string, id uint) (*model.TreatmentSession, error)
+	CreateTreatmentSession(customerID string, request model.TreatmentSessionRequest, recordedBy uint) (*model.TreatmentSession, error)
+	UpdateTreatmentSession(customerID string, id uint, request model.TreatmentSessionRequest, recordedBy uint) (*model.TreatmentSession, error)
+	DeleteTreatmentSession(customerID string, id uint) error
+	GetTimeline(customerID string, request model.TimelineRequest) ([]model.TimelineEntry, error)
+}
+
+type ClinicalNoteService interface {
+	GetNote(customerID string, sessionID uint) (*model.ClinicalNote, error)
+	CreateNote(customerID string, sessionID uint, request model.ClinicalNoteRequest, currentUserID uint) (*model.ClinicalNote, error)
+	UpdateNote(customerID string, sessionID uint, request model.ClinicalNoteRequest, currentUserID uint) (*model.ClinicalNote, error)
+	SignNote(customerID string, sessionID uint, currentUserID uint) (*model.ClinicalNote, error)
+	DeleteNote(customerID string, sessionID uint, currentUserID uint) error
+	AddAddendum(customerID string, sessionID uint, request model.ClinicalNoteAddendumRequest, currentUserID uint, currentUserRole string) (*model.ClinicalNote, error)
+}
+
+type ScheduleService interface {
+	GetWorkingHours(therapistID uint) ([]model.WorkingHour, error)
+	CreateWorkingHour(request model.WorkingHourRequest) (*model.WorkingHour, error)
+	UpdateWorkingHour(id uint, request model.WorkingHourRequest) (*model.WorkingHour, error)
+	DeleteWorkingHour(id uint) error
+	GetExceptions(request model.ScheduleListRequest) ([]model.ScheduleException, error)
+	CreateException(request model.ScheduleExceptionRequest, currentUserID uint) (*model.ScheduleException, error)
+	DeleteException(id uint) error
+}
+
+type AppointmentService interface {
+	GetSlots(request model.AppointmentSlotRequest) ([]model.AppointmentSlot, error)
+	GetAppointments(request model.AppointmentListRequest) ([]model.Appointment, error)
+	GetAppointment(id uint) (*model.AppointmentDetail, error)
+	CreateAppointment(request model.CreateAppointmentRequest, currentUserID uint, currentUserRole string) (*model.Appointment, error)
+	RescheduleAppointment(id uint, request model.RescheduleAppointmentRequest, currentUserID uint) (*model.Appointment, error)
+	CancelAppointment(id uint, request model.CancelAppointmentRequest, currentUserID uint) (*model.Appointment, error)
+	UpdateAppointmentStatus(id uint, request model.AppointmentStatusRequest, currentUserID uint) (*model.Appointment, error)
+	PreviewSeries(request model.CreateAppointmentSeriesRequest) (*model.AppointmentSeriesPreview, error)
+	CreateSeries(request model.CreateAppointmentSeriesRequest, currentUserID uint, currentUserRole string) (*model.AppointmentSeries, error)
+	GetSeries(id uint) (*model.AppointmentSeries, error)
+}
+
+type AttendanceService interface {
+	GetPolicies() ([]model.BookingPolicy, error)
+	CreatePolicy(request model.BookingPolicyRequest) (*model.BookingPolicy, error)
+	UpdatePolicy(id uint, request model.BookingPolicyRequest) (*model.BookingPolicy, error)
+	DeletePolicy(id uint) error
+	GetAttendance(customerID string) (*model.AttendanceRecord, error)
+	GetFlaggedCustomers() ([]model.FlaggedCustomer, error)
+	CheckBooking(customerID string, booking model.BookingContext) error
+}
+
+type CalendarService interface {
+	GetToken(userID uint) (*model.CalendarToken, error)
+	RegenerateToken(userID uint, currentUserRole, baseURL string) (*model.CalendarTokenCreatedResponse, error)
+	RevokeToken(userID uint) error
+	TherapistFeed(plainToken string) ([]byte, error)
+	LayananTerapiFeed(plainToken string, layananTerapiID uint) ([]byte, error)
+	ResourceFeed(plainToken string, resourceID uint) ([]byte, error)
+}
+
+type QueueService interface {
+	GetQueue(request model.QueueListRequest) ([]model.QueueEntry, error)
+	CheckIn(request model.QueueCheckInRequest, currentUserID uint) (*model.QueueEntry, error)
+	CallNext(request model.QueueCallNextRequest, currentUserID uint) (*model.QueueEntry, error)
+	PerformAction(id uint, action string, request model.QueueCallRequest, currentUserID uint) (*model.QueueEntry, error)
+	Snapshot() (*model.QueueSnapshot, error)
+	EventsAfter(lastEventID uint) ([]model.QueueEvent, bool, error)
+	Subscribe() (<-chan model.QueueEvent, func())
+}
+
+type NotificationService interface {
+	GetMessages(request model.OutboxListRequest) ([]model.OutboxMessage, error)
+	RetryMessage(id uint) (*model.OutboxMessage, error)
+}
+
+type ResourceService interface {
+	GetTypes() ([]model.ResourceType, error)
+	CreateType(request model.ResourceTypeRequest) (*model.ResourceType, error)
+	UpdateType(id uint, request model.ResourceTypeRequest) (*model.ResourceType, error)
+	DeleteType(id uint) error
+	GetResources(request model.ResourceListRequest) ([]model.Resource, error)
+	CreateResource(request model.ResourceRequest) (*model.Resource, error)
+	UpdateResource(id uint, request model.ResourceRequest) (*model.Resource, error)
+	DeleteResource(id uint) error
+	GetRequirements(request model.ResourceRequirementListRequest) ([]model.ResourceRequirement, error)
+	CreateRequirement(request model.ResourceRequirementRequest) (*model.ResourceRequirement, error)
+	DeleteRequirement(id uint) error
 }
@@ -0,0 +1,41 @@
+package service
+
+import "time"
+
+// LoginPolicy controls brute-force protection on login.
+type LoginPolicy struct {
+	// MaxAttempts is the number of failed attempts after which an account is locked.
+	MaxAttempts int
+	// IPMaxAttempts is the number of failed attempts after which an IP address is refused.
+	IPMaxAttempts int
+	// AttemptWindow is how long a failed attempt keeps counting.
+	AttemptWindow time.Duration
+	// LockoutDuration is how long an account stays locked.
+	LockoutDuration time.Duration
+	// DelayBase and DelayMax bound the waiting time enforced between failed attempts.
+	DelayBase time.Duration
+	DelayMax  time.Duration
+}
+
+// retryAfter returns how long a caller must still wait before its next attempt,
+// given the number of recent failures and when the last one happened. The
+// first failure is free; every further failure doubles the delay.
+func (p LoginPolicy) retryAfter(failures int64, lastFailure *time.Time, now time.Time) time.Duration {
+	if failures < 2 || lastFailure == nil || p.DelayBase <= 0 {
+		return 0
+	}
+
+	delay := p.DelayBase
+	for i := int64(2); i < failures && delay < p.DelayMax; i++ {
+		delay *= 2
+	}
+	if p.DelayMax > 0 && delay > p.DelayMax {
+		delay = p.DelayMax
+	}
+
+	wait := lastFailure.Add(delay).Sub(now)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"fmt"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// findCustomer returns an active customer. notFoundCode is 404 when the
+// customer is the resource being addressed and 400 when the request merely
+// refers to it.
+func findCustomer(customerRepo repository.CustomerRepository, customerID string, notFoundCode int) (*model.Customer, error) {
+	customer, err := customerRepo.FindCustomerByID(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		return nil, &ServiceError{Message: "customer not found", Code: notFoundCode}
+	}
+	return customer, nil
+}
+
+// findTreatmentSession returns a treatment session of the customer.
+func findTreatmentSession(sessionRepo repository.TreatmentSessionRepository, customerID string, sessionID uint) (*model.TreatmentSession, error) {
+	session, err := sessionRepo.FindByID(customerID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, &ServiceError{Message: "treatment session not found", Code: 404}
+	}
+	return session, nil
+}
+
+// checkTherapist verifies that the user exists and their role may treat
+// patients, so only therapists get working hours, appointments and sessions.
+func checkTherapist(userRepo repository.UserRepository, authorizer *Authorizer, therapistID uint) error {
+	user, err := userRepo.FindByID(therapistID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &ServiceError{Message: "therapist not found", Code: 400}
+		}
+		return err
+	}
+	if !authorizer.Can(user.Role.Name, model.PermTherapist) {
+		return &ServiceError{Message: fmt.Sprintf("user %d is not a therapist", therapistID), Code: 400}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"fmt"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 	"sim-clinic-api/internal/model"
@@ -24,8 +25,12 @@ func (s *masterDataService) CreateLayananTerapi(request model.LayananTerapiReque
 	}
 
 	layanan := &model.LayananTerapi{
-		Code: request.Code,
-		Name: request.Name,
+		Code:            request.Code,
+		Name:            request.Name,
+		DurationMinutes: request.DurationMinutes,
+	}
+	if layanan.DurationMinutes == 0 {
+		layanan.DurationMinutes = model.DefaultLayananDurationMinutes
 	}
 
 	if err := s.masterRepo.CreateLayananTerapi(layanan); err != nil {
@@ -70,6 +75,9 @@ func (s *masterDataService) UpdateLayananTerapi(id uint, request model.LayananTe
 
 	layanan.Code = request.Code
 	layanan.Name = request.Name
+	if request.DurationMinutes != 0 {
+		layanan.DurationMinutes = request.DurationMinutes
+	}
 
 	if err := s.masterRepo.UpdateLayananTerapi(layanan); err != nil {
 		return nil, err
@@ -161,7 +169,10 @@ func (s *masterDataService) UpdateRiwayatPenyakit(id uint, request model.Riwayat
 	return riwayat, nil
 }
 
-func (s *masterDataService) DeleteRiwayatPenyakit(id uint) error {
+// DeleteRiwayatPenyakit deletes a riwayat penyakit. One that is still used by
+// a patient's medical history can only be deleted together with a
+// replacement, which the history entries are moved to.
+func (s *masterDataService) DeleteRiwayatPenyakit(id uint, replacementID uint) error {
 	_, err := s.masterRepo.FindRiwayatPenyakitByID(id)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -170,11 +181,42 @@ func (s *masterDataService) DeleteRiwayatPenyakit(id uint) error {
 		return err
 	}
 
-	if err := s.masterRepo.DeleteRiwayatPenyakit(id); err != nil {
+	if replacementID == 0 {
+		used, err := s.masterRepo.CountRiwayatPenyakitUsage(id)
+		if err != nil {
+			return err
+		}
+		if used > 0 {
+			return &ServiceError{
+				Message: fmt.Sprintf("riwayat penyakit is used by %d medical history entries, provide a replacement_id", used),
+				Code:    409,
+			}
+		}
+
+		if err := s.masterRepo.DeleteRiwayatPenyakit(id); err != nil {
+			return err
+		}
+
+		logrus.Infof("Riwayat penyakit deleted: %d", id)
+		return nil
+	}
+
+	if replacementID == id {
+		return &ServiceError{Message: "replacement must be a different riwayat penyakit", Code: 400}
+	}
+	if _, err := s.masterRepo.FindRiwayatPenyakitByID(replacementID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &ServiceError{Message: "replacement riwayat penyakit not found", Code: 400}
+		}
+		return err
+	}
+
+	moved, err := s.masterRepo.ReplaceRiwayatPenyakit(id, replacementID)
+	if err != nil {
 		return err
 	}
 
-	logrus.Infof("Riwayat penyakit deleted: %d", id)
+	logrus.Infof("Riwayat penyakit deleted: %d, %d medical history entries moved to %d", id, moved, replacementID)
 	return nil
 }
 
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/repository"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type medicalHistoryService struct {
+	historyRepo  repository.MedicalHistoryRepository
+	customerRepo repository.CustomerRepository
+	masterRepo   repository.MasterDataRepository
+}
+
+func NewMedicalHistoryService(
+	historyRepo repository.MedicalHistoryRepository,
+	customerRepo repository.CustomerRepository,
+	masterRepo repository.MasterDataRepository,
+) MedicalHistoryService {
+	return &medicalHistoryService{
+		historyRepo:  historyRepo,
+		customerRepo: customerRepo,
+		masterRepo:   masterRepo,
+	}
+}
+
+func (s *medicalHistoryService) GetMedicalHistory(customerID string) ([]model.CustomerMedicalHistory, error) {
+	if _, err := findCustomer(s.customerRepo, customerID, 404); err != nil {
+		return nil, err
+	}
+	return s.historyRepo.FindByCustomer(customerID)
+}
+
+func (s *medicalHistoryService) CreateMedicalHistory(customerID string, request model.MedicalHistoryRequest, recordedBy uint) (*model.CustomerMedicalHistory, error) {
+	if _, err := findCustomer(s.customerRepo, customerID, 404); err != nil {
+		return nil, err
+	}
+	if err := s.checkRiwayatPenyakit(request.RiwayatPenyakitID); err != nil {
+		return nil, err
+	}
+
+	history := &model.CustomerMedicalHistory{
+		CustomerID:        customerID,
+		RiwayatPenyakitID: request.RiwayatPenyakitID,
+		OnsetDate:         request.OnsetTime(),
+		Status:            request.Status,
+		Notes:             request.Notes,
+		RecordedBy:        recordedBy,
+	}
+	if history.Status == "" {
+		history.Status = model.MedicalHistoryActive
+	}
+
+	if err := s.historyRepo.Create(history); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Medical history %d recorded for customer %s by user %d", history.ID, customerID, recordedBy)
+	return s.historyRepo.FindByID(customerID, history.ID)
+}
+
+func (s *medicalHistoryService) UpdateMedicalHistory(customerID string, id uint, request model.MedicalHistoryRequest, recordedBy uint) (*model.CustomerMedicalHistory, error) {
+	history, err := s.findMedicalHistory(customerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.RiwayatPenyakitID != history.RiwayatPenyakitID {
+		if err := s.checkRiwayatPenyakit(request.RiwayatPenyakitID); err != nil {
+			return nil, err
+		}
+	}
+
+	history.RiwayatPenyakitID = request.RiwayatPenyakitID
+	history.OnsetDate = request.OnsetTime()
+	history.Notes = request.Notes
+	history.RecordedBy = recordedBy
+	if request.Status != "" {
+		history.Status = request.Status
+	}
+
+	if err := s.historyRepo.Update(history); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Medical history %d of customer %s updated by user %d", id, customerID, recordedBy)
+	return s.historyRepo.FindByID(customerID, id)
+}
+
+func (s *medicalHistoryService) DeleteMedicalHistory(customerID string, id uint) error {
+	if _, err := s.findMedicalHistory(customerID, id); err != nil {
+		return err
+	}
+
+	if err := s.historyRepo.Delete(id); err != nil {
+		return err
+	}
+
+	logrus.Infof("Medical history %d of customer %s deleted", id, customerID)
+	return nil
+}
+
+func (s *medicalHistoryService) findMedicalHistory(customerID string, id uint) (*model.CustomerMedicalHistory, error) {
+	if _, err := findCustomer(s.customerRepo, customerID, 404); err != nil {
+		return nil, err
+	}
+
+	history, err := s.historyRepo.FindByID(customerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		return nil, &ServiceError{Message: "medical history not found", Code: 404}
+	}
+	return history, nil
+}
+
+func (s *medicalHistoryService) checkRiwayatPenyakit(id uint) error {
+	if _, err := s.masterRepo.FindRiwayatPenyakitByID(id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &ServiceError{Message: "riwayat penyakit not found", Code: 400}
+		}
+		return err
+	}
+	return nil
+}
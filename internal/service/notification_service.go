@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/notification"
+	"sim-clinic-api/internal/repository"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// outboxLease is how long a claimed message stays hidden from other workers.
+	outboxLease = 5 * time.Minute
+	// maxRetryDelay caps the exponential backoff between attempts.
+	maxRetryDelay = 6 * time.Hour
+	// reminderInterval is how often the worker looks for appointments to remind.
+	reminderInterval = time.Minute
+
+	defaultOutboxListLimit = 50
+)
+
+// NotificationPolicy controls how patient messages are written and delivered.
+type NotificationPolicy struct {
+	// Channel is where patient messages go: whatsapp or sms
+	Channel         string
+	DefaultLanguage string
+	ClinicName      string
+	Location        *time.Location
+	MaxAttempts     int
+	RetryBase       time.Duration
+	PollInterval    time.Duration
+	BatchSize       int
+	// ReminderLead is how long before an appointment its reminder is sent
+	ReminderLead time.Duration
+}
+
+func (p NotificationPolicy) withDefaults() NotificationPolicy {
+	if p.Channel == "" {
+		p.Channel = notification.ChannelWhatsApp
+	}
+	if !notification.SupportedLanguage(p.DefaultLanguage) {
+		p.DefaultLanguage = notification.LanguageIndonesian
+	}
+	if p.Location == nil {
+		p.Location = time.Local
+	}
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 5
+	}
+	if p.RetryBase <= 0 {
+		p.RetryBase = time.Minute
+	}
+	if p.PollInterval <= 0 {
+		p.PollInterval = 15 * time.Second
+	}
+	if p.BatchSize <= 0 {
+		p.BatchSize = 20
+	}
+	if p.ReminderLead <= 0 {
+		p.ReminderLead = 24 * time.Hour
+	}
+	return p
+}
+
+// appointmentMessages writes the patient message about an appointment. It
+// returns nothing when the customer opted out, has no phone number, or the
+// appointment already started. appointment must have Customer and
+// LayananTerapi loaded.
+func (p NotificationPolicy) appointmentMessages(template string, appointment *model.Appointment) []model.OutboxMessage {
+	customer := appointment.Customer
+	if customer == nil || customer.NotificationOptOut || customer.PhoneNumber == "" {
+		return nil
+	}
+	if !appointment.StartAt.After(time.Now()) {
+		return nil
+	}
+
+	language := customer.PreferredLanguage
+	if !notification.SupportedLanguage(language) {
+		language = p.DefaultLanguage
+	}
+
+	var layanan string
+	if appointment.LayananTerapi != nil {
+		layanan = appointment.LayananTerapi.Name
+	}
+	subject, body, err := notification.Render(template, language, notification.TemplateData{
+		CustomerName:  customer.CustomerName,
+		LayananTerapi: layanan,
+		ClinicName:    p.ClinicName,
+		At:            appointment.StartAt.In(p.Location),
+	})
+	if err != nil {
+		logrus.Errorf("Failed to render %s for appointment %d: %v", template, appointment.ID, err)
+		return nil
+	}
+
+	// Pesan tentang janji yang sudah lewat tidak ada gunanya lagi
+	expiresAt := appointment.StartAt
+	return []model.OutboxMessage{{
+		Channel:       p.Channel,
+		Recipient:     customer.PhoneNumber,
+		Template:      template,
+		Language:      language,
+		Subject:       subject,
+		Body:          body,
+		CustomerID:    &customer.Id,
+		Status:        model.OutboxPending,
+		NextAttemptAt: time.Now(),
+		ExpiresAt:     &expiresAt,
+	}}
+}
+
+type notificationService struct {
+	outboxRepo repository.OutboxRepository
+}
+
+func NewNotificationService(outboxRepo repository.OutboxRepository) NotificationService {
+	return &notificationService{outboxRepo: outboxRepo}
+}
+
+func (s *notificationService) GetMessages(request model.OutboxListRequest) ([]model.OutboxMessage, error) {
+	limit := request.Limit
+	if limit == 0 {
+		limit = defaultOutboxListLimit
+	}
+	return s.outboxRepo.Find(request.Status, request.CustomerID, limit)
+}
+
+// RetryMessage queues a failed or skipped message again, unless it expired,
+// e.g. a reminder of an appointment that already started.
+func (s *notificationService) RetryMessage(id uint) (*model.OutboxMessage, error) {
+	message, err := s.outboxRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if message == nil {
+		return nil, &ServiceError{Message: "notification not found", Code: 404}
+	}
+	if message.ExpiresAt != nil && !time.Now().Before(*message.ExpiresAt) {
+		return nil, &ServiceError{Message: "notification has expired and can no longer be sent", Code: 409}
+	}
+
+	requeued, err := s.outboxRepo.Requeue(id)
+	if err != nil {
+		return nil, err
+	}
+	if !requeued {
+		return nil, &ServiceError{Message: "only failed or skipped notifications can be retried", Code: 409}
+	}
+
+	logrus.Infof("Notification %d queued again", id)
+	return s.outboxRepo.FindByID(id)
+}
+
+// NotificationWorker queues appointment reminders and delivers the outbox.
+// Several replicas may run one; claimed messages are not picked up twice.
+type NotificationWorker struct {
+	outboxRepo      repository.OutboxRepository
+	appointmentRepo repository.AppointmentRepository
+	customerRepo    repository.CustomerRepository
+	notifier        notification.Notifier
+	policy          NotificationPolicy
+	lastReminders   time.Time
+}
+
+func NewNotificationWorker(
+	outboxRepo repository.OutboxRepository,
+	appointmentRepo repository.AppointmentRepository,
+	customerRepo repository.CustomerRepository,
+	notifier notification.Notifier,
+	policy NotificationPolicy,
+) *NotificationWorker {
+	return &NotificationWorker{
+		outboxRepo:      outboxRepo,
+		appointmentRepo: appointmentRepo,
+		customerRepo:    customerRepo,
+		notifier:        notifier,
+		policy:          policy.withDefaults(),
+	}
+}
+
+// Run polls the outbox until ctx is cancelled.
+func (w *NotificationWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.policy.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if time.Since(w.lastReminders) >= reminderInterval {
+				if err := w.enqueueReminders(); err != nil {
+					logrus.Errorf("Failed to queue appointment reminders: %v", err)
+				}
+				w.lastReminders = time.Now()
+			}
+			if err := w.deliverDue(ctx); err != nil {
+				logrus.Errorf("Failed to deliver notifications: %v", err)
+			}
+		}
+	}
+}
+
+// enqueueReminders queues a reminder for every appointment starting within the
+// reminder lead. The dedup key includes the start time, so a rescheduled
+// appointment is reminded again. Reminders remember the appointment, so
+// deliver can drop them once it is cancelled or moved.
+func (w *NotificationWorker) enqueueReminders() error {
+	now := time.Now()
+	appointments, err := w.appointmentRepo.FindReminderCandidates(now, now.Add(w.policy.ReminderLead), w.policy.ReminderLead)
+	if err != nil {
+		return err
+	}
+
+	var messages []model.OutboxMessage
+	for i := range appointments {
+		for _, message := range w.policy.appointmentMessages(notification.TemplateAppointmentReminder, &appointments[i]) {
+			key := fmt.Sprintf("%s:%d:%d", notification.TemplateAppointmentReminder, appointments[i].ID, appointments[i].StartAt.Unix())
+			message.DedupKey = &key
+			message.AppointmentID = &appointments[i].ID
+			message.AppointmentStartAt = &appointments[i].StartAt
+			messages = append(messages, message)
+		}
+	}
+	return w.outboxRepo.Enqueue(messages)
+}
+
+func (w *NotificationWorker) deliverDue(ctx context.Context) error {
+	for ctx.Err() == nil {
+		messages, err := w.outboxRepo.ClaimDue(w.policy.BatchSize, outboxLease)
+		if err != nil {
+			return err
+		}
+		for i := range messages {
+			if err := w.deliver(&messages[i]); err != nil {
+				return err
+			}
+		}
+		if len(messages) < w.policy.BatchSize {
+			return nil
+		}
+	}
+	return nil
+}
+
+// deliver sends one claimed message and records the outcome. Errors from the
+// notifier are recorded on the message; only storage errors are returned.
+func (w *NotificationWorker) deliver(message *model.OutboxMessage) error {
+	if message.ExpiresAt != nil && time.Now().After(*message.ExpiresAt) {
+		return w.outboxRepo.MarkFinished(message.ID, model.OutboxSkipped, "expired before it could be sent")
+	}
+
+	// Pesan bisa tertahan retry berjam-jam; janji mungkin sudah batal atau digeser
+	if message.AppointmentID != nil {
+		appointment, err := w.appointmentRepo.FindByID(*message.AppointmentID)
+		if err != nil {
+			return err
+		}
+		if appointment == nil {
+			return w.outboxRepo.MarkFinished(message.ID, model.OutboxSkipped, "appointment no longer exists")
+		}
+		// Pemberitahuan pembatalan justru untuk janji yang batal
+		if message.Template != notification.TemplateAppointmentCancelled && appointment.Status != model.AppointmentBooked {
+			return w.outboxRepo.MarkFinished(message.ID, model.OutboxSkipped, "appointment is no longer booked")
+		}
+		if message.AppointmentStartAt != nil && !appointment.StartAt.Equal(*message.AppointmentStartAt) {
+			return w.outboxRepo.MarkFinished(message.ID, model.OutboxSkipped, "appointment was rescheduled")
+		}
+	}
+
+	// Pasien bisa berhenti berlangganan setelah pesan masuk antrean
+	if message.CustomerID != nil {
+		customer, err := w.customerRepo.FindCustomerByID(*message.CustomerID)
+		if err != nil {
+			return err
+		}
+		if customer == nil {
+			return w.outboxRepo.MarkFinished(message.ID, model.OutboxSkipped, "customer no longer exists")
+		}
+		if customer.NotificationOptOut {
+			return w.outboxRepo.MarkFinished(message.ID, model.OutboxSkipped, "customer opted out of notifications")
+		}
+	}
+
+	err := w.notifier.Send(notification.Message{
+		Channel: message.Channel,
+		To:      message.Recipient,
+		Subject: message.Subject,
+		Body:    message.Body,
+	})
+	if err == nil {
+		return w.outboxRepo.MarkSent(message.ID)
+	}
+
+	if notification.IsPermanent(err) || message.Attempts >= w.policy.MaxAttempts {
+		logrus.Errorf("Giving up on notification %d after %d attempts: %v", message.ID, message.Attempts, err)
+		return w.outboxRepo.MarkFinished(message.ID, model.OutboxFailed, err.Error())
+	}
+
+	next := time.Now().Add(w.retryDelay(message.Attempts))
+	logrus.Warnf("Notification %d failed (attempt %d), retrying at %s: %v", message.ID, message.Attempts, next.Format(time.RFC3339), err)
+	return w.outboxRepo.MarkRetry(message.ID, err.Error(), next)
+}
+
+// retryDelay doubles the wait after every attempt, up to maxRetryDelay.
+func (w *NotificationWorker) retryDelay(attempts int) time.Duration {
+	delay := w.policy.RetryBase
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/notification"
+	"sim-clinic-api/internal/repository"
+)
+
+// stubOutboxRepository records how delivered messages were finished.
+type stubOutboxRepository struct {
+	repository.OutboxRepository
+	status string
+}
+
+func (r *stubOutboxRepository) MarkSent(uint) error {
+	r.status = model.OutboxSent
+	return nil
+}
+
+func (r *stubOutboxRepository) MarkFinished(_ uint, status, _ string) error {
+	r.status = status
+	return nil
+}
+
+type stubAppointmentRepository struct {
+	repository.AppointmentRepository
+	appointment *model.Appointment
+}
+
+func (r stubAppointmentRepository) FindByID(uint) (*model.Appointment, error) {
+	return r.appointment, nil
+}
+
+type stubNotifier struct{}
+
+func (stubNotifier) Send(notification.Message) error { return nil }
+
+func TestDeliverChecksTheAppointment(t *testing.T) {
+	startAt := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	movedAt := startAt.Add(time.Hour)
+	appointmentID := uint(7)
+
+	tests := []struct {
+		name     string
+		template string
+		status   string
+		startAt  time.Time
+		want     string
+	}{
+		{name: "confirmation of a booked appointment", template: notification.TemplateAppointmentBooked, status: model.AppointmentBooked, startAt: startAt, want: model.OutboxSent},
+		{name: "confirmation of a cancelled appointment", template: notification.TemplateAppointmentBooked, status: model.AppointmentCancelled, startAt: startAt, want: model.OutboxSkipped},
+		{name: "confirmation of a rescheduled appointment", template: notification.TemplateAppointmentBooked, status: model.AppointmentBooked, startAt: movedAt, want: model.OutboxSkipped},
+		{name: "reminder of a cancelled appointment", template: notification.TemplateAppointmentReminder, status: model.AppointmentCancelled, startAt: startAt, want: model.OutboxSkipped},
+		{name: "cancellation of a cancelled appointment", template: notification.TemplateAppointmentCancelled, status: model.AppointmentCancelled, startAt: startAt, want: model.OutboxSent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := &stubOutboxRepository{}
+			appointments := stubAppointmentRepository{appointment: &model.Appointment{ID: appointmentID, Status: tt.status, StartAt: tt.startAt}}
+			worker := NewNotificationWorker(outbox, appointments, nil, stubNotifier{}, NotificationPolicy{})
+
+			scheduled := startAt
+			err := worker.deliver(&model.OutboxMessage{
+				ID:                 1,
+				Template:           tt.template,
+				AppointmentID:      &appointmentID,
+				AppointmentStartAt: &scheduled,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if outbox.status != tt.want {
+				t.Errorf("message %s, want %s", outbox.status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"encoding/json"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/pkg/cache"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// QueueEventsChannel is the Postgres NOTIFY channel replicas use to pass queue
+// events to the displays connected to each other.
+const QueueEventsChannel = "queue_events"
+
+const (
+	// queueSubscriberBuffer is how many events a display may fall behind
+	// before it is disconnected and has to resume with Last-Event-ID.
+	queueSubscriberBuffer = 64
+	// queueSeenTTL is how long delivered event ids are remembered, so an
+	// event that arrives both locally and through NOTIFY is sent once.
+	queueSeenTTL  = 10 * time.Minute
+	queueSeenSize = 1024
+)
+
+// QueueHub fans queue events out to the displays connected to this replica.
+// Events are published to the other replicas as well, which deliver them to
+// their own displays.
+type QueueHub struct {
+	mu          sync.Mutex
+	subscribers map[chan model.QueueEvent]struct{}
+	seen        *cache.Cache[uint, bool]
+	publish     func(payload string) error
+}
+
+// NewQueueHub creates a hub. publish may be nil when running a single replica.
+func NewQueueHub(publish func(payload string) error) *QueueHub {
+	return &QueueHub{
+		subscribers: make(map[chan model.QueueEvent]struct{}),
+		seen:        cache.New[uint, bool](queueSeenSize),
+		publish:     publish,
+	}
+}
+
+// Subscribe returns a channel receiving every new queue event and a function
+// to stop receiving them. The channel is closed when the subscriber falls too
+// far behind or unsubscribes.
+func (h *QueueHub) Subscribe() (<-chan model.QueueEvent, func()) {
+	events := make(chan model.QueueEvent, queueSubscriberBuffer)
+
+	h.mu.Lock()
+	h.subscribers[events] = struct{}{}
+	h.mu.Unlock()
+
+	return events, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(events)
+	}
+}
+
+// Publish delivers an event to local subscribers and to the other replicas.
+func (h *QueueHub) Publish(event model.QueueEvent) {
+	h.deliver(event)
+
+	if h.publish == nil {
+		return
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		logrus.Errorf("Failed to encode queue event %d: %v", event.ID, err)
+		return
+	}
+	if err := h.publish(string(payload)); err != nil {
+		logrus.Warnf("Failed to publish queue event %d: %v", event.ID, err)
+	}
+}
+
+// HandleNotification delivers an event received from another replica.
+func (h *QueueHub) HandleNotification(payload string) {
+	var event model.QueueEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		logrus.Warnf("Ignoring malformed queue event: %v", err)
+		return
+	}
+	h.deliver(event)
+}
+
+func (h *QueueHub) deliver(event model.QueueEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.seen.Get(event.ID); ok {
+		return
+	}
+	h.seen.Set(event.ID, true, queueSeenTTL)
+
+	for events := range h.subscribers {
+		select {
+		case events <- event:
+		default:
+			// Layar yang tertinggal diputus, lalu menyusul lewat Last-Event-ID
+			h.remove(events)
+		}
+	}
+}
+
+// remove must be called with h.mu held.
+func (h *QueueHub) remove(events chan model.QueueEvent) {
+	if _, ok := h.subscribers[events]; ok {
+		delete(h.subscribers, events)
+		close(events)
+	}
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/repository"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// maxQueueReplay caps how many events are replayed to a reconnecting display.
+// A display that missed more gets a fresh snapshot instead.
+const maxQueueReplay = 500
+
+// callNextAttempts is how often CallNext retries when another caller took the
+// same customer first.
+const callNextAttempts = 3
+
+type queueService struct {
+	queueRepo    repository.QueueRepository
+	customerRepo repository.CustomerRepository
+	masterRepo   repository.MasterDataRepository
+	counterRepo  repository.CounterRepository
+	hub          *QueueHub
+	location     *time.Location
+}
+
+func NewQueueService(
+	queueRepo repository.QueueRepository,
+	customerRepo repository.CustomerRepository,
+	masterRepo repository.MasterDataRepository,
+	counterRepo repository.CounterRepository,
+	hub *QueueHub,
+	location *time.Location,
+) QueueService {
+	if location == nil {
+		location = time.Local
+	}
+
+	return &queueService{
+		queueRepo:    queueRepo,
+		customerRepo: customerRepo,
+		masterRepo:   masterRepo,
+		counterRepo:  counterRepo,
+		hub:          hub,
+		location:     location,
+	}
+}
+
+func (s *queueService) GetQueue(request model.QueueListRequest) ([]model.QueueEntry, error) {
+	date := request.Date
+	if date == "" {
+		date = s.today()
+	}
+	return s.queueRepo.Find(date, request.LayananTerapiID, request.Status)
+}
+
+// CheckIn puts a customer in today's queue for a layanan terapi and hands out
+// the next number of that layanan terapi.
+func (s *queueService) CheckIn(request model.QueueCheckInRequest, currentUserID uint) (*model.QueueEntry, error) {
+	if _, err := findCustomer(s.customerRepo, request.CustomerID, 400); err != nil {
+		return nil, err
+	}
+
+	layanan, err := s.masterRepo.FindLayananTerapiByID(request.LayananTerapiID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, &ServiceError{Message: "layanan terapi not found", Code: 400}
+		}
+		return nil, err
+	}
+
+	today := s.today()
+	if err := s.checkNotQueued(today, request); err != nil {
+		return nil, err
+	}
+
+	number, err := s.counterRepo.Next("queue:"+strconv.FormatUint(uint64(layanan.ID), 10), today)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &model.QueueEntry{
+		QueueDate:       today,
+		LayananTerapiID: layanan.ID,
+		Number:          number,
+		Ticket:          fmt.Sprintf("%s-%03d", layanan.Code, number),
+		CustomerID:      request.CustomerID,
+		Status:          model.QueueWaiting,
+		CreatedBy:       currentUserID,
+	}
+	event := &model.QueueEvent{
+		QueueDate:       today,
+		LayananTerapiID: layanan.ID,
+		Type:            model.QueueActionCheckIn,
+		Ticket:          entry.Ticket,
+		Status:          model.QueueWaiting,
+		UserID:          currentUserID,
+	}
+	if err := s.queueRepo.Create(entry, event); err != nil {
+		// Meja lain mendaftarkan pasien yang sama di antara pengecekan dan simpan
+		if errors.Is(err, repository.ErrQueueEntryExists) {
+			if err := s.checkNotQueued(today, request); err != nil {
+				return nil, err
+			}
+			return nil, &ServiceError{Message: "customer is already in the queue", Code: 409}
+		}
+		return nil, err
+	}
+	s.hub.Publish(*event)
+
+	logrus.Infof("Customer %s checked in as %s by user %d", request.CustomerID, entry.Ticket, currentUserID)
+	return s.queueRepo.FindByID(entry.ID)
+}
+
+// checkNotQueued refuses a check-in when the customer already waits or is
+// being called for the layanan terapi, naming their ticket.
+func (s *queueService) checkNotQueued(date string, request model.QueueCheckInRequest) error {
+	existing, err := s.queueRepo.FindActiveByCustomer(date, request.CustomerID, request.LayananTerapiID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return &ServiceError{Message: "customer is already in the queue as " + existing.Ticket, Code: 409}
+	}
+	return nil
+}
+
+// CallNext calls the waiting customer with the lowest number for a layanan terapi.
+func (s *queueService) CallNext(request model.QueueCallNextRequest, currentUserID uint) (*model.QueueEntry, error) {
+	for attempt := 0; attempt < callNextAttempts; attempt++ {
+		entry, err := s.queueRepo.FindNextWaiting(s.today(), request.LayananTerapiID)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			return nil, &ServiceError{Message: "nobody is waiting for this layanan terapi", Code: 404}
+		}
+
+		updated, err := s.apply(entry, model.QueueActionCall, request.Room, currentUserID)
+		if err != nil {
+			return nil, err
+		}
+		// Terapis lain memanggil pasien yang sama lebih dulu, coba nomor berikutnya
+		if updated {
+			return s.queueRepo.FindByID(entry.ID)
+		}
+	}
+	return nil, &ServiceError{Message: "the queue is changing too quickly, please try again", Code: 409}
+}
+
+// PerformAction calls, recalls, skips or finishes a queue entry.
+func (s *queueService) PerformAction(id uint, action string, request model.QueueCallRequest, currentUserID uint) (*model.QueueEntry, error) {
+	entry, err := s.queueRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, &ServiceError{Message: "queue entry not found", Code: 404}
+	}
+
+	updated, err := s.apply(entry, action, request.Room, currentUserID)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, &ServiceError{Message: "queue entry was changed by someone else, please reload", Code: 409}
+	}
+	return s.queueRepo.FindByID(id)
+}
+
+// Snapshot returns today's tickets and the id of the newest event, from which
+// a display continues with the live feed.
+func (s *queueService) Snapshot() (*model.QueueSnapshot, error) {
+	// Id diambil lebih dulu supaya perubahan di antaranya tetap terkirim ulang
+	lastEventID, err := s.queueRepo.LastEventID()
+	if err != nil {
+		return nil, err
+	}
+
+	today := s.today()
+	entries, err := s.queueRepo.Find(today, 0, "")
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &model.QueueSnapshot{
+		QueueDate:   today,
+		LastEventID: lastEventID,
+		Tickets:     make([]model.QueueTicket, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		snapshot.Tickets = append(snapshot.Tickets, model.QueueTicket{
+			QueueEntryID:    entry.ID,
+			LayananTerapiID: entry.LayananTerapiID,
+			Ticket:          entry.Ticket,
+			Status:          entry.Status,
+			Room:            entry.Room,
+		})
+	}
+	return snapshot, nil
+}
+
+// EventsAfter returns today's events a display missed since lastEventID. It
+// returns false when the display missed more than maxQueueReplay events and
+// should be sent a snapshot instead.
+func (s *queueService) EventsAfter(lastEventID uint) ([]model.QueueEvent, bool, error) {
+	// Satu kejadian lebih dari batas menandakan replay tidak lengkap
+	events, err := s.queueRepo.FindEventsAfter(s.today(), lastEventID, maxQueueReplay+1)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(events) > maxQueueReplay {
+		return nil, false, nil
+	}
+	return events, true, nil
+}
+
+func (s *queueService) Subscribe() (<-chan model.QueueEvent, func()) {
+	return s.hub.Subscribe()
+}
+
+// apply performs an action on an entry and publishes the event. It returns
+// false if the entry changed status since it was read.
+func (s *queueService) apply(entry *model.QueueEntry, action, room string, currentUserID uint) (bool, error) {
+	if entry.QueueDate != s.today() {
+		return false, &ServiceError{Message: "queue entries of past days cannot be changed", Code: 409}
+	}
+
+	from := entry.Status
+	to, ok := model.QueueActionTarget(action, from)
+	if !ok {
+		return false, &ServiceError{Message: "queue entry is " + from + " and cannot be " + action, Code: 409}
+	}
+
+	now := time.Now()
+	switch action {
+	case model.QueueActionCall, model.QueueActionRecall:
+		entry.CallCount++
+		entry.CalledBy = &currentUserID
+		entry.CalledAt = &now
+		if room != "" {
+			entry.Room = room
+		}
+	case model.QueueActionFinish:
+		entry.FinishedAt = &now
+	}
+	entry.Status = to
+
+	event := &model.QueueEvent{
+		QueueDate:       entry.QueueDate,
+		LayananTerapiID: entry.LayananTerapiID,
+		Type:            action,
+		Ticket:          entry.Ticket,
+		Status:          to,
+		Room:            entry.Room,
+		UserID:          currentUserID,
+	}
+	updated, err := s.queueRepo.Update(entry, from, event)
+	if err != nil || !updated {
+		return false, err
+	}
+	s.hub.Publish(*event)
+
+	logrus.Infof("Queue %s %s by user %d", entry.Ticket, action, currentUserID)
+	return true, nil
+}
+
+func (s *queueService) today() string {
+	return time.Now().In(s.location).Format(model.DateLayout)
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/repository"
+)
+
+// stubQueueRepository holds the events of one day with ids 1 to events.
+type stubQueueRepository struct {
+	repository.QueueRepository
+	events uint
+}
+
+func (r stubQueueRepository) FindEventsAfter(date string, afterID uint, limit int) ([]model.QueueEvent, error) {
+	var events []model.QueueEvent
+	for id := afterID + 1; id <= r.events && len(events) < limit; id++ {
+		events = append(events, model.QueueEvent{ID: id, QueueDate: date})
+	}
+	return events, nil
+}
+
+func TestQueueEventsAfter(t *testing.T) {
+	tests := []struct {
+		name         string
+		events       uint
+		lastEventID  uint
+		wantComplete bool
+		wantEvents   int
+	}{
+		{name: "up to date", events: 10, lastEventID: 10, wantComplete: true, wantEvents: 0},
+		{name: "a few missed", events: 10, lastEventID: 7, wantComplete: true, wantEvents: 3},
+		{name: "exactly the limit", events: maxQueueReplay + 1, lastEventID: 1, wantComplete: true, wantEvents: maxQueueReplay},
+		{name: "over the limit", events: maxQueueReplay + 2, lastEventID: 1, wantComplete: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewQueueService(stubQueueRepository{events: tt.events}, nil, nil, nil, nil, time.UTC)
+			events, complete, err := s.EventsAfter(tt.lastEventID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if complete != tt.wantComplete {
+				t.Fatalf("complete = %v, want %v", complete, tt.wantComplete)
+			}
+			if len(events) != tt.wantEvents {
+				t.Errorf("got %d events, want %d", len(events), tt.wantEvents)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"fmt"
+	"sim-clinic-api/internal/model"
+	"time"
+)
+
+// resourceNeed is how many resources of one type an appointment needs.
+type resourceNeed struct {
+	resourceType model.ResourceType
+	quantity     int
+}
+
+// resourcePlanner picks rooms and equipment for appointments within a period.
+// It knows which resources the layanan and teknik terapi need and when each
+// of them is already reserved.
+type resourcePlanner struct {
+	needs     []resourceNeed
+	resources map[uint][]model.Resource
+	bookings  map[uint][]model.ResourceBooking
+	location  *time.Location
+}
+
+// planResources loads what an appointment for the layanan terapi, and the
+// teknik terapi if given, needs during period. Reservations of the
+// appointments in exclude are ignored, as they are the ones being moved.
+func (s *appointmentService) planResources(layananTerapiID uint, teknikTerapiID *uint, period timeRange, exclude map[uint]bool) (*resourcePlanner, error) {
+	planner := &resourcePlanner{
+		resources: make(map[uint][]model.Resource),
+		bookings:  make(map[uint][]model.ResourceBooking),
+		location:  s.policy.Location,
+	}
+
+	var teknikID uint
+	if teknikTerapiID != nil {
+		teknikID = *teknikTerapiID
+	}
+	requirements, err := s.resourceRepo.FindRequirements(layananTerapiID, teknikID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Layanan dan teknik yang butuh jenis yang sama cukup memakai satu alat
+	quantities := make(map[uint]int)
+	for _, requirement := range requirements {
+		if _, seen := quantities[requirement.ResourceTypeID]; !seen && requirement.ResourceType != nil {
+			planner.needs = append(planner.needs, resourceNeed{resourceType: *requirement.ResourceType})
+		}
+		if requirement.Quantity > quantities[requirement.ResourceTypeID] {
+			quantities[requirement.ResourceTypeID] = requirement.Quantity
+		}
+	}
+	if len(planner.needs) == 0 {
+		return planner, nil
+	}
+
+	typeIDs := make([]uint, len(planner.needs))
+	for i := range planner.needs {
+		planner.needs[i].quantity = quantities[planner.needs[i].resourceType.ID]
+		typeIDs[i] = planner.needs[i].resourceType.ID
+	}
+
+	resources, err := s.resourceRepo.FindActiveByTypes(typeIDs)
+	if err != nil {
+		return nil, err
+	}
+	resourceIDs := make([]uint, len(resources))
+	for i, resource := range resources {
+		planner.resources[resource.ResourceTypeID] = append(planner.resources[resource.ResourceTypeID], resource)
+		resourceIDs[i] = resource.ID
+	}
+
+	bookings, err := s.appointmentRepo.FindResourceBookings(resourceIDs, period.Start, period.End)
+	if err != nil {
+		return nil, err
+	}
+	for _, booking := range bookings {
+		if exclude[booking.AppointmentID] {
+			continue
+		}
+		planner.bookings[booking.ResourceID] = append(planner.bookings[booking.ResourceID], booking)
+	}
+	return planner, nil
+}
+
+// allocate picks the resources an appointment in slot needs, or explains
+// which type has nothing free. The result is never nil, so it can replace the
+// reservations of an appointment that needs no resources any more.
+func (p *resourcePlanner) allocate(slot timeRange) ([]model.AppointmentResource, error) {
+	allocations := []model.AppointmentResource{}
+	for _, need := range p.needs {
+		picked := 0
+		for i := range p.resources[need.resourceType.ID] {
+			if picked == need.quantity {
+				break
+			}
+			resource := &p.resources[need.resourceType.ID][i]
+			if !resourceOpen(resource, slot, p.location) || p.reserved(resource.ID, slot) >= resource.Capacity {
+				continue
+			}
+			allocations = append(allocations, model.AppointmentResource{ResourceID: resource.ID, Resource: resource})
+			picked++
+		}
+
+		if picked < need.quantity {
+			if need.quantity == 1 {
+				return nil, &ServiceError{Message: fmt.Sprintf("no %s is available at this time", need.resourceType.Name), Code: 409}
+			}
+			return nil, &ServiceError{
+				Message: fmt.Sprintf("only %d of the %d %s needed are available at this time", picked, need.quantity, need.resourceType.Name),
+				Code:    409,
+			}
+		}
+	}
+	return allocations, nil
+}
+
+// reserve marks allocations as taken in slot, so later appointments planned
+// with the same planner, such as the rest of a series, do not get them too.
+func (p *resourcePlanner) reserve(slot timeRange, allocations []model.AppointmentResource) {
+	for _, allocation := range allocations {
+		p.bookings[allocation.ResourceID] = append(p.bookings[allocation.ResourceID], model.ResourceBooking{
+			ResourceID: allocation.ResourceID,
+			StartAt:    slot.Start,
+			EndAt:      slot.End,
+		})
+	}
+}
+
+// reserved returns how many reservations of a resource are in use at once at
+// the busiest moment of slot, the same way the repository checks it.
+func (p *resourcePlanner) reserved(resourceID uint, slot timeRange) int {
+	return model.PeakBookings(p.bookings[resourceID], slot.Start, slot.End)
+}
+
+// resourceOpen reports whether slot lies within the resource's active hours.
+func resourceOpen(resource *model.Resource, slot timeRange, location *time.Location) bool {
+	if resource.OpenTime == "" || resource.CloseTime == "" {
+		return true
+	}
+
+	local := slot.Start.In(location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	hours, ok := clockRange(day, resource.OpenTime, resource.CloseTime)
+	return ok && hours.contains(slot)
+}
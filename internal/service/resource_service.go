@@ -0,0 +1,318 @@
+package service
+
+import (
+	"fmt"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// maxListedAppointments caps how many appointment ids an error message names.
+const maxListedAppointments = 20
+
+type resourceService struct {
+	resourceRepo repository.ResourceRepository
+	masterRepo   repository.MasterDataRepository
+	location     *time.Location
+}
+
+func NewResourceService(resourceRepo repository.ResourceRepository, masterRepo repository.MasterDataRepository, location *time.Location) ResourceService {
+	if location == nil {
+		location = time.Local
+	}
+
+	return &resourceService{
+		resourceRepo: resourceRepo,
+		masterRepo:   masterRepo,
+		location:     location,
+	}
+}
+
+// ============ RESOURCE TYPE ============
+func (s *resourceService) GetTypes() ([]model.ResourceType, error) {
+	return s.resourceRepo.FindTypes()
+}
+
+func (s *resourceService) CreateType(request model.ResourceTypeRequest) (*model.ResourceType, error) {
+	existing, err := s.resourceRepo.FindTypeByCode(request.Code)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, &ServiceError{Message: "code already exists", Code: 400}
+	}
+
+	resourceType := &model.ResourceType{
+		Code: request.Code,
+		Name: request.Name,
+		Kind: request.Kind,
+	}
+	if err := s.resourceRepo.CreateType(resourceType); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Resource type %d (%s) created", resourceType.ID, resourceType.Code)
+	return resourceType, nil
+}
+
+func (s *resourceService) UpdateType(id uint, request model.ResourceTypeRequest) (*model.ResourceType, error) {
+	resourceType, err := s.findType(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Code != resourceType.Code {
+		existing, err := s.resourceRepo.FindTypeByCode(request.Code)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return nil, &ServiceError{Message: "code already exists", Code: 400}
+		}
+	}
+
+	resourceType.Code = request.Code
+	resourceType.Name = request.Name
+	resourceType.Kind = request.Kind
+	if err := s.resourceRepo.UpdateType(resourceType); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Resource type %d updated", id)
+	return resourceType, nil
+}
+
+func (s *resourceService) DeleteType(id uint) error {
+	if _, err := s.findType(id); err != nil {
+		return err
+	}
+
+	used, err := s.resourceRepo.CountTypeUsage(id)
+	if err != nil {
+		return err
+	}
+	if used > 0 {
+		return &ServiceError{Message: "resource type still has resources or requirements", Code: 409}
+	}
+
+	if err := s.resourceRepo.DeleteType(id); err != nil {
+		return err
+	}
+
+	logrus.Infof("Resource type %d deleted", id)
+	return nil
+}
+
+// ============ RESOURCE ============
+func (s *resourceService) GetResources(request model.ResourceListRequest) ([]model.Resource, error) {
+	return s.resourceRepo.Find(request.ResourceTypeID)
+}
+
+func (s *resourceService) CreateResource(request model.ResourceRequest) (*model.Resource, error) {
+	if _, err := s.findType(request.ResourceTypeID); err != nil {
+		return nil, err
+	}
+
+	resource := &model.Resource{Active: true}
+	applyResourceRequest(resource, request)
+	if err := s.resourceRepo.Create(resource); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Resource %d (%s) created", resource.ID, resource.Name)
+	return s.resourceRepo.FindByID(resource.ID)
+}
+
+// UpdateResource changes a resource. A change that upcoming appointments
+// holding the resource would no longer fit, such as deactivating it, moving
+// it to another type or shortening its hours, is refused.
+func (s *resourceService) UpdateResource(id uint, request model.ResourceRequest) (*model.Resource, error) {
+	resource, err := s.findResource(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.ResourceTypeID != resource.ResourceTypeID {
+		if _, err := s.findType(request.ResourceTypeID); err != nil {
+			return nil, err
+		}
+	}
+
+	previousTypeID := resource.ResourceTypeID
+	applyResourceRequest(resource, request)
+
+	bookings, err := s.resourceRepo.FindUpcomingBookings(id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	var stranded []model.ResourceBooking
+	if !resource.Active || resource.ResourceTypeID != previousTypeID {
+		stranded = bookings
+	} else {
+		stranded = s.unfitBookings(resource, bookings)
+	}
+	if len(stranded) > 0 {
+		return nil, upcomingBookingsError("this change", stranded)
+	}
+
+	if err := s.resourceRepo.Update(resource); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Resource %d updated", id)
+	return s.resourceRepo.FindByID(id)
+}
+
+// DeleteResource deletes a resource that no upcoming appointment holds.
+func (s *resourceService) DeleteResource(id uint) error {
+	if _, err := s.findResource(id); err != nil {
+		return err
+	}
+
+	bookings, err := s.resourceRepo.FindUpcomingBookings(id, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(bookings) > 0 {
+		return upcomingBookingsError("deleting the resource", bookings)
+	}
+
+	if err := s.resourceRepo.Delete(id); err != nil {
+		return err
+	}
+
+	logrus.Infof("Resource %d deleted", id)
+	return nil
+}
+
+// ============ RESOURCE REQUIREMENT ============
+func (s *resourceService) GetRequirements(request model.ResourceRequirementListRequest) ([]model.ResourceRequirement, error) {
+	return s.resourceRepo.FindRequirements(request.LayananTerapiID, request.TeknikTerapiID)
+}
+
+func (s *resourceService) CreateRequirement(request model.ResourceRequirementRequest) (*model.ResourceRequirement, error) {
+	if _, err := s.findType(request.ResourceTypeID); err != nil {
+		return nil, err
+	}
+	if request.LayananTerapiID != nil {
+		if _, err := s.masterRepo.FindLayananTerapiByID(*request.LayananTerapiID); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, &ServiceError{Message: "layanan terapi not found", Code: 400}
+			}
+			return nil, err
+		}
+	}
+	if request.TeknikTerapiID != nil {
+		if _, err := s.masterRepo.FindTeknikTerapiByID(*request.TeknikTerapiID); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, &ServiceError{Message: "teknik terapi not found", Code: 400}
+			}
+			return nil, err
+		}
+	}
+
+	quantity := request.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+	requirement := &model.ResourceRequirement{
+		ResourceTypeID:  request.ResourceTypeID,
+		LayananTerapiID: request.LayananTerapiID,
+		TeknikTerapiID:  request.TeknikTerapiID,
+		Quantity:        quantity,
+	}
+	if err := s.resourceRepo.CreateRequirement(requirement); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Resource requirement %d created", requirement.ID)
+	return s.resourceRepo.FindRequirementByID(requirement.ID)
+}
+
+func (s *resourceService) DeleteRequirement(id uint) error {
+	requirement, err := s.resourceRepo.FindRequirementByID(id)
+	if err != nil {
+		return err
+	}
+	if requirement == nil {
+		return &ServiceError{Message: "resource requirement not found", Code: 404}
+	}
+
+	if err := s.resourceRepo.DeleteRequirement(id); err != nil {
+		return err
+	}
+
+	logrus.Infof("Resource requirement %d deleted", id)
+	return nil
+}
+
+func (s *resourceService) findType(id uint) (*model.ResourceType, error) {
+	resourceType, err := s.resourceRepo.FindTypeByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if resourceType == nil {
+		return nil, &ServiceError{Message: "resource type not found", Code: 404}
+	}
+	return resourceType, nil
+}
+
+func (s *resourceService) findResource(id uint) (*model.Resource, error) {
+	resource, err := s.resourceRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if resource == nil {
+		return nil, &ServiceError{Message: "resource not found", Code: 404}
+	}
+	return resource, nil
+}
+
+// unfitBookings returns the bookings that fall outside the active hours of the
+// resource, or that take place while more bookings than its capacity do.
+func (s *resourceService) unfitBookings(resource *model.Resource, bookings []model.ResourceBooking) []model.ResourceBooking {
+	var unfit []model.ResourceBooking
+	for _, booking := range bookings {
+		slot := timeRange{Start: booking.StartAt, End: booking.EndAt}
+		if !resourceOpen(resource, slot, s.location) || model.PeakBookings(bookings, booking.StartAt, booking.EndAt) > resource.Capacity {
+			unfit = append(unfit, booking)
+		}
+	}
+	return unfit
+}
+
+// upcomingBookingsError refuses a change that would leave bookings without
+// their resource, naming the appointments to reschedule first.
+func upcomingBookingsError(change string, bookings []model.ResourceBooking) error {
+	ids := make([]string, 0, maxListedAppointments)
+	for i := 0; i < len(bookings) && i < maxListedAppointments; i++ {
+		ids = append(ids, strconv.FormatUint(uint64(bookings[i].AppointmentID), 10))
+	}
+	list := strings.Join(ids, ", ")
+	if len(bookings) > maxListedAppointments {
+		list += ", ..."
+	}
+	return &ServiceError{
+		Message: fmt.Sprintf("%s would affect %d upcoming appointments holding this resource, reschedule or cancel them first: %s", change, len(bookings), list),
+		Code:    409,
+	}
+}
+
+func applyResourceRequest(resource *model.Resource, request model.ResourceRequest) {
+	resource.ResourceTypeID = request.ResourceTypeID
+	resource.Name = request.Name
+	resource.Capacity = request.Capacity
+	if resource.Capacity == 0 {
+		resource.Capacity = 1
+	}
+	resource.OpenTime = request.OpenTime
+	resource.CloseTime = request.CloseTime
+	if request.Active != nil {
+		resource.Active = *request.Active
+	}
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/repository"
+)
+
+type roleService struct {
+	roleRepo   repository.RoleRepository
+	authCache  *AuthCache
+	authorizer *Authorizer
+}
+
+func NewRoleService(roleRepo repository.RoleRepository, authCache *AuthCache, authorizer *Authorizer) RoleService {
+	return &roleService{
+		roleRepo:   roleRepo,
+		authCache:  authCache,
+		authorizer: authorizer,
+	}
+}
+
+func (s *roleService) CreateRole(request model.RoleRequest, currentUserRole string) (*model.Role, error) {
+	if err := s.authorizer.Require(currentUserRole, model.PermRoleWrite); err != nil {
+		return nil, err
+	}
+
+	existing, _ := s.roleRepo.FindByName(request.Name)
+	if existing != nil {
+		return nil, &ServiceError{Message: "role already exists", Code: 400}
+	}
+
+	role := &model.Role{
+		Name:        request.Name,
+		Description: request.Description,
+		Level:       request.Level,
+	}
+
+	permissions, err := s.grantablePermissions(request.Permissions, currentUserRole)
+	if err != nil {
+		return nil, err
+	}
+	role.Permissions = permissions
+
+	if !s.authorizer.CanAssignRole(currentUserRole, role) {
+		return nil, &ServiceError{Message: "access denied: role level must be below your own", Code: 403}
+	}
+
+	if err := s.roleRepo.Create(role); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Role created: %s (level %d)", role.Name, role.Level)
+	return role, nil
+}
+
+func (s *roleService) GetRoles(currentUserRole string) ([]model.Role, error) {
+	if err := s.authorizer.Require(currentUserRole, model.PermRoleRead); err != nil {
+		return nil, err
+	}
+
+	return s.roleRepo.FindAll()
+}
+
+func (s *roleService) GetRoleByID(id uint, currentUserRole string) (*model.Role, error) {
+	if err := s.authorizer.Require(currentUserRole, model.PermRoleRead); err != nil {
+		return nil, err
+	}
+
+	role, err := s.roleRepo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, &ServiceError{Message: "role not found", Code: 404}
+		}
+		return nil, err
+	}
+	return role, nil
+}
+
+// UpdateRole replaces a role's details and permissions. Permissions of the
+// role that currentUserRole does not hold itself are kept, whether or not the
+// request lists them.
+func (s *roleService) UpdateRole(id uint, request model.RoleRequest, currentUserRole string) (*model.Role, error) {
+	if err := s.authorizer.Require(currentUserRole, model.PermRoleWrite); err != nil {
+		return nil, err
+	}
+
+	role, err := s.roleRepo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, &ServiceError{Message: "role not found", Code: 404}
+		}
+		return nil, err
+	}
+
+	if !s.authorizer.CanAssignRole(currentUserRole, role) {
+		return nil, &ServiceError{Message: "access denied: insufficient permissions", Code: 403}
+	}
+
+	oldName := role.Name
+	if request.Name != role.Name {
+		// Role bawaan dicari berdasarkan nama saat seeding
+		if role.IsSystem {
+			return nil, &ServiceError{Message: "system roles cannot be renamed", Code: 400}
+		}
+
+		existing, _ := s.roleRepo.FindByName(request.Name)
+		if existing != nil {
+			return nil, &ServiceError{Message: "role already exists", Code: 400}
+		}
+	}
+
+	// Permission yang tidak dimiliki pengubah tidak bisa ia berikan, jadi juga tidak ia cabut
+	kept := make(map[string]bool)
+	var keptPermissions []model.Permission
+	for _, p := range role.Permissions {
+		if !s.authorizer.Can(currentUserRole, p.Name) {
+			kept[p.Name] = true
+			keptPermissions = append(keptPermissions, p)
+		}
+	}
+	var names []string
+	for _, name := range request.Permissions {
+		if !kept[name] {
+			names = append(names, name)
+		}
+	}
+
+	permissions, err := s.grantablePermissions(names, currentUserRole)
+	if err != nil {
+		return nil, err
+	}
+
+	role.Name = request.Name
+	role.Description = request.Description
+	role.Level = request.Level
+	role.Permissions = append(permissions, keptPermissions...)
+
+	if !s.authorizer.CanAssignRole(currentUserRole, role) {
+		return nil, &ServiceError{Message: "access denied: role level must be below your own", Code: 403}
+	}
+
+	if err := s.roleRepo.Update(role); err != nil {
+		return nil, err
+	}
+	s.authCache.InvalidateRole(oldName)
+	if oldName != role.Name {
+		s.authCache.InvalidateRole(role.Name)
+	}
+
+	logrus.Infof("Role updated: %s (level %d)", role.Name, role.Level)
+	return role, nil
+}
+
+func (s *roleService) DeleteRole(id uint, currentUserRole string) error {
+	if err := s.authorizer.Require(currentUserRole, model.PermRoleWrite); err != nil {
+		return err
+	}
+
+	role, err := s.roleRepo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &ServiceError{Message: "role not found", Code: 404}
+		}
+		return err
+	}
+
+	if role.IsSystem {
+		return &ServiceError{Message: "system roles cannot be deleted", Code: 400}
+	}
+
+	if !s.authorizer.CanAssignRole(currentUserRole, role) {
+		return &ServiceError{Message: "access denied: insufficient permissions", Code: 403}
+	}
+
+	users, err := s.roleRepo.CountUsers(id)
+	if err != nil {
+		return err
+	}
+	if users > 0 {
+		return &ServiceError{Message: fmt.Sprintf("role is still assigned to %d users", users), Code: 400}
+	}
+
+	if err := s.roleRepo.Delete(id); err != nil {
+		return err
+	}
+	s.authCache.InvalidateRole(role.Name)
+
+	logrus.Infof("Role deleted: %s", role.Name)
+	return nil
+}
+
+func (s *roleService) GetPermissions(currentUserRole string) ([]model.Permission, error) {
+	if err := s.authorizer.Require(currentUserRole, model.PermRoleRead); err != nil {
+		return nil, err
+	}
+
+	return s.roleRepo.FindAllPermissions()
+}
+
+// grantablePermissions resolves permission names, refusing unknown ones and
+// ones the current role does not hold itself.
+func (s *roleService) grantablePermissions(names []string, currentUserRole string) ([]model.Permission, error) {
+	permissions, err := s.roleRepo.FindPermissionsByNames(names)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool, len(permissions))
+	for _, p := range permissions {
+		found[p.Name] = true
+	}
+
+	for _, name := range names {
+		if !found[name] {
+			return nil, &ServiceError{Message: fmt.Sprintf("unknown permission: %s", name), Code: 400}
+		}
+		if !s.authorizer.Can(currentUserRole, name) {
+			return nil, &ServiceError{Message: fmt.Sprintf("access denied: cannot grant %s", name), Code: 403}
+		}
+	}
+
+	return permissions, nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// stubRoleRepository keeps roles in memory by name.
+type stubRoleRepository struct {
+	repository.RoleRepository
+	roles map[string]*model.Role
+}
+
+func (r *stubRoleRepository) FindByID(id uint) (*model.Role, error) {
+	for _, role := range r.roles {
+		if role.ID == id {
+			copied := *role
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *stubRoleRepository) FindByName(name string) (*model.Role, error) {
+	role, ok := r.roles[name]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *role
+	return &copied, nil
+}
+
+func (r *stubRoleRepository) FindPermissionsByNames(names []string) ([]model.Permission, error) {
+	permissions := make([]model.Permission, len(names))
+	for i, name := range names {
+		permissions[i] = model.Permission{Name: name}
+	}
+	return permissions, nil
+}
+
+func (r *stubRoleRepository) Update(role *model.Role) error {
+	r.roles[role.Name] = role
+	return nil
+}
+
+func permissionsOf(names ...string) []model.Permission {
+	permissions := make([]model.Permission, len(names))
+	for i, name := range names {
+		permissions[i] = model.Permission{Name: name}
+	}
+	return permissions
+}
+
+func TestUpdateRoleKeepsPermissionsTheActorLacks(t *testing.T) {
+	repo := &stubRoleRepository{roles: map[string]*model.Role{
+		"admin": {
+			ID: 1, Name: "admin", Level: 50,
+			Permissions: permissionsOf(model.PermRoleWrite, model.PermCustomerRead, model.PermCustomerWrite),
+		},
+		"front_desk": {
+			ID: 2, Name: "front_desk", Level: 10,
+			Permissions: permissionsOf(model.PermCustomerRead, model.PermMedicalRead),
+		},
+	}}
+	authCache := NewAuthCache(10, time.Minute, nil)
+	s := NewRoleService(repo, authCache, NewAuthorizer(repo, authCache))
+
+	role, err := s.UpdateRole(2, model.RoleRequest{
+		Name:        "front_desk",
+		Level:       10,
+		Permissions: []string{model.PermCustomerWrite},
+	}, "admin")
+	if err != nil {
+		t.Fatalf("UpdateRole: %v", err)
+	}
+
+	var got []string
+	for _, p := range role.Permissions {
+		got = append(got, p.Name)
+	}
+	sort.Strings(got)
+	want := []string{model.PermCustomerWrite, model.PermMedicalRead}
+	sort.Strings(want)
+	if !slices.Equal(got, want) {
+		t.Fatalf("permissions = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/repository"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type scheduleService struct {
+	scheduleRepo repository.ScheduleRepository
+	userRepo     repository.UserRepository
+	authorizer   *Authorizer
+	location     *time.Location
+}
+
+func NewScheduleService(scheduleRepo repository.ScheduleRepository, userRepo repository.UserRepository, authorizer *Authorizer, location *time.Location) ScheduleService {
+	if location == nil {
+		location = time.Local
+	}
+	return &scheduleService{
+		scheduleRepo: scheduleRepo,
+		userRepo:     userRepo,
+		authorizer:   authorizer,
+		location:     location,
+	}
+}
+
+func (s *scheduleService) GetWorkingHours(therapistID uint) ([]model.WorkingHour, error) {
+	return s.scheduleRepo.FindWorkingHours(therapistID)
+}
+
+func (s *scheduleService) CreateWorkingHour(request model.WorkingHourRequest) (*model.WorkingHour, error) {
+	if err := checkTherapist(s.userRepo, s.authorizer, request.TherapistID); err != nil {
+		return nil, err
+	}
+
+	workingHour := &model.WorkingHour{
+		TherapistID: request.TherapistID,
+		Weekday:     request.Weekday,
+		StartTime:   request.StartTime,
+		EndTime:     request.EndTime,
+	}
+	if err := s.checkOverlap(workingHour); err != nil {
+		return nil, err
+	}
+
+	if err := s.scheduleRepo.CreateWorkingHour(workingHour); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Working hour %d added for therapist %d", workingHour.ID, workingHour.TherapistID)
+	return s.scheduleRepo.FindWorkingHourByID(workingHour.ID)
+}
+
+func (s *scheduleService) UpdateWorkingHour(id uint, request model.WorkingHourRequest) (*model.WorkingHour, error) {
+	workingHour, err := s.findWorkingHour(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.TherapistID != workingHour.TherapistID {
+		if err := checkTherapist(s.userRepo, s.authorizer, request.TherapistID); err != nil {
+			return nil, err
+		}
+	}
+
+	workingHour.TherapistID = request.TherapistID
+	workingHour.Weekday = request.Weekday
+	workingHour.StartTime = request.StartTime
+	workingHour.EndTime = request.EndTime
+	if err := s.checkOverlap(workingHour); err != nil {
+		return nil, err
+	}
+
+	if err := s.scheduleRepo.UpdateWorkingHour(workingHour); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Working hour %d updated", id)
+	return s.scheduleRepo.FindWorkingHourByID(id)
+}
+
+func (s *scheduleService) DeleteWorkingHour(id uint) error {
+	if _, err := s.findWorkingHour(id); err != nil {
+		return err
+	}
+
+	if err := s.scheduleRepo.DeleteWorkingHour(id); err != nil {
+		return err
+	}
+
+	logrus.Infof("Working hour %d deleted", id)
+	return nil
+}
+
+func (s *scheduleService) GetExceptions(request model.ScheduleListRequest) ([]model.ScheduleException, error) {
+	var from, to time.Time
+	if request.From != "" {
+		from, _ = time.ParseInLocation(model.DateLayout, request.From, s.location)
+	}
+	if request.To != "" {
+		to, _ = time.ParseInLocation(model.DateLayout, request.To, s.location)
+		to = to.AddDate(0, 0, 1)
+	}
+	return s.scheduleRepo.FindExceptions(request.TherapistID, from, to)
+}
+
+// CreateException blocks time for leave or a holiday. Appointments already
+// booked in that time are kept and have to be rescheduled by hand.
+func (s *scheduleService) CreateException(request model.ScheduleExceptionRequest, currentUserID uint) (*model.ScheduleException, error) {
+	if request.TherapistID != nil {
+		if err := checkTherapist(s.userRepo, s.authorizer, *request.TherapistID); err != nil {
+			return nil, err
+		}
+	}
+
+	exception := &model.ScheduleException{
+		TherapistID: request.TherapistID,
+		Kind:        request.Kind,
+		StartAt:     request.StartAt,
+		EndAt:       request.EndAt,
+		Reason:      request.Reason,
+		CreatedBy:   currentUserID,
+	}
+	if err := s.scheduleRepo.CreateException(exception); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Schedule exception %d (%s) added by user %d", exception.ID, exception.Kind, currentUserID)
+	return exception, nil
+}
+
+func (s *scheduleService) DeleteException(id uint) error {
+	exception, err := s.scheduleRepo.FindExceptionByID(id)
+	if err != nil {
+		return err
+	}
+	if exception == nil {
+		return &ServiceError{Message: "schedule exception not found", Code: 404}
+	}
+
+	if err := s.scheduleRepo.DeleteException(id); err != nil {
+		return err
+	}
+
+	logrus.Infof("Schedule exception %d deleted", id)
+	return nil
+}
+
+// checkOverlap rejects a block that overlaps another block of the same therapist.
+func (s *scheduleService) checkOverlap(workingHour *model.WorkingHour) error {
+	existing, err := s.scheduleRepo.FindWorkingHoursByWeekday(workingHour.TherapistID, time.Weekday(workingHour.Weekday))
+	if err != nil {
+		return err
+	}
+
+	for i := range existing {
+		if existing[i].ID != workingHour.ID && existing[i].Overlaps(workingHour) {
+			return &ServiceError{Message: "working hours overlap an existing block of this therapist", Code: 409}
+		}
+	}
+	return nil
+}
+
+func (s *scheduleService) findWorkingHour(id uint) (*model.WorkingHour, error) {
+	workingHour, err := s.scheduleRepo.FindWorkingHourByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if workingHour == nil {
+		return nil, &ServiceError{Message: "working hour not found", Code: 404}
+	}
+	return workingHour, nil
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"sim-clinic-api/internal/repository"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RunTokenJanitor periodically purges expired blacklist entries, refresh
+// tokens, password reset tokens and sessions until ctx is cancelled.
+func RunTokenJanitor(
+	ctx context.Context,
+	tokenRepo repository.TokenRepository,
+	sessionRepo repository.SessionRepository,
+	interval time.Duration,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := tokenRepo.CleanExpiredTokens(); err != nil {
+				logrus.Errorf("Failed to clean expired tokens: %v", err)
+			}
+			if err := sessionRepo.DeleteExpired(); err != nil {
+				logrus.Errorf("Failed to clean expired sessions: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"fmt"
+	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/repository"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type treatmentSessionService struct {
+	treatmentSessionRepo repository.TreatmentSessionRepository
+	historyRepo          repository.MedicalHistoryRepository
+	customerRepo         repository.CustomerRepository
+	masterRepo           repository.MasterDataRepository
+	userRepo             repository.UserRepository
+	clinicalNoteRepo     repository.ClinicalNoteRepository
+	authorizer           *Authorizer
+}
+
+func NewTreatmentSessionService(
+	treatmentSessionRepo repository.TreatmentSessionRepository,
+	historyRepo repository.MedicalHistoryRepository,
+	customerRepo repository.CustomerRepository,
+	masterRepo repository.MasterDataRepository,
+	userRepo repository.UserRepository,
+	clinicalNoteRepo repository.ClinicalNoteRepository,
+	authorizer *Authorizer,
+) TreatmentSessionService {
+	return &treatmentSessionService{
+		treatmentSessionRepo: treatmentSessionRepo,
+		historyRepo:          historyRepo,
+		customerRepo:         customerRepo,
+		masterRepo:           masterRepo,
+		userRepo:             userRepo,
+		clinicalNoteRepo:     clinicalNoteRepo,
+		authorizer:           authorizer,
+	}
+}
+
+func (s *treatmentSessionService) GetTreatmentSessions(customerID string, request model.TimelineRequest) ([]model.TreatmentSession, error) {
+	if _, err := findCustomer(s.customerRepo, customerID, 404); err != nil {
+		return nil, err
+	}
+
+	from, to := timelineRange(request)
+	return s.treatmentSessionRepo.FindByCustomer(customerID, from, to)
+}
+
+func (s *treatmentSessionService) GetTreatmentSession(customerID string, id uint) (*model.TreatmentSession, error) {
+	if _, err := findCustomer(s.customerRepo, customerID, 404); err != nil {
+		return nil, err
+	}
+
+	return findTreatmentSession(s.treatmentSessionRepo, customerID, id)
+}
+
+func (s *treatmentSessionService) CreateTreatmentSession(customerID string, request model.TreatmentSessionRequest, recordedBy uint) (*model.TreatmentSession, error) {
+	if _, err := findCustomer(s.customerRepo, customerID, 404); err != nil {
+		return nil, err
+	}
+
+	session := &model.TreatmentSession{CustomerID: customerID}
+	if err := s.apply(session, request, recordedBy); err != nil {
+		return nil, err
+	}
+
+	if err := s.treatmentSessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Treatment session %d recorded for customer %s by user %d", session.ID, customerID, recordedBy)
+	return s.treatmentSessionRepo.FindByID(customerID, session.ID)
+}
+
+func (s *treatmentSessionService) UpdateTreatmentSession(customerID string, id uint, request model.TreatmentSessionRequest, recordedBy uint) (*model.TreatmentSession, error) {
+	session, err := s.GetTreatmentSession(customerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNoteNotSigned(id); err != nil {
+		return nil, err
+	}
+
+	if err := s.apply(session, request, recordedBy); err != nil {
+		return nil, err
+	}
+
+	if err := s.treatmentSessionRepo.Update(session); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Treatment session %d of customer %s updated by user %d", id, customerID, recordedBy)
+	return s.treatmentSessionRepo.FindByID(customerID, id)
+}
+
+func (s *treatmentSessionService) DeleteTreatmentSession(customerID string, id uint) error {
+	if _, err := s.GetTreatmentSession(customerID, id); err != nil {
+		return err
+	}
+	if err := s.checkNoteNotSigned(id); err != nil {
+		return err
+	}
+
+	if err := s.treatmentSessionRepo.Delete(id); err != nil {
+		return err
+	}
+
+	logrus.Infof("Treatment session %d of customer %s deleted", id, customerID)
+	return nil
+}
+
+// GetTimeline merges the treatment sessions and medical history of a customer
+// into one list, newest first.
+func (s *treatmentSessionService) GetTimeline(customerID string, request model.TimelineRequest) ([]model.TimelineEntry, error) {
+	if _, err := findCustomer(s.customerRepo, customerID, 404); err != nil {
+		return nil, err
+	}
+
+	from, to := timelineRange(request)
+	sessions, err := s.treatmentSessionRepo.FindByCustomer(customerID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	histories, err := s.historyRepo.FindByCustomer(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]model.TimelineEntry, 0, len(sessions)+len(histories))
+	for i := range sessions {
+		entries = append(entries, model.TimelineEntry{
+			Type:             model.TimelineTreatmentSession,
+			OccurredAt:       sessions[i].StartedAt,
+			TreatmentSession: &sessions[i],
+		})
+	}
+	for i := range histories {
+		// Riwayat tanpa tanggal mulai ditempatkan saat dicatat
+		occurredAt := histories[i].CreatedAt
+		if histories[i].OnsetDate != nil {
+			occurredAt = *histories[i].OnsetDate
+		}
+		if (!from.IsZero() && occurredAt.Before(from)) || (!to.IsZero() && !occurredAt.Before(to)) {
+			continue
+		}
+		entries = append(entries, model.TimelineEntry{
+			Type:           model.TimelineMedicalHistory,
+			OccurredAt:     occurredAt,
+			MedicalHistory: &histories[i],
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].OccurredAt.After(entries[j].OccurredAt)
+	})
+	return entries, nil
+}
+
+// apply validates the references of the request and copies it onto the session.
+// Master data that was deleted may stay on an existing session but cannot be
+// newly assigned.
+func (s *treatmentSessionService) apply(session *model.TreatmentSession, request model.TreatmentSessionRequest, recordedBy uint) error {
+	if request.TherapistID != session.TherapistID {
+		if err := checkTherapist(s.userRepo, s.authorizer, request.TherapistID); err != nil {
+			return err
+		}
+	}
+
+	if request.LayananTerapiID != session.LayananTerapiID {
+		if _, err := s.masterRepo.FindLayananTerapiByID(request.LayananTerapiID); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return &ServiceError{Message: "layanan terapi not found", Code: 400}
+			}
+			return err
+		}
+	}
+
+	current := make(map[uint]model.TeknikTerapi, len(session.TeknikTerapi))
+	for _, teknik := range session.TeknikTerapi {
+		current[teknik.ID] = teknik
+	}
+	var newIDs []uint
+	for _, id := range request.TeknikTerapiIDs {
+		if _, ok := current[id]; !ok {
+			newIDs = append(newIDs, id)
+		}
+	}
+	found := map[uint]model.TeknikTerapi{}
+	if len(newIDs) > 0 {
+		teks, err := s.masterRepo.FindTeknikTerapiByIDs(newIDs)
+		if err != nil {
+			return err
+		}
+		for _, teknik := range teks {
+			found[teknik.ID] = teknik
+		}
+	}
+
+	teknikTerapi := make([]model.TeknikTerapi, 0, len(request.TeknikTerapiIDs))
+	for _, id := range request.TeknikTerapiIDs {
+		if teknik, ok := current[id]; ok {
+			teknikTerapi = append(teknikTerapi, teknik)
+			continue
+		}
+		teknik, ok := found[id]
+		if !ok {
+			return &ServiceError{Message: fmt.Sprintf("teknik terapi %d not found", id), Code: 400}
+		}
+		teknikTerapi = append(teknikTerapi, teknik)
+	}
+
+	session.TherapistID = request.TherapistID
+	session.StartedAt = request.StartedAt
+	session.DurationMinutes = request.DurationMinutes
+	session.LayananTerapiID = request.LayananTerapiID
+	session.TeknikTerapi = teknikTerapi
+	session.Complaint = request.Complaint
+	session.Outcome = request.Outcome
+	session.RecordedBy = recordedBy
+	return nil
+}
+
+// checkNoteNotSigned rejects changes to a session whose clinical note is signed.
+func (s *treatmentSessionService) checkNoteNotSigned(sessionID uint) error {
+	note, err := s.clinicalNoteRepo.FindBySession(sessionID)
+	if err != nil {
+		return err
+	}
+	if note != nil && note.IsSigned() {
+		return &ServiceError{Message: "treatment session has a signed clinical note and cannot be changed", Code: 409}
+	}
+	return nil
+}
+
+// timelineRange turns the from/to dates of a request into a half-open range;
+// to is inclusive, so the range ends at the start of the following day.
+func timelineRange(request model.TimelineRequest) (from, to time.Time) {
+	if request.From != "" {
+		from, _ = time.ParseInLocation(model.DateLayout, request.From, time.Local)
+	}
+	if request.To != "" {
+		to, _ = time.ParseInLocation(model.DateLayout, request.To, time.Local)
+		to = to.AddDate(0, 0, 1)
+	}
+	return from, to
+}
@@ -1,54 +1,66 @@
 package service
 
 import (
+	"errors"
+	"fmt"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 	"sim-clinic-api/internal/model"
+	"sim-clinic-api/internal/notification"
 	"sim-clinic-api/internal/repository"
+	"sim-clinic-api/internal/utils"
+	"time"
 )
 
 type userService struct {
-	userRepo repository.UserRepository
+	userRepo    repository.UserRepository
+	roleRepo    repository.RoleRepository
+	tokenRepo   repository.TokenRepository
+	sessionRepo repository.SessionRepository
+	notifier    notification.Notifier
+	resetExpire time.Duration
+	authCache   *AuthCache
+	authorizer  *Authorizer
 }
 
-func NewUserService(userRepo repository.UserRepository) UserService {
-	return &userService{userRepo: userRepo}
+func NewUserService(
+	userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository,
+	tokenRepo repository.TokenRepository,
+	sessionRepo repository.SessionRepository,
+	notifier notification.Notifier,
+	resetExpire time.Duration,
+	authCache *AuthCache,
+	authorizer *Authorizer,
+) UserService {
+	return &userService{
+		userRepo:    userRepo,
+		roleRepo:    roleRepo,
+		tokenRepo:   tokenRepo,
+		sessionRepo: sessionRepo,
+		notifier:    notifier,
+		resetExpire: resetExpire,
+		authCache:   authCache,
+		authorizer:  authorizer,
+	}
 }
 
 func (s *userService) GetAllUsers(currentUserRole string, page, limit, search string) ([]model.User, int64, error) {
-	switch currentUserRole {
-	case "super_admin":
-		// Super admin bisa melihat semua user
-		users, total, err := s.userRepo.FindAll(page, limit, search)
-		if err != nil {
-			return nil, 0, err
-		}
-		return users, total, nil
-
-	case "admin":
-		// Admin bisa melihat admin dan user (tidak bisa melihat super_admin)
-		users, err := s.userRepo.FindByRoles([]string{"admin", "user"})
-		if err != nil {
-			return nil, 0, err
-		}
-		return users, 0, nil
-
-	case "user":
-		// User tidak boleh melihat user lain
-		return nil, 0, &ServiceError{
-			Message: "access denied: insufficient permissions",
-			Code:    403,
-		}
+	if err := s.authorizer.Require(currentUserRole, model.PermUserRead); err != nil {
+		return nil, 0, err
+	}
 
-	default:
-		return nil, 0, &ServiceError{
-			Message: "invalid user role",
-			Code:    400,
-		}
+	// Tanpa user:manage_all, user dengan role di atas level sendiri disembunyikan
+	var maxRoleLevel *int
+	if !s.authorizer.Can(currentUserRole, model.PermUserManageAll) {
+		level := s.authorizer.Level(currentUserRole)
+		maxRoleLevel = &level
 	}
+
+	return s.userRepo.FindAll(page, limit, search, maxRoleLevel)
 }
 
-func (s *userService) GetUserByID(id uint, currentUserRole string) (*model.User, error) {
+func (s *userService) GetUserByID(id uint, currentUserRole string, currentUserID uint) (*model.User, error) {
 	user, err := s.userRepo.FindByID(id)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -58,7 +70,7 @@ func (s *userService) GetUserByID(id uint, currentUserRole string) (*model.User,
 	}
 
 	// Authorization check
-	if !s.hasPermission(currentUserRole, user.Role.Name) {
+	if id != currentUserID && !s.canViewUser(currentUserRole, user) {
 		return nil, &ServiceError{
 			Message: "access denied: insufficient permissions",
 			Code:    403,
@@ -78,8 +90,8 @@ func (s *userService) UpdateUser(id uint, request model.UpdateUserRequest, curre
 		return nil, err
 	}
 
-	// Authorization check
-	if !s.canUpdateUser(currentUserRole, currentUserID, targetUser, request.RoleID) {
+	// Authorization check, setiap user boleh mengubah profilnya sendiri
+	if id != currentUserID && !s.authorizer.CanManageUser(currentUserRole, model.PermUserWrite, targetUser) {
 		return nil, &ServiceError{
 			Message: "access denied: insufficient permissions",
 			Code:    403,
@@ -105,22 +117,43 @@ func (s *userService) UpdateUser(id uint, request model.UpdateUserRequest, curre
 		targetUser.Email = *request.Email
 	}
 
-	if request.RoleID != nil {
+	roleChanged := false
+	if request.RoleID != nil && *request.RoleID != targetUser.RoleID {
 		// Validate new role exists
-		_, err := s.userRepo.FindByID(*request.RoleID)
+		newRole, err := s.roleRepo.FindByID(*request.RoleID)
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
 				return nil, &ServiceError{Message: "role not found", Code: 400}
 			}
 			return nil, err
 		}
-		targetUser.RoleID = *request.RoleID
+
+		if !s.canChangeRole(currentUserRole, currentUserID, targetUser, newRole) {
+			return nil, &ServiceError{
+				Message: "access denied: insufficient permissions",
+				Code:    403,
+			}
+		}
+
+		roleChanged = true
+		targetUser.RoleID = newRole.ID
+		targetUser.Role = *newRole
 	}
 
 	// Save updates
 	if err := s.userRepo.Update(targetUser); err != nil {
 		return nil, err
 	}
+	s.authCache.InvalidateUser(id)
+
+	// Sesi lama masih membawa hak akses role sebelumnya
+	if roleChanged {
+		ids, err := s.sessionRepo.RevokeAllByUser(id, "role_changed")
+		if err != nil {
+			return nil, err
+		}
+		s.authCache.InvalidateSessions(ids)
+	}
 
 	// Reload user with role data
 	updatedUser, err := s.userRepo.FindByID(id)
@@ -143,7 +176,7 @@ func (s *userService) DeleteUser(id uint, currentUserRole string, currentUserID
 	}
 
 	// Authorization check
-	if !s.canDeleteUser(currentUserRole, currentUserID, targetUser) {
+	if !s.authorizer.CanManageUser(currentUserRole, model.PermUserDelete, targetUser) {
 		return &ServiceError{
 			Message: "access denied: insufficient permissions",
 			Code:    403,
@@ -158,93 +191,277 @@ func (s *userService) DeleteUser(id uint, currentUserRole string, currentUserID
 	if err := s.userRepo.Delete(id); err != nil {
 		return err
 	}
+	s.authCache.InvalidateUser(id)
+
+	ids, err := s.sessionRepo.RevokeAllByUser(id, "user_deleted")
+	if err != nil {
+		return err
+	}
+	s.authCache.InvalidateSessions(ids)
 
 	logrus.Infof("User %d deleted user %d successfully", currentUserID, id)
 	return nil
 }
 
-// hasPermission checks if current user role has permission to access target user role
-func (s *userService) hasPermission(currentRole, targetRole string) bool {
-	roleHierarchy := map[string]int{
-		"super_admin": 3,
-		"admin":       2,
-		"user":        1,
+func (s *userService) ChangePassword(userID uint, request model.ChangePasswordRequest) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &ServiceError{Message: "user not found", Code: 404}
+		}
+		return err
 	}
 
-	currentLevel, currentOk := roleHierarchy[currentRole]
-	targetLevel, targetOk := roleHierarchy[targetRole]
+	if !utils.CheckPasswordHash(request.CurrentPassword, user.Password) {
+		return &ServiceError{Message: "current password is incorrect", Code: 400}
+	}
 
-	if !currentOk || !targetOk {
-		return false
+	if request.CurrentPassword == request.NewPassword {
+		return &ServiceError{Message: "new password must be different from current password", Code: 400}
+	}
+
+	if err := s.setPassword(user, request.NewPassword, "password_changed"); err != nil {
+		return err
 	}
 
-	// Current user can only access users with equal or lower level
-	return currentLevel >= targetLevel
+	logrus.Infof("User %d changed their password", userID)
+	return nil
 }
 
-// Helper function to get role hierarchy level
-func (s *userService) getRoleLevel(role string) int {
-	roleLevels := map[string]int{
-		"super_admin": 3,
-		"admin":       2,
-		"user":        1,
+func (s *userService) RequestPasswordReset(id uint, currentUserRole string, currentUserID uint) error {
+	targetUser, err := s.userRepo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &ServiceError{Message: "user not found", Code: 404}
+		}
+		return err
+	}
+
+	if !s.authorizer.CanManageUser(currentUserRole, model.PermUserWrite, targetUser) {
+		return &ServiceError{
+			Message: "access denied: insufficient permissions",
+			Code:    403,
+		}
+	}
+
+	// Token lama yang belum terpakai tidak berlaku lagi
+	if err := s.tokenRepo.InvalidateUserPasswordResetTokens(targetUser.ID); err != nil {
+		return err
+	}
+
+	rawToken, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return err
+	}
+
+	resetToken := &model.PasswordResetToken{
+		TokenHash:   utils.HashToken(rawToken),
+		UserID:      targetUser.ID,
+		RequestedBy: currentUserID,
+		ExpiresAt:   time.Now().Add(s.resetExpire),
+	}
+	if err := s.tokenRepo.CreatePasswordResetToken(resetToken); err != nil {
+		return err
+	}
+
+	err = s.notifier.Send(notification.Message{
+		Channel: notification.ChannelEmail,
+		To:      targetUser.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nAn administrator has requested a password reset for your account.\n"+
+				"Use this token to set a new password: %s\n\nThe token expires at %s and can only be used once.",
+			targetUser.Username, rawToken, resetToken.ExpiresAt.Format(time.RFC1123),
+		),
+	})
+	if err != nil {
+		return err
 	}
-	return roleLevels[role]
+
+	logrus.Infof("User %d requested password reset for user %d", currentUserID, id)
+	return nil
 }
 
-func (s *userService) canUpdateUser(currentRole string, currentUserID uint, targetUser *model.User, newRoleID *uint) bool {
-	roleHierarchy := map[string]int{
-		"super_admin": 3,
-		"admin":       2,
-		"user":        1,
+func (s *userService) ResetPassword(request model.ResetPasswordRequest) error {
+	resetToken, err := s.tokenRepo.FindPasswordResetTokenByHash(utils.HashToken(request.Token))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &ServiceError{Message: "invalid or expired reset token", Code: 400}
+		}
+		return err
 	}
 
-	//currentLevel := roleHierarchy[currentRole]
-	targetLevel := roleHierarchy[targetUser.Role.Name]
+	if resetToken.UsedAt != nil || time.Now().After(resetToken.ExpiresAt) {
+		return &ServiceError{Message: "invalid or expired reset token", Code: 400}
+	}
 
-	// Super admin can update anyone
-	if currentRole == "super_admin" {
-		return true
+	user, err := s.userRepo.FindByID(resetToken.UserID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &ServiceError{Message: "invalid or expired reset token", Code: 400}
+		}
+		return err
+	}
+
+	if err := s.tokenRepo.MarkPasswordResetTokenUsed(resetToken.ID); err != nil {
+		if errors.Is(err, repository.ErrPasswordResetTokenUsed) {
+			return &ServiceError{Message: "invalid or expired reset token", Code: 400}
+		}
+		return err
+	}
+
+	if err := s.setPassword(user, request.NewPassword, "password_reset"); err != nil {
+		return err
 	}
 
-	// Admin can update themselves and users
-	if currentRole == "admin" {
-		// Admin can update themselves
-		if currentUserID == targetUser.ID {
-			return true
+	logrus.Infof("User %d reset their password", user.ID)
+	return nil
+}
+
+func (s *userService) UnlockUser(id uint, currentUserRole string, currentUserID uint) error {
+	targetUser, err := s.userRepo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &ServiceError{Message: "user not found", Code: 404}
+		}
+		return err
+	}
+
+	if !s.authorizer.CanManageUser(currentUserRole, model.PermUserWrite, targetUser) {
+		return &ServiceError{
+			Message: "access denied: insufficient permissions",
+			Code:    403,
+		}
+	}
+
+	if err := s.userRepo.ResetFailedLogins(id); err != nil {
+		return err
+	}
+	s.authCache.InvalidateUser(id)
+
+	logrus.Infof("User %d unlocked user %d", currentUserID, id)
+	return nil
+}
+
+func (s *userService) GetMySessions(userID uint, currentSessionID string) ([]model.UserSession, error) {
+	sessions, err := s.sessionRepo.FindActiveByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+func (s *userService) RevokeMySession(userID uint, sessionID string) error {
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &ServiceError{Message: "session not found", Code: 404}
+		}
+		return err
+	}
+
+	// Jangan bocorkan keberadaan sesi milik user lain
+	if session.UserID != userID {
+		return &ServiceError{Message: "session not found", Code: 404}
+	}
+
+	if err := s.sessionRepo.Revoke(sessionID, "revoked_by_user"); err != nil {
+		return err
+	}
+	s.authCache.InvalidateSession(sessionID)
+
+	logrus.Infof("User %d revoked session %s", userID, sessionID)
+	return nil
+}
+
+func (s *userService) RevokeAllMySessions(userID uint) error {
+	ids, err := s.sessionRepo.RevokeAllByUser(userID, "logout_everywhere")
+	if err != nil {
+		return err
+	}
+	s.authCache.InvalidateSessions(ids)
+
+	logrus.Infof("User %d logged out of %d sessions", userID, len(ids))
+	return nil
+}
+
+func (s *userService) RevokeUserSessions(id uint, currentUserRole string, currentUserID uint) error {
+	targetUser, err := s.userRepo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &ServiceError{Message: "user not found", Code: 404}
+		}
+		return err
+	}
+
+	if !s.authorizer.CanManageUser(currentUserRole, model.PermUserWrite, targetUser) {
+		return &ServiceError{
+			Message: "access denied: insufficient permissions",
+			Code:    403,
 		}
-		// Admin can update users (but not other admins or super_admins)
-		return targetLevel <= roleHierarchy["user"]
 	}
 
-	// User can only update themselves
-	if currentRole == "user" {
-		return currentUserID == targetUser.ID
+	ids, err := s.sessionRepo.RevokeAllByUser(id, "revoked_by_admin")
+	if err != nil {
+		return err
 	}
+	s.authCache.InvalidateSessions(ids)
 
-	return false
+	logrus.Infof("User %d revoked %d sessions of user %d", currentUserID, len(ids), id)
+	return nil
 }
 
-func (s *userService) canDeleteUser(currentRole string, currentUserID uint, targetUser *model.User) bool {
-	roleHierarchy := map[string]int{
-		"super_admin": 3,
-		"admin":       2,
-		"user":        1,
+// setPassword stores a new password hash and revokes every token issued to the user so far.
+func (s *userService) setPassword(user *model.User, password, reason string) error {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
 	}
 
-	//currentLevel := roleHierarchy[currentRole]
-	targetLevel := roleHierarchy[targetUser.Role.Name]
+	now := time.Now()
+	user.Password = hashedPassword
+	user.PasswordChangedAt = &now
+	user.TokenVersion++
 
-	// Super admin can delete anyone except themselves
-	if currentRole == "super_admin" {
-		return currentUserID != targetUser.ID
+	if err := s.userRepo.Update(user); err != nil {
+		return err
 	}
+	s.authCache.InvalidateUser(user.ID)
 
-	// Admin can only delete users
-	if currentRole == "admin" {
-		return targetLevel <= roleHierarchy["user"]
+	ids, err := s.sessionRepo.RevokeAllByUser(user.ID, reason)
+	if err != nil {
+		return err
+	}
+	s.authCache.InvalidateSessions(ids)
+
+	if err := s.tokenRepo.RevokeUserRefreshTokens(user.ID, reason); err != nil {
+		return err
+	}
+
+	return s.tokenRepo.InvalidateUserPasswordResetTokens(user.ID)
+}
+
+// canViewUser checks if the current role may see a user of the target's role level
+func (s *userService) canViewUser(currentRole string, targetUser *model.User) bool {
+	if !s.authorizer.Can(currentRole, model.PermUserRead) {
+		return false
+	}
+	if s.authorizer.Can(currentRole, model.PermUserManageAll) {
+		return true
+	}
+	return targetUser.Role.Level <= s.authorizer.Level(currentRole)
+}
+
+// canChangeRole checks if the current role may move targetUser to newRole
+func (s *userService) canChangeRole(currentRole string, currentUserID uint, targetUser *model.User, newRole *model.Role) bool {
+	// Mengganti role sendiri hanya untuk pemegang user:manage_all
+	if targetUser.ID == currentUserID && !s.authorizer.Can(currentRole, model.PermUserManageAll) {
+		return false
 	}
 
-	// User cannot delete anyone
-	return false
+	return s.authorizer.Can(currentRole, model.PermUserWrite) && s.authorizer.CanAssignRole(currentRole, newRole)
 }
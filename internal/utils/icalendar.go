@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// icalLineLimit is the longest content line RFC 5545 allows, in octets.
+const icalLineLimit = 75
+
+const icalTimeLayout = "20060102T150405Z"
+
+// ICalEvent is one VEVENT of a calendar feed. Times are written in UTC.
+type ICalEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time
+	// Modified is when the event last changed; clients use it to update their copy
+	Modified time.Time
+	// Cancelled events stay in the feed so clients remove their copy
+	Cancelled bool
+}
+
+// WriteICalendar renders an RFC 5545 calendar with the given events. Calendar
+// apps are asked to refresh it every refresh.
+func WriteICalendar(prodID, name string, refresh time.Duration, events []ICalEvent) []byte {
+	var b strings.Builder
+	line := func(property, value string) {
+		writeICalLine(&b, property+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", prodID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", escapeICalText(name))
+	line("REFRESH-INTERVAL;VALUE=DURATION", icalDuration(refresh))
+	line("X-PUBLISHED-TTL", icalDuration(refresh))
+	for _, event := range events {
+		line("BEGIN", "VEVENT")
+		line("UID", event.UID)
+		line("DTSTAMP", event.Modified.UTC().Format(icalTimeLayout))
+		line("LAST-MODIFIED", event.Modified.UTC().Format(icalTimeLayout))
+		line("DTSTART", event.Start.UTC().Format(icalTimeLayout))
+		line("DTEND", event.End.UTC().Format(icalTimeLayout))
+		line("SUMMARY", escapeICalText(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", escapeICalText(event.Description))
+		}
+		if event.Cancelled {
+			line("STATUS", "CANCELLED")
+			line("TRANSP", "TRANSPARENT")
+		} else {
+			line("STATUS", "CONFIRMED")
+			line("TRANSP", "OPAQUE")
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return []byte(b.String())
+}
+
+// writeICalLine folds a content line at 75 octets without splitting a UTF-8
+// character, and ends it with CRLF.
+func writeICalLine(b *strings.Builder, line string) {
+	limit := icalLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Baris lanjutan diawali spasi, jadi sisa ruangnya satu oktet lebih sedikit
+		limit = icalLineLimit - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
+
+var icalTextEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+func escapeICalText(s string) string {
+	return icalTextEscaper.Replace(s)
+}
+
+// icalDuration writes d as an RFC 5545 duration in whole minutes.
+func icalDuration(d time.Duration) string {
+	minutes := int(d / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+	return "PT" + strconv.Itoa(minutes) + "M"
+}
@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestWriteICalLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{
+			name: "short",
+			line: "SUMMARY:Terapi",
+			want: "SUMMARY:Terapi\r\n",
+		},
+		{
+			name: "exactly the limit",
+			line: strings.Repeat("a", 75),
+			want: strings.Repeat("a", 75) + "\r\n",
+		},
+		{
+			name: "one octet over",
+			line: strings.Repeat("a", 76),
+			want: strings.Repeat("a", 75) + "\r\n a\r\n",
+		},
+		{
+			name: "continuation lines hold 74 octets",
+			line: strings.Repeat("a", 75+74+1),
+			want: strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 74) + "\r\n a\r\n",
+		},
+		{
+			name: "two-octet rune across the limit",
+			line: strings.Repeat("a", 74) + "é",
+			want: strings.Repeat("a", 74) + "\r\n é\r\n",
+		},
+		{
+			name: "four-octet rune across the limit",
+			line: strings.Repeat("a", 73) + "😀b",
+			want: strings.Repeat("a", 73) + "\r\n 😀b\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			writeICalLine(&b, tt.line)
+			if got := b.String(); got != tt.want {
+				t.Errorf("writeICalLine(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteICalLineMultibyte(t *testing.T) {
+	lines := []string{
+		"DESCRIPTION:" + strings.Repeat("é", 100),
+		"DESCRIPTION:" + strings.Repeat("日本", 60),
+		"DESCRIPTION:" + strings.Repeat("a😀", 50),
+	}
+
+	for _, line := range lines {
+		var b strings.Builder
+		writeICalLine(&b, line)
+		folded := b.String()
+
+		physical := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n")
+		for i, p := range physical {
+			if len(p) > icalLineLimit {
+				t.Errorf("line %d of %q is %d octets", i, line, len(p))
+			}
+			if !utf8.ValidString(p) {
+				t.Errorf("line %d of %q splits a character: %q", i, line, p)
+			}
+		}
+		if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != line+"\r\n" {
+			t.Errorf("unfolding %q gave %q", line, unfolded)
+		}
+	}
+}
+
+func TestEscapeICalText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Terapi pijat", "Terapi pijat"},
+		{"Budi, Ani; Sari", `Budi\, Ani\; Sari`},
+		{`C:\terapi`, `C:\\terapi`},
+		{"baris satu\nbaris dua", `baris satu\nbaris dua`},
+		{"baris satu\r\nbaris dua", `baris satu\nbaris dua`},
+		{"baris satu\rbaris dua", `baris satu\nbaris dua`},
+		{`\;`, `\\\;`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeICalText(tt.text); got != tt.want {
+			t.Errorf("escapeICalText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestWriteICalendar(t *testing.T) {
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.FixedZone("WIB", 7*60*60))
+	feed := string(WriteICalendar("-//Test//EN", "Jadwal, Terapis", 15*time.Minute, []ICalEvent{{
+		UID:         "appointment-1@test",
+		Summary:     "Pijat; 60 menit",
+		Description: "Catatan\nkedua",
+		Start:       start,
+		End:         start.Add(time.Hour),
+		Modified:    start,
+	}}))
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:Jadwal\\, Terapis\r\n",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT15M\r\n",
+		"UID:appointment-1@test\r\n",
+		"DTSTART:20260105T020000Z\r\n",
+		"DTEND:20260105T030000Z\r\n",
+		"SUMMARY:Pijat\\; 60 menit\r\n",
+		"DESCRIPTION:Catatan\\nkedua\r\n",
+		"STATUS:CONFIRMED\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(feed, want) {
+			t.Errorf("feed does not contain %q:\n%s", want, feed)
+		}
+	}
+}
+
+func TestWriteICalendarStatus(t *testing.T) {
+	start := time.Date(2026, 1, 5, 2, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		cancelled bool
+		want      string
+	}{
+		{name: "booked", want: "STATUS:CONFIRMED\r\nTRANSP:OPAQUE\r\n"},
+		{name: "cancelled", cancelled: true, want: "STATUS:CANCELLED\r\nTRANSP:TRANSPARENT\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed := string(WriteICalendar("-//Test//EN", "Jadwal", time.Hour, []ICalEvent{{
+				UID:       "appointment-1@test",
+				Summary:   "Pijat",
+				Start:     start,
+				End:       start.Add(time.Hour),
+				Modified:  start,
+				Cancelled: tt.cancelled,
+			}}))
+			if !strings.Contains(feed, tt.want) {
+				t.Errorf("feed does not contain %q:\n%s", tt.want, feed)
+			}
+		})
+	}
+}
@@ -3,40 +3,48 @@ package utils
 import (
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"sim-clinic-api/internal/model"
 	"time"
 )
 
+// TokenPurposeMFA marks a short-lived token that can only be exchanged for an
+// access token after a second factor has been verified.
+const TokenPurposeMFA = "mfa"
+
+// MFATokenAudience is the audience of MFA challenge tokens. Access tokens have
+// none, so verifiers that check aud never mistake one for the other.
+const MFATokenAudience = "sim-clinic-mfa"
+
 type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	Purpose  string `json:"purpose,omitempty"`
+	// SessionID links an access token to its UserSession
+	SessionID string `json:"sid,omitempty"`
+	// TokenVersion is the user's TokenVersion when the token was issued
+	TokenVersion uint `json:"ver,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(user *model.User, jwtSecret string, expireTime time.Duration) (string, error) {
-	expirationTime := time.Now().Add(expireTime)
-
-	claims := &Claims{
-		UserID:   user.ID,
-		Username: user.Username,
-		Role:     user.Role.Name,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Subject:   fmt.Sprintf("%d", user.ID),
-		},
-	}
+func GenerateJWT(user *model.User, sessionID string, keys *KeySet, expireTime time.Duration) (string, error) {
+	claims := newClaims(user, "", expireTime)
+	claims.SessionID = sessionID
+	return keys.Sign(claims)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(jwtSecret))
+// GenerateMFAToken signs a challenge token. keys must not be the published
+// access token keys, otherwise other services verifying against the JWKS
+// could accept a half-authenticated login.
+func GenerateMFAToken(user *model.User, keys *KeySet, expireTime time.Duration) (string, error) {
+	claims := newClaims(user, TokenPurposeMFA, expireTime)
+	claims.Audience = jwt.ClaimStrings{MFATokenAudience}
+	return keys.Sign(claims)
 }
 
-func ParseToken(tokenString, jwtSecret string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(jwtSecret), nil
-	})
+func ParseToken(tokenString string, keys *KeySet, options ...jwt.ParserOption) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keys.Keyfunc, options...)
 
 	if err != nil {
 		return nil, err
@@ -49,3 +57,34 @@ func ParseToken(tokenString, jwtSecret string) (*Claims, error) {
 
 	return claims, nil
 }
+
+// ParseMFAToken verifies a challenge token made by GenerateMFAToken.
+func ParseMFAToken(tokenString string, keys *KeySet) (*Claims, error) {
+	claims, err := ParseToken(tokenString, keys, jwt.WithAudience(MFATokenAudience))
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != TokenPurposeMFA {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
+
+func newClaims(user *model.User, purpose string, expireTime time.Duration) *Claims {
+	expirationTime := time.Now().Add(expireTime)
+
+	return &Claims{
+		UserID:       user.ID,
+		Username:     user.Username,
+		Role:         user.Role.Name,
+		Purpose:      purpose,
+		TokenVersion: user.TokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Subject:   fmt.Sprintf("%d", user.ID),
+			ID:        uuid.New().String(),
+		},
+	}
+}
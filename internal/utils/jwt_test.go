@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"sim-clinic-api/internal/model"
+)
+
+func TestGenerateJWTCarriesTokenVersion(t *testing.T) {
+	keys := NewHMACKeySet("test-secret")
+	user := &model.User{ID: 3, Username: "terapis", TokenVersion: 2}
+
+	token, err := GenerateJWT(user, "session", keys, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ParseToken(token, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.TokenVersion != user.TokenVersion {
+		t.Errorf("TokenVersion = %d, want %d", claims.TokenVersion, user.TokenVersion)
+	}
+}
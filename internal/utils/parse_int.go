@@ -0,0 +1,11 @@
+package utils
+
+import "strconv"
+
+func ParseInt(intStr string, defaultValue int) int {
+	value, err := strconv.Atoi(intStr)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
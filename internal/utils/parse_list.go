@@ -0,0 +1,14 @@
+package utils
+
+import "strings"
+
+// ParseList splits a comma separated value, dropping empty items.
+func ParseList(listStr string) []string {
+	var items []string
+	for _, item := range strings.Split(listStr, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
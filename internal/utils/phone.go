@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+// DefaultPhoneCountryCode is assumed for numbers written without a country code.
+const DefaultPhoneCountryCode = "62"
+
+var ErrInvalidPhoneNumber = errors.New("invalid phone number")
+
+// NormalizePhoneNumber converts a phone number as typed at the front desk to
+// E.164, e.g. "0812-3456-7890", "62812 3456 7890" and "+6281234567890" all
+// become "+6281234567890". Numbers without a country code are taken to be
+// Indonesian; other countries must be written with "+" or "00", since a bare
+// "21..." or "65..." could be either a local or a foreign number.
+func NormalizePhoneNumber(raw string) (string, error) {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(raw) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+			// pemisah yang umum diketik, dibuang
+		default:
+			return "", ErrInvalidPhoneNumber
+		}
+	}
+	number := b.String()
+
+	switch {
+	case strings.HasPrefix(number, "+"):
+		number = number[1:]
+	case strings.HasPrefix(number, "00"):
+		// Awalan panggilan internasional
+		number = number[2:]
+	case strings.HasPrefix(number, "0"):
+		number = DefaultPhoneCountryCode + number[1:]
+	case strings.HasPrefix(number, DefaultPhoneCountryCode):
+	case strings.HasPrefix(number, "8"):
+		// Nomor seluler yang ditulis tanpa 0 di depan
+		number = DefaultPhoneCountryCode + number
+	default:
+		return "", ErrInvalidPhoneNumber
+	}
+
+	// E.164: maksimal 15 digit, tanpa 0 setelah tanda plus
+	if len(number) < 8 || len(number) > 15 || number[0] == '0' {
+		return "", ErrInvalidPhoneNumber
+	}
+	return "+" + number, nil
+}
@@ -0,0 +1,49 @@
+package utils
+
+import "testing"
+
+func TestNormalizePhoneNumber(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "0812-3456-7890", want: "+6281234567890"},
+		{raw: "(0812) 3456.7890", want: "+6281234567890"},
+		{raw: "62812 3456 7890", want: "+6281234567890"},
+		{raw: "+6281234567890", want: "+6281234567890"},
+		{raw: " +62 812 3456 7890 ", want: "+6281234567890"},
+		{raw: "812 3456 7890", want: "+6281234567890"},
+		{raw: "021 555 1234", want: "+62215551234"},
+		{raw: "+65 9123 4567", want: "+6591234567"},
+		{raw: "0065 9123 4567", want: "+6591234567"},
+		{raw: "+1 415 555 0100", want: "+14155550100"},
+
+		// Tanpa 0 atau kode negara, tidak jelas nomor lokal atau luar negeri
+		{raw: "21 555 1234", wantErr: true},
+		{raw: "65 9123 4567", wantErr: true},
+		{raw: "1 415 555 0100", wantErr: true},
+		{raw: "7123 4567 890", wantErr: true},
+		{raw: "9123 4567", wantErr: true},
+
+		{raw: "", wantErr: true},
+		{raw: "0812", wantErr: true},
+		{raw: "+62 8123 4567 8901 2345", wantErr: true},
+		{raw: "+0812 3456 7890", wantErr: true},
+		{raw: "0812-3456-789a", wantErr: true},
+		{raw: "0812+3456", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizePhoneNumber(tt.raw)
+		if tt.wantErr {
+			if err != ErrInvalidPhoneNumber {
+				t.Errorf("NormalizePhoneNumber(%q) = %q, %v; want %v", tt.raw, got, err, ErrInvalidPhoneNumber)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizePhoneNumber(%q) = %q, %v; want %q", tt.raw, got, err, tt.want)
+		}
+	}
+}
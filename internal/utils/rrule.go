@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxRecurrenceOccurrences caps how many occurrences a recurrence rule may produce.
+const MaxRecurrenceOccurrences = 52
+
+var rruleWeekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// RecurrenceRule is the subset of an RFC 5545 RRULE used for therapy
+// programs: FREQ=WEEKLY with an optional INTERVAL (1 or 2), BYDAY, and
+// either COUNT or UNTIL.
+type RecurrenceRule struct {
+	Interval int
+	Count    int
+	Until    time.Time
+	ByDay    []time.Weekday
+}
+
+// ParseRecurrenceRule parses a rule such as "FREQ=WEEKLY;INTERVAL=2;COUNT=8"
+// or "RRULE:FREQ=WEEKLY;BYDAY=MO,TH;UNTIL=20261231". A date-only UNTIL is
+// inclusive of that whole day in loc.
+func ParseRecurrenceRule(rule string, loc *time.Location) (*RecurrenceRule, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if rule == "" {
+		return nil, errors.New("rrule is empty")
+	}
+
+	r := &RecurrenceRule{Interval: 1}
+	freq := ""
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("rrule: invalid part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			freq = strings.ToUpper(value)
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 || interval > 2 {
+				return nil, errors.New("rrule: INTERVAL must be 1 (weekly) or 2 (biweekly)")
+			}
+			r.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 1 {
+				return nil, errors.New("rrule: COUNT must be a positive number")
+			}
+			r.Count = count
+		case "UNTIL":
+			until, err := parseRRuleUntil(value, loc)
+			if err != nil {
+				return nil, err
+			}
+			r.Until = until
+		case "BYDAY":
+			for _, day := range strings.Split(strings.ToUpper(value), ",") {
+				weekday, ok := rruleWeekdays[day]
+				if !ok {
+					return nil, fmt.Errorf("rrule: unsupported BYDAY value %q", day)
+				}
+				// Hari yang diulang akan membuat dua janji di hari yang sama
+				if !slices.Contains(r.ByDay, weekday) {
+					r.ByDay = append(r.ByDay, weekday)
+				}
+			}
+		case "WKST":
+			if strings.ToUpper(value) != "MO" {
+				return nil, errors.New("rrule: only WKST=MO is supported")
+			}
+		default:
+			return nil, fmt.Errorf("rrule: %s is not supported", key)
+		}
+	}
+
+	if freq != "WEEKLY" {
+		return nil, errors.New("rrule: only FREQ=WEEKLY is supported")
+	}
+	if (r.Count == 0) == r.Until.IsZero() {
+		return nil, errors.New("rrule: exactly one of COUNT or UNTIL is required")
+	}
+	if r.Count > MaxRecurrenceOccurrences {
+		return nil, fmt.Errorf("rrule: COUNT cannot exceed %d", MaxRecurrenceOccurrences)
+	}
+	return r, nil
+}
+
+func parseRRuleUntil(value string, loc *time.Location) (time.Time, error) {
+	if until, err := time.Parse("20060102T150405Z", value); err == nil {
+		return until, nil
+	}
+	if until, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+		return until, nil
+	}
+	if day, err := time.ParseInLocation("20060102", value, loc); err == nil {
+		// Tanggal saja berarti sampai akhir hari itu
+		return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return time.Time{}, errors.New("rrule: UNTIL must be formatted as YYYYMMDD or YYYYMMDDTHHMMSSZ")
+}
+
+// Occurrences returns the start times produced by the rule on or after start;
+// without BYDAY the first one is start itself. Every occurrence keeps the
+// wall-clock time of start in its location.
+// It fails if the rule would produce more than MaxRecurrenceOccurrences.
+func (r *RecurrenceRule) Occurrences(start time.Time) ([]time.Time, error) {
+	days := r.ByDay
+	if len(days) == 0 {
+		days = []time.Weekday{start.Weekday()}
+	}
+	offsets := make([]int, 0, len(days))
+	for _, day := range days {
+		// Minggu dimulai hari Senin (WKST=MO)
+		offsets = append(offsets, (int(day)+6)%7)
+	}
+	sort.Ints(offsets)
+
+	weekStart := start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	var occurrences []time.Time
+	for week := 0; ; week += r.Interval {
+		for _, offset := range offsets {
+			occurrence := weekStart.AddDate(0, 0, week*7+offset)
+			if occurrence.Before(start) {
+				continue
+			}
+			if !r.Until.IsZero() && occurrence.After(r.Until) {
+				return occurrences, nil
+			}
+			if len(occurrences) == MaxRecurrenceOccurrences {
+				return nil, fmt.Errorf("rrule: produces more than %d occurrences", MaxRecurrenceOccurrences)
+			}
+			occurrences = append(occurrences, occurrence)
+			if r.Count > 0 && len(occurrences) == r.Count {
+				return occurrences, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRecurrenceRuleRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+	}{
+		{"empty", ""},
+		{"not weekly", "FREQ=DAILY;COUNT=3"},
+		{"no limit", "FREQ=WEEKLY"},
+		{"count and until", "FREQ=WEEKLY;COUNT=3;UNTIL=20260301"},
+		{"interval above two", "FREQ=WEEKLY;INTERVAL=3;COUNT=3"},
+		{"zero count", "FREQ=WEEKLY;COUNT=0"},
+		{"count above cap", "FREQ=WEEKLY;COUNT=53"},
+		{"unknown day", "FREQ=WEEKLY;BYDAY=XX;COUNT=3"},
+		{"bad until", "FREQ=WEEKLY;UNTIL=2026-03-01"},
+		{"unsupported part", "FREQ=WEEKLY;COUNT=3;BYMONTH=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseRecurrenceRule(tt.rule, time.UTC); err == nil {
+				t.Errorf("ParseRecurrenceRule(%q) succeeded, want error", tt.rule)
+			}
+		})
+	}
+}
+
+func TestRecurrenceRuleOccurrences(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	// Senin, 5 Januari 2026 pukul 09.00
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, jakarta)
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2026, month, d, 9, 0, 0, 0, jakarta)
+	}
+
+	tests := []struct {
+		name string
+		rule string
+		want []time.Time
+	}{
+		{
+			name: "count",
+			rule: "FREQ=WEEKLY;COUNT=3",
+			want: []time.Time{day(1, 5), day(1, 12), day(1, 19)},
+		},
+		{
+			name: "date-only until includes that day",
+			rule: "RRULE:FREQ=WEEKLY;UNTIL=20260119",
+			want: []time.Time{day(1, 5), day(1, 12), day(1, 19)},
+		},
+		{
+			name: "utc until before the last start",
+			rule: "FREQ=WEEKLY;UNTIL=20260119T015959Z",
+			want: []time.Time{day(1, 5), day(1, 12)},
+		},
+		{
+			name: "biweekly",
+			rule: "FREQ=WEEKLY;INTERVAL=2;COUNT=3",
+			want: []time.Time{day(1, 5), day(1, 19), day(2, 2)},
+		},
+		{
+			name: "several days a week",
+			rule: "FREQ=WEEKLY;BYDAY=TH,MO;COUNT=4",
+			want: []time.Time{day(1, 5), day(1, 8), day(1, 12), day(1, 15)},
+		},
+		{
+			name: "repeated day counts once",
+			rule: "FREQ=WEEKLY;BYDAY=MO,MO;COUNT=3",
+			want: []time.Time{day(1, 5), day(1, 12), day(1, 19)},
+		},
+		{
+			name: "days before start are skipped",
+			rule: "FREQ=WEEKLY;BYDAY=SU;COUNT=2",
+			want: []time.Time{day(1, 11), day(1, 18)},
+		},
+		{
+			name: "biweekly several days until",
+			rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;UNTIL=20260121",
+			want: []time.Time{day(1, 5), day(1, 7), day(1, 19), day(1, 21)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(tt.rule, jakarta)
+			if err != nil {
+				t.Fatalf("ParseRecurrenceRule(%q): %v", tt.rule, err)
+			}
+			got, err := rule.Occurrences(start)
+			if err != nil {
+				t.Fatalf("Occurrences: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Occurrences = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("occurrence %d = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRecurrenceRuleOccurrencesCap(t *testing.T) {
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		rule    string
+		want    int
+		wantErr bool
+	}{
+		{name: "count at cap", rule: "FREQ=WEEKLY;COUNT=52", want: 52},
+		{name: "until at cap", rule: "FREQ=WEEKLY;UNTIL=20261228", want: 52},
+		{name: "until past cap", rule: "FREQ=WEEKLY;UNTIL=20270104", wantErr: true},
+		{name: "several days past cap", rule: "FREQ=WEEKLY;BYDAY=MO,TH;UNTIL=20260731", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(tt.rule, time.UTC)
+			if err != nil {
+				t.Fatalf("ParseRecurrenceRule(%q): %v", tt.rule, err)
+			}
+			got, err := rule.Occurrences(start)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Occurrences produced %d occurrences, want error", len(got))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Occurrences: %v", err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("Occurrences produced %d occurrences, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"sim-clinic-api/internal/config"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const listenRetryDelay = 5 * time.Second
+
+// Notify sends payload to every connection listening on channel.
+func Notify(db *gorm.DB, channel, payload string) error {
+	return db.Exec("SELECT pg_notify(?, ?)", channel, payload).Error
+}
+
+// Listen subscribes to a Postgres NOTIFY channel on a dedicated connection and
+// calls handle for every payload until ctx is cancelled. Notifications sent
+// while the connection is down are lost, so onReconnect is called after every
+// reconnect to let callers drop state that may be stale.
+func Listen(ctx context.Context, cfg *config.Config, channel string, handle func(payload string), onReconnect func()) {
+	connected := false
+	for ctx.Err() == nil {
+		conn, err := pgx.Connect(ctx, dsn(cfg))
+		if err == nil {
+			_, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize())
+		}
+		if err != nil {
+			logrus.Warnf("Listening on %s failed: %v", channel, err)
+			if conn != nil {
+				conn.Close(context.Background())
+			}
+			sleepContext(ctx, listenRetryDelay)
+			continue
+		}
+
+		if connected && onReconnect != nil {
+			onReconnect()
+		}
+		connected = true
+		logrus.Infof("Listening for notifications on %s", channel)
+
+		for {
+			notification, err := conn.WaitForNotification(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					logrus.Warnf("Lost notification connection on %s: %v", channel, err)
+				}
+				break
+			}
+			handle(notification.Payload)
+		}
+
+		conn.Close(context.Background())
+		sleepContext(ctx, listenRetryDelay)
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -13,11 +14,14 @@ import (
 	"gorm.io/gorm/logger"
 )
 
-func NewPostgresConnection(cfg *config.Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf(
+func dsn(cfg *config.Config) string {
+	return fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBSSLMode,
 	)
+}
+
+func NewPostgresConnection(cfg *config.Config) (*gorm.DB, error) {
 
 	// Configure GORM logger
 	gormLogger := logger.New(
@@ -29,7 +33,7 @@ func NewPostgresConnection(cfg *config.Config) (*gorm.DB, error) {
 		},
 	)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(postgres.Open(dsn(cfg)), &gorm.Config{
 		Logger: gormLogger,
 	})
 	if err != nil {
@@ -56,41 +60,278 @@ func getLogLevel(level string) logger.LogLevel {
 }
 
 func AutoMigrate(db *gorm.DB) error {
-	err := db.AutoMigrate(
+	// pg_trgm dipakai untuk mencari nama customer yang mirip
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		return err
+	}
+
+	// Kode "Code" lama dikosongkan agar unique index bisa dibuat, lalu diisi ulang oleh cmd/migrate
+	if db.Migrator().HasTable(&model.Customer{}) {
+		err := db.Exec("UPDATE customers SET code_register = NULL WHERE code_register IN ('', 'Code')").Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Nama role yang sudah dihapus boleh dipakai lagi; index unik lama tanpa WHERE dibuat ulang oleh AutoMigrate
+	err := db.Exec(`
+		DO $$
+		BEGIN
+			IF EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'idx_roles_name' AND indexdef NOT LIKE '% WHERE %') THEN
+				DROP INDEX idx_roles_name;
+			END IF;
+		END $$`).Error
+	if err != nil {
+		return err
+	}
+
+	err = db.AutoMigrate(
+		&model.Permission{},
 		&model.Role{},
 		&model.User{},
 		&model.BlacklistedToken{},
+		&model.RefreshToken{},
+		&model.PasswordResetToken{},
+		&model.LoginAttempt{},
+		&model.MFARecoveryCode{},
+		&model.UserSession{},
+		&model.APIKey{},
 		&model.LayananTerapi{},
 		&model.RiwayatPenyakit{},
 		&model.TeknikTerapi{},
+		&model.Counter{},
 		&model.Customer{},
+		&model.CustomerAlias{},
+		&model.CustomerMergeLog{},
+		&model.CustomerMedicalHistory{},
+		&model.TreatmentSession{},
+		&model.ClinicalNote{},
+		&model.ClinicalNoteAddendum{},
+		&model.WorkingHour{},
+		&model.ScheduleException{},
+		&model.ResourceType{},
+		&model.Resource{},
+		&model.ResourceRequirement{},
+		&model.AppointmentSeries{},
+		&model.Appointment{},
+		&model.AppointmentResource{},
+		&model.AppointmentEvent{},
+		&model.BookingPolicy{},
+		&model.CalendarToken{},
+		&model.OutboxMessage{},
+		&model.QueueEntry{},
+		&model.QueueEvent{},
 	)
 
 	if err != nil {
 		return err
 	}
 
-	// Seed initial roles
-	return seedRoles(db)
+	err = db.Exec("CREATE INDEX IF NOT EXISTS idx_customers_customer_name_trgm ON customers USING gin (customer_name gin_trgm_ops)").Error
+	if err != nil {
+		return err
+	}
+
+	// Janji temu satu terapis tidak boleh bertumpuk, dijaga langsung oleh database.
+	// Deferrable agar satu seri bisa digeser dalam satu transaksi; constraint lama dibuat ulang
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS btree_gist").Error; err != nil {
+		return err
+	}
+	err = db.Exec(`
+		DO $$
+		BEGIN
+			IF EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'appointments_therapist_no_overlap' AND NOT condeferrable) THEN
+				ALTER TABLE appointments DROP CONSTRAINT appointments_therapist_no_overlap;
+			END IF;
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'appointments_therapist_no_overlap') THEN
+				ALTER TABLE appointments ADD CONSTRAINT appointments_therapist_no_overlap
+					EXCLUDE USING gist (therapist_id WITH =, tstzrange(start_at, end_at, '[)') WITH &&)
+					WHERE (status NOT IN ('cancelled', 'no_show'))
+					DEFERRABLE INITIALLY IMMEDIATE;
+			END IF;
+		END $$`).Error
+	if err != nil {
+		return err
+	}
+
+	// Satu pasien hanya boleh sekali menunggu per layanan per hari, juga saat dua meja mendaftarkan bersamaan.
+	// Duplikat dari sebelum index ini ada dilewati, tiket pertama yang dipertahankan
+	err = db.Exec(`
+		UPDATE queue_entries SET status = ?, updated_at = NOW()
+		WHERE status IN ? AND id NOT IN (
+			SELECT MIN(id) FROM queue_entries WHERE status IN ?
+			GROUP BY queue_date, layanan_terapi_id, customer_id
+		)`, model.QueueSkipped, activeQueueStatuses, activeQueueStatuses).Error
+	if err != nil {
+		return err
+	}
+	err = db.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_queue_entries_active_customer
+		ON queue_entries (queue_date, layanan_terapi_id, customer_id)
+		WHERE status IN ('waiting', 'called')`).Error
+	if err != nil {
+		return err
+	}
+
+	// Pembatalan dari sebelum ada cancelled_at: updated_at adalah perkiraan terbaik
+	err = db.Exec("UPDATE appointments SET cancelled_at = updated_at WHERE status = ? AND cancelled_at IS NULL", model.AppointmentCancelled).Error
+	if err != nil {
+		return err
+	}
+
+	// Seed permissions and initial roles
+	added, err := seedPermissions(db)
+	if err != nil {
+		return err
+	}
+	return seedRoles(db, added)
 }
 
-func seedRoles(db *gorm.DB) error {
-	roles := []model.Role{
-		{Name: "super_admin", Description: "Super Administrator"},
-		{Name: "admin", Description: "Administrator"},
-		{Name: "user", Description: "Regular User"},
+// activeQueueStatuses are the queue statuses covered by
+// idx_queue_entries_active_customer.
+var activeQueueStatuses = []string{model.QueueWaiting, model.QueueCalled}
+
+// CheckMigrated reports an error when the database is missing permissions
+// that this version knows about, which means cmd/migrate has not been run.
+// Without them every route would be denied.
+func CheckMigrated(db *gorm.DB) error {
+	names := make([]string, len(model.Permissions))
+	for i, permission := range model.Permissions {
+		names[i] = permission.Name
 	}
 
-	for _, role := range roles {
-		var existingRole model.Role
-		result := db.Where("name = ?", role.Name).First(&existingRole)
+	if !db.Migrator().HasTable(&model.Permission{}) {
+		return errors.New("database is not migrated, run go run ./cmd/migrate")
+	}
+	var count int64
+	if err := db.Model(&model.Permission{}).Where("name IN ?", names).Count(&count).Error; err != nil {
+		return err
+	}
+	if count < int64(len(names)) {
+		return errors.New("database schema is out of date, run go run ./cmd/migrate")
+	}
+	return nil
+}
+
+// seedPermissions creates missing permissions, brings the descriptions of
+// existing ones in line with the code and returns the names of the ones it
+// created.
+func seedPermissions(db *gorm.DB) (map[string]bool, error) {
+	added := make(map[string]bool)
+	for _, permission := range model.Permissions {
+		var existing model.Permission
+		result := db.Where("name = ?", permission.Name).
+			Attrs(model.Permission{Description: permission.Description}).
+			FirstOrCreate(&existing)
 		if result.Error != nil {
-			if result.Error == gorm.ErrRecordNotFound {
-				if err := db.Create(&role).Error; err != nil {
-					return err
+			return nil, result.Error
+		}
+		if result.RowsAffected > 0 {
+			added[permission.Name] = true
+			continue
+		}
+
+		// Assign akan ikut menghitung update sebagai baris baru, jadi deskripsi diperbarui terpisah
+		if existing.Description != permission.Description {
+			err := db.Model(&existing).Update("description", permission.Description).Error
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return added, nil
+}
+
+// defaultRoles maps the seeded roles onto their initial permission sets.
+// super_admin always receives every permission; the other roles get their
+// full set once, when first marked as system roles, and afterwards only
+// permissions that did not exist before, so edits made through /api/roles
+// survive restarts.
+var defaultRoles = []struct {
+	Role        model.Role
+	Permissions []string
+}{
+	{
+		Role: model.Role{Name: "super_admin", Description: "Super Administrator", Level: 100},
+	},
+	{
+		Role: model.Role{Name: "admin", Description: "Administrator", Level: 50},
+		Permissions: []string{
+			model.PermUserRead, model.PermUserWrite, model.PermUserDelete,
+			model.PermRoleRead,
+			model.PermCustomerRead, model.PermCustomerWrite, model.PermCustomerDelete, model.PermCustomerMerge,
+			model.PermMedicalRead, model.PermMedicalWrite, model.PermMedicalAmend,
+			model.PermAppointmentRead, model.PermAppointmentWrite, model.PermAppointmentDesk,
+			model.PermScheduleManage, model.PermBookingPolicyManage,
+			model.PermCalendarAll, model.PermResourceManage,
+			model.PermQueueRead, model.PermQueueWrite,
+			model.PermNotificationManage,
+			model.PermMasterRead, model.PermMasterWrite,
+		},
+	},
+	{
+		Role: model.Role{Name: "user", Description: "Regular User", Level: 10},
+		Permissions: []string{
+			model.PermCustomerRead, model.PermCustomerWrite,
+			model.PermTherapist,
+			model.PermAppointmentRead, model.PermAppointmentWrite, model.PermAppointmentDesk,
+			model.PermQueueRead, model.PermQueueWrite,
+			model.PermMasterRead,
+		},
+	},
+}
+
+func seedRoles(db *gorm.DB, addedPermissions map[string]bool) error {
+	for _, def := range defaultRoles {
+		var role model.Role
+		result := db.Where("name = ?", def.Role.Name).First(&role)
+		if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
+			return result.Error
+		}
+
+		isNew := result.Error == gorm.ErrRecordNotFound
+		if isNew {
+			role = def.Role
+		}
+
+		// Role lama dari sebelum ada permission ikut diberi set bawaan
+		assignDefaults := isNew || !role.IsSystem
+		role.IsSystem = true
+		if role.Level == 0 {
+			role.Level = def.Role.Level
+		}
+
+		names := def.Permissions
+		if !assignDefaults {
+			names = nil
+			for _, name := range def.Permissions {
+				if addedPermissions[name] {
+					names = append(names, name)
 				}
-			} else {
-				return result.Error
+			}
+		}
+
+		var permissions []model.Permission
+		query := db
+		if def.Role.Name != "super_admin" {
+			query = query.Where("name IN ?", names)
+		}
+		if err := query.Find(&permissions).Error; err != nil {
+			return err
+		}
+
+		if err := db.Omit("Permissions").Save(&role).Error; err != nil {
+			return err
+		}
+
+		switch {
+		case assignDefaults || def.Role.Name == "super_admin":
+			if err := db.Model(&role).Association("Permissions").Replace(permissions); err != nil {
+				return err
+			}
+		case len(permissions) > 0:
+			if err := db.Model(&role).Association("Permissions").Append(permissions); err != nil {
+				return err
 			}
 		}
 	}